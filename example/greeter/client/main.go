@@ -14,7 +14,7 @@ func main() {
 	svc := greeterService{}
 	greeter.RegisterGreeter(&svc)
 
-	if err := rsocket.Connect(); err != nil {
+	if err := rsocket.Connect(rsocket.Handler(context.Background())); err != nil {
 		panic(err)
 	}
 }
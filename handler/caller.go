@@ -16,6 +16,10 @@ import (
 var _ = (invoke.Caller)((*Handler)(nil))
 
 func (i *Handler) ImportRequestResponse(namespace, operation string) uint32 {
+	if i.opTable == nil {
+		// The peer resolves our imports from the SETUP frame.
+		return invoke.ImportRequestResponse(namespace, operation)
+	}
 	for _, op := range i.opTable {
 		if op.Direction == operations.Export &&
 			op.Type == operations.RequestResponse &&
@@ -28,6 +32,10 @@ func (i *Handler) ImportRequestResponse(namespace, operation string) uint32 {
 }
 
 func (i *Handler) ImportFireAndForget(namespace, operation string) uint32 {
+	if i.opTable == nil {
+		// The peer resolves our imports from the SETUP frame.
+		return invoke.ImportFireAndForget(namespace, operation)
+	}
 	for _, op := range i.opTable {
 		if op.Direction == operations.Export &&
 			op.Type == operations.FireAndForget &&
@@ -40,6 +48,10 @@ func (i *Handler) ImportFireAndForget(namespace, operation string) uint32 {
 }
 
 func (i *Handler) ImportRequestStream(namespace, operation string) uint32 {
+	if i.opTable == nil {
+		// The peer resolves our imports from the SETUP frame.
+		return invoke.ImportRequestStream(namespace, operation)
+	}
 	for _, op := range i.opTable {
 		if op.Direction == operations.Export &&
 			op.Type == operations.RequestStream &&
@@ -52,6 +64,10 @@ func (i *Handler) ImportRequestStream(namespace, operation string) uint32 {
 }
 
 func (i *Handler) ImportRequestChannel(namespace, operation string) uint32 {
+	if i.opTable == nil {
+		// The peer resolves our imports from the SETUP frame.
+		return invoke.ImportRequestChannel(namespace, operation)
+	}
 	for _, op := range i.opTable {
 		if op.Direction == operations.Export &&
 			op.Type == operations.RequestChannel &&
@@ -64,6 +80,9 @@ func (i *Handler) ImportRequestChannel(namespace, operation string) uint32 {
 }
 
 func (i *Handler) RequestResponse(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+	if i.closing.Load() {
+		return mono.Error[payload.Payload](ErrConnectionClosing)
+	}
 	return proxy.Mono(ctx, frames.RequestPayload{
 		FrameType: frames.FrameTypeRequestResponse,
 		StreamID:  i.getNextStreamID(),
@@ -71,10 +90,13 @@ func (i *Handler) RequestResponse(ctx context.Context, p payload.Payload) mono.M
 		Data:      p.Data(),
 		Complete:  true,
 		InitialN:  1,
-	}, i.SendFrame, i.registerStream)
+	}, i.sendRequestFrame, i.registerStream)
 }
 
 func (i *Handler) FireAndForget(ctx context.Context, p payload.Payload) {
+	if i.closing.Load() {
+		return
+	}
 	i.SendFrame(&frames.RequestPayload{
 		FrameType: frames.FrameTypeRequestFNF,
 		StreamID:  i.getNextStreamID(),
@@ -86,23 +108,29 @@ func (i *Handler) FireAndForget(ctx context.Context, p payload.Payload) {
 }
 
 func (i *Handler) RequestStream(ctx context.Context, p payload.Payload) flux.Flux[payload.Payload] {
+	if i.closing.Load() {
+		return flux.Error[payload.Payload](ErrConnectionClosing)
+	}
 	return proxy.Flux(ctx, frames.RequestPayload{
 		FrameType: frames.FrameTypeRequestStream,
 		StreamID:  i.getNextStreamID(),
 		Metadata:  p.Metadata(),
 		Data:      p.Data(),
 		InitialN:  rx.RequestMax,
-	}, nil, i.SendFrame, i.registerStream)
+	}, nil, i.sendRequestFrame, i.registerStream)
 }
 
 func (i *Handler) RequestChannel(ctx context.Context, p payload.Payload, in flux.Flux[payload.Payload]) flux.Flux[payload.Payload] {
+	if i.closing.Load() {
+		return flux.Error[payload.Payload](ErrConnectionClosing)
+	}
 	return proxy.Flux(ctx, frames.RequestPayload{
 		FrameType: frames.FrameTypeRequestChannel,
 		StreamID:  i.getNextStreamID(),
 		Metadata:  p.Metadata(),
 		Data:      p.Data(),
 		InitialN:  rx.RequestMax,
-	}, in, i.SendFrame, i.registerStream)
+	}, in, i.sendRequestFrame, i.registerStream)
 }
 
 func (i *Handler) getNextStreamID() uint32 {
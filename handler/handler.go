@@ -4,24 +4,38 @@ import (
 	"context"
 	"encoding/binary"
 	"errors"
-	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/nanobus/iota/go/internal/frames"
 	"github.com/nanobus/iota/go/internal/socket"
 	"github.com/nanobus/iota/go/invoke"
+	// Registers the decoder of structured APPLICATION_ERROR data.
+	_ "github.com/nanobus/iota/go/invoke/apperror"
+	"github.com/nanobus/iota/go/metadata"
 	"github.com/nanobus/iota/go/operations"
 	"github.com/nanobus/iota/go/payload"
 	"github.com/nanobus/iota/go/proxy"
 	"github.com/nanobus/iota/go/rx"
 	"github.com/nanobus/iota/go/rx/flux"
 	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/tap"
 )
 
 type FrameSender func(f frames.Frame) error
 
+// ErrConnectionClosing is returned for requests made after the peer
+// announced that the connection is closing.
+var ErrConnectionClosing = errors.New("connection closing")
+
+// drainInterval is how often Shutdown checks for outstanding streams.
+const drainInterval = 10 * time.Millisecond
+
 type Handler struct {
 	ctx          context.Context
-	sendFrame    FrameSender
+	frameSender  FrameSender
 	streamIDs    uint64
 	nextStreamID func() (id uint32, firstLoop bool)
 
@@ -41,7 +55,32 @@ type Handler struct {
 	importedRS   []invoke.RequestStreamHandler
 	importedRC   []invoke.RequestChannelHandler
 
+	// extensions maps extended types to the handlers of EXT frames.
+	extensions map[uint32]invoke.ExtensionHandler
+
 	opTable operations.Table
+
+	// exports maps the operations the peer imports, as announced in its
+	// SETUP frame, to the indexes of the local exports.
+	exports map[operationKey]uint32
+
+	// closing is set when either side announced that the connection
+	// is closing. No new requests are started.
+	closing atomic.Bool
+	// starting counts incoming requests that are not registered yet.
+	starting atomic.Int32
+
+	// mimeTypes are the MIME types of request metadata and data.
+	// Composite metadata can route requests by name.
+	mimeTypes metadata.MimeTypes
+
+	// observer sees every frame received and sent.
+	observer tap.FrameObserver
+}
+
+type operationKey struct {
+	requestType operations.RequestType
+	index       uint32
 }
 
 // type fragmentedPayload struct {
@@ -71,14 +110,96 @@ func New(ctx context.Context, mode Mode) *Handler {
 	return &h
 }
 
+// SetMimeTypes sets the MIME types of request metadata and data.
+// Servers use the MIME types sent by the client in SETUP. Handler
+// functions get them from the context with metadata.MimeTypesFromContext.
+func (i *Handler) SetMimeTypes(mimeTypes metadata.MimeTypes) {
+	if mimeTypes.Data == "" {
+		mimeTypes.Data = DefaultDataMimeType
+	}
+	i.mimeTypes = mimeTypes
+	i.ctx = metadata.WithMimeTypes(i.ctx, mimeTypes)
+}
+
+// MimeTypes returns the MIME types of request metadata and data.
+func (i *Handler) MimeTypes() metadata.MimeTypes {
+	return i.mimeTypes
+}
+
 func (i *Handler) SetFrameSender(sendFrame func(f frames.Frame) error) {
-	i.sendFrame = sendFrame
+	i.frameSender = sendFrame
+}
+
+// SetFrameObserver sets an observer that sees every frame the handler
+// receives and sends. It must be called before frames are exchanged.
+// Transports that observe frames themselves, such as rsocket.Transport,
+// also see the frames they handle without passing them to the handler.
+func (i *Handler) SetFrameObserver(observer tap.FrameObserver) {
+	i.observer = observer
+}
+
+func (i *Handler) sendFrame(f frames.Frame) error {
+	if i.observer != nil {
+		i.observer.ObserveFrame(tap.NewEvent(tap.Outbound, f))
+	}
+	return i.frameSender(f)
 }
 
 func (i *Handler) Close() error {
 	return nil
 }
 
+// Closing returns true once the connection is closing
+// and new requests are not accepted.
+func (i *Handler) Closing() bool {
+	return i.closing.Load()
+}
+
+// Shutdown sends an ERROR frame with ErrCodeConnectionClose to the peer
+// so that it stops sending requests, then waits for the outstanding
+// streams to finish. New incoming requests are rejected. If ctx is done
+// before all streams finish, ctx.Err() is returned.
+func (i *Handler) Shutdown(ctx context.Context) error {
+	if !i.closing.Swap(true) {
+		if err := i.sendFrame(&frames.Error{
+			Code: frames.ErrCodeConnectionClose,
+			Data: ErrConnectionClosing.Error(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+	for {
+		if i.outstanding() == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// outstanding returns the number of active streams.
+func (i *Handler) outstanding() int {
+	return int(i.starting.Load()) + i.guestStreams.Size() + i.hostStreams.Size()
+}
+
+// Terminate fails all active streams with err.
+// It is called when the underlying connection is lost
+// so that requesters are not left waiting.
+func (i *Handler) Terminate(err error) {
+	for _, s := range i.guestStreams.Clear() {
+		s.OnError(err)
+	}
+	for _, s := range i.hostStreams.Clear() {
+		s.OnError(err)
+	}
+}
+
 func (i *Handler) registerStream(s proxy.Stream) {
 	if s.StreamID()&1 == 1 {
 		i.guestStreams.Add(s)
@@ -107,40 +228,116 @@ func (i *Handler) SendFrame(f frames.Frame) error {
 	return i.sendFrame(f)
 }
 
+// sendRequestFrame sends frames for requests made by this side.
+// A canceled stream is no longer active.
+func (i *Handler) sendRequestFrame(f frames.Frame) error {
+	if c, ok := f.(*frames.Cancel); ok {
+		i.removeStream(c.StreamID)
+	}
+	return i.sendFrame(f)
+}
+
+// completeStream marks one side of a stream as terminated and removes
+// the stream once it is no longer active.
+func (i *Handler) completeStream(str proxy.Stream) {
+	if s, ok := str.(*requestStream); ok && !s.halfClose() {
+		return
+	}
+	i.removeStream(str.StreamID())
+}
+
+// handleConnectionError handles an ERROR frame on stream 0.
+func (i *Handler) handleConnectionError(f *frames.Error) error {
+	if f.Code == frames.ErrCodeConnectionClose {
+		// The peer finishes outstanding streams before closing.
+		i.closing.Store(true)
+		return nil
+	}
+
+	err := f.Err()
+	i.Terminate(err)
+	return err
+}
+
+// startRequest runs a request handler on a new goroutine. The request
+// counts as outstanding until the handler has registered its stream.
+func (i *Handler) startRequest(fn func()) {
+	i.starting.Add(1)
+	go func() {
+		defer i.starting.Add(-1)
+		fn()
+	}()
+}
+
 func (i *Handler) HandleFrame(f frames.Frame) (err error) {
+	if i.observer != nil {
+		i.observer.ObserveFrame(tap.NewEvent(tap.Inbound, f))
+	}
 	var str proxy.Stream
 	frameType := f.Type()
 	streamID := f.GetStreamID()
+	if e, ok := f.(*frames.Error); ok && streamID == 0 {
+		return i.handleConnectionError(e)
+	}
+	if ext, ok := f.(*frames.Ext); ok {
+		// Extensions need not belong to an active stream.
+		return i.handleExt(ext)
+	}
 	if frameType >= frames.FrameTypeRequestN {
 		var ok bool
 		str, ok = i.getStream(streamID)
 		if !ok {
-			// Return error
-			if frameType != frames.FrameTypeRequestN {
-				return fmt.Errorf("stream ID %d not found", streamID)
-			}
+			// Frames for streams that are no longer active,
+			// such as after a cancel, are ignored.
 			return
 		}
 	}
 
 	switch v := f.(type) {
 	case *frames.Setup:
+		if e := checkSetup(v); e != nil {
+			i.sendFrame(e)
+			return e.Err()
+		}
 		if i.opTable == nil {
 			opers, err := operations.FromBytes(v.Data)
 			if err != nil {
-				return errors.New("could not read operations list")
+				e := frames.Error{
+					Code: frames.ErrCodeInvalidSetup,
+					Data: "could not read operations list",
+				}
+				i.sendFrame(&e)
+				return e.Err()
 			}
 			i.opTable = opers
+			i.exports = linkImports(opers, invoke.GetOperationsTable())
 		}
+		i.SetMimeTypes(metadata.MimeTypes{
+			Metadata: v.MimeMetadata,
+			Data:     v.MimeData,
+		})
 
 	case *frames.RequestPayload:
+		if i.closing.Load() {
+			if frameType != frames.FrameTypeRequestFNF {
+				i.sendFrame(&frames.Error{
+					StreamID: streamID,
+					Code:     frames.ErrCodeRejected,
+					Data:     ErrConnectionClosing.Error(),
+				})
+			}
+			return nil
+		}
+
 		switch frameType {
 		case frames.FrameTypeRequestResponse:
 			// if i.checkFollows(&rr) {
 			// 	// Will be processed under frames.FrameTypePayload
 			// 	return
 			// }
-			go i.handleRequestResponse(i.ctx, streamID, v.Data, v.Metadata)
+			i.startRequest(func() {
+				i.handleRequestResponse(i.ctx, streamID, v.Data, v.Metadata)
+			})
 
 		case frames.FrameTypeRequestFNF:
 			// if i.checkFollows(&rr) {
@@ -148,7 +345,9 @@ func (i *Handler) HandleFrame(f frames.Frame) (err error) {
 			// 	return
 			// }
 
-			go i.handleFireAndForget(i.ctx, streamID, v.Data, v.Metadata)
+			i.startRequest(func() {
+				i.handleFireAndForget(i.ctx, streamID, v.Data, v.Metadata)
+			})
 
 		case frames.FrameTypeRequestStream:
 			// if i.checkFollows(&rs) {
@@ -156,7 +355,13 @@ func (i *Handler) HandleFrame(f frames.Frame) (err error) {
 			// 	return
 			// }
 
-			go i.handleRequestStream(i.ctx, streamID, v.Data, v.Metadata, v.InitialN)
+			// The stream is registered before its handler starts so
+			// that the REQUEST_N and CANCEL frames that follow reach it.
+			s := &requestStream{ctx: i.ctx, streamID: streamID}
+			i.registerStream(s)
+			i.startRequest(func() {
+				i.handleRequestStream(i.ctx, s, v.Data, v.Metadata, v.InitialN)
+			})
 
 		case frames.FrameTypeRequestChannel:
 			// if i.checkFollows(&rc) {
@@ -164,7 +369,12 @@ func (i *Handler) HandleFrame(f frames.Frame) (err error) {
 			// 	return
 			// }
 
-			go i.handleRequestChannel(i.ctx, streamID, v.Data, v.Metadata, v.InitialN)
+			// The stream is active until both directions terminate.
+			s := &requestStream{streamID: streamID, halves: 2}
+			i.registerStream(s)
+			i.startRequest(func() {
+				i.handleRequestChannel(i.ctx, s, v.Data, v.Metadata, v.InitialN)
+			})
 		}
 
 	case *frames.RequestN:
@@ -172,7 +382,11 @@ func (i *Handler) HandleFrame(f frames.Frame) (err error) {
 		go s.DoRequest(int(v.N))
 
 	case *frames.Cancel:
-		str.OnComplete()
+		if c, ok := str.(DoCancel); ok {
+			c.DoCancel()
+		} else {
+			str.OnComplete()
+		}
 		i.removeStream(streamID)
 
 	case *frames.Payload:
@@ -227,38 +441,40 @@ func (i *Handler) HandleFrame(f frames.Frame) (err error) {
 
 		if v.Complete {
 			str.OnComplete()
-			i.removeStream(streamID)
+			i.completeStream(str)
 		}
 
 	case *frames.Error:
-		str.OnError(errors.New(v.Data))
+		str.OnError(invoke.ParseError(payload.ErrCode(v.Code), v.Data))
 		str.OnComplete()
-		i.removeStream(streamID)
+		i.completeStream(str)
 	}
 
 	return nil
 }
 
 func (i *Handler) handleRequestResponse(ctx context.Context, streamID uint32, data, metadata []byte) {
-	if !i.checkMetadata(streamID, metadata) {
+	operationID, ok := i.operation(streamID, operations.RequestResponse, metadata)
+	if !ok {
 		return
 	}
-
-	operationID := binary.BigEndian.Uint32(metadata)
 	handler := invoke.GetRequestResponseHandler(operationID)
 	if handler == nil {
 		i.sendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "not_found",
 		})
 		return
 	}
 
-	p := payload.New(data, metadata[8:])
+	p := payload.New(data, i.handlerMetadata(metadata))
 	s := requestStream{ctx: ctx, streamID: streamID}
+	i.registerStream(&s)
 	ctx = proxy.WithContext(ctx, &s)
 	handler(ctx, p).Subscribe(mono.Subscribe[payload.Payload]{
 		OnSuccess: func(p payload.Payload) {
+			i.removeStream(streamID)
 			i.sendFrame(&frames.Payload{
 				StreamID: streamID,
 				Metadata: p.Metadata(),
@@ -268,56 +484,55 @@ func (i *Handler) handleRequestResponse(ctx context.Context, streamID uint32, da
 			})
 		},
 		OnError: func(err error) {
-			i.sendFrame(&frames.Error{
-				StreamID: streamID,
-				Data:     err.Error(),
-			})
+			i.removeStream(streamID)
+			i.sendFrame(frames.NewError(streamID, err))
 		},
 	})
 }
 
 func (i *Handler) handleFireAndForget(ctx context.Context, streamID uint32, data, metadata []byte) {
-	if !i.checkMetadata(streamID, metadata) {
+	operationID, ok := i.operation(streamID, operations.FireAndForget, metadata)
+	if !ok {
 		return
 	}
-
-	operationID := binary.BigEndian.Uint32(metadata)
 	handler := invoke.GetFireAndForgetHandler(operationID)
 	if handler == nil {
 		i.sendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "not_found",
 		})
 		return
 	}
 
-	p := payload.New(data, metadata[8:])
+	p := payload.New(data, i.handlerMetadata(metadata))
 	s := requestStream{ctx: ctx, streamID: streamID}
 	i.registerStream(&s)
+	defer i.removeStream(streamID)
 	ctx = proxy.WithContext(ctx, &s)
 	handler(ctx, p)
 }
 
-func (i *Handler) handleRequestStream(ctx context.Context, streamID uint32, data, metadata []byte, initialN uint32) {
-	if !i.checkMetadata(streamID, metadata) {
+func (i *Handler) handleRequestStream(ctx context.Context, s *requestStream, data, metadata []byte, initialN uint32) {
+	streamID := s.streamID
+	operationID, ok := i.operation(streamID, operations.RequestStream, metadata)
+	if !ok {
+		i.removeStream(streamID)
 		return
 	}
-
-	operationID := binary.BigEndian.Uint32(metadata)
 	handler := invoke.GetRequestStreamHandler(operationID)
 	if handler == nil {
 		i.sendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "not_found",
 		})
+		i.removeStream(streamID)
 		return
 	}
 
 	p := payload.New(data, metadata)
-	s := requestStream{ctx: ctx, streamID: streamID}
-	i.registerStream(&s) // Need to register for RequestN frames
-	// ctx := stream.WithContext(context.Background(), s)
-	f := handler(ctx, p)
+	f := proxy.Responder(handler(ctx, p))
 	f.Subscribe(flux.Subscribe[payload.Payload]{
 		OnNext: func(p payload.Payload) {
 			i.sendFrame(&frames.Payload{
@@ -328,42 +543,40 @@ func (i *Handler) handleRequestStream(ctx context.Context, streamID uint32, data
 			})
 		},
 		OnComplete: func() {
+			i.completeStream(s)
 			i.sendFrame(&frames.Payload{
 				StreamID: streamID,
 				Complete: true,
 			})
 		},
 		OnError: func(err error) {
-			i.sendFrame(&frames.Error{
-				StreamID: streamID,
-				Data:     err.Error(),
-			})
+			i.completeStream(s)
+			i.sendFrame(frames.NewError(streamID, err))
 		},
 		NoRequest: true,
 	})
-	sub := f.Subscription()
-	s.sub = sub
-	s.Request(int(initialN))
+	s.subscribe(f.Subscription(), int(initialN))
 }
 
-func (i *Handler) handleRequestChannel(ctx context.Context, streamID uint32, data, metadata []byte, initialN uint32) {
-	if !i.checkMetadata(streamID, metadata) {
+func (i *Handler) handleRequestChannel(ctx context.Context, s *requestStream, data, metadata []byte, initialN uint32) {
+	streamID := s.streamID
+	operationID, ok := i.operation(streamID, operations.RequestChannel, metadata)
+	if !ok {
+		i.removeStream(streamID)
 		return
 	}
-
-	operationID := binary.BigEndian.Uint32(metadata)
 	handler := invoke.GetRequestChannelHandler(operationID)
 	if handler == nil {
 		i.sendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "not_found",
 		})
+		i.removeStream(streamID)
 		return
 	}
 
 	p := payload.New(data, metadata)
-	s := requestStream{streamID: streamID}
-	i.registerStream(&s) // Need to register for RequestN frames
 	in := flux.Create(func(sink flux.Sink[payload.Payload]) {
 		s.sink = sink
 		sink.OnSubscribe(flux.OnSubscribe{
@@ -380,7 +593,7 @@ func (i *Handler) handleRequestChannel(ctx context.Context, streamID uint32, dat
 			},
 		})
 	})
-	f := handler(ctx, p, in)
+	f := proxy.Responder(handler(ctx, p, in))
 	f.Subscribe(flux.Subscribe[payload.Payload]{
 		OnNext: func(p payload.Payload) {
 			i.sendFrame(&frames.Payload{
@@ -391,22 +604,19 @@ func (i *Handler) handleRequestChannel(ctx context.Context, streamID uint32, dat
 			})
 		},
 		OnComplete: func() {
+			i.completeStream(s)
 			i.sendFrame(&frames.Payload{
 				StreamID: streamID,
 				Complete: true,
 			})
 		},
 		OnError: func(err error) {
-			i.sendFrame(&frames.Error{
-				StreamID: streamID,
-				Data:     err.Error(),
-			})
+			i.completeStream(s)
+			i.sendFrame(frames.NewError(streamID, err))
 		},
 		NoRequest: true,
 	})
-	sub := f.Subscription()
-	s.sub = sub
-	s.Request(int(initialN))
+	s.subscribe(f.Subscription(), int(initialN))
 }
 
 // func (i *Handler) handleRequestResponse(ctx context.Context, streamID uint32, data, metadata []byte) {
@@ -656,10 +866,147 @@ func (i *Handler) getRequestChannelHandler(index uint32) invoke.RequestChannelHa
 	return i.importedRC[index]
 }
 
+// SetExtensionHandler sets the handler of the EXT frames with
+// extendedType. It must be called before frames are exchanged.
+func (i *Handler) SetExtensionHandler(extendedType uint32, handler invoke.ExtensionHandler) {
+	if i.extensions == nil {
+		i.extensions = make(map[uint32]invoke.ExtensionHandler)
+	}
+	i.extensions[extendedType] = handler
+}
+
+// SendExtension sends an EXT frame with extendedType on a stream, or on
+// stream 0 for the connection. If ignore is true, peers that do not
+// support the extension drop the frame instead of rejecting it.
+func (i *Handler) SendExtension(streamID, extendedType uint32, p payload.Payload, ignore bool) error {
+	return i.sendFrame(&frames.Ext{
+		StreamID:     streamID,
+		ExtendedType: extendedType,
+		Metadata:     p.Metadata(),
+		Data:         p.Data(),
+		Ignore:       ignore,
+	})
+}
+
+// handleExt passes an EXT frame to the handler of its extended type.
+// Unsupported extensions are dropped if the IGNORE flag is set and
+// rejected otherwise.
+func (i *Handler) handleExt(f *frames.Ext) error {
+	handler, ok := i.extensions[f.ExtendedType]
+	if !ok {
+		if f.Ignore {
+			return nil
+		}
+		e := frames.UnsupportedExt(f)
+		i.sendFrame(e)
+		if f.StreamID == 0 {
+			return e.Err()
+		}
+		return nil
+	}
+	handler(i.ctx, f.StreamID, payload.New(f.Data, f.Metadata))
+	return nil
+}
+
+// linkImports matches the operations imported by the peer
+// to the operations exported locally by namespace and name.
+func linkImports(remote, local operations.Table) map[operationKey]uint32 {
+	exports := make(map[operationKey]uint32)
+	for _, imp := range remote {
+		if imp.Direction != operations.Import {
+			continue
+		}
+		for _, exp := range local {
+			if exp.Direction == operations.Export &&
+				exp.Type == imp.Type &&
+				exp.Namespace == imp.Namespace &&
+				exp.Operation == imp.Operation {
+				exports[operationKey{imp.Type, imp.Index}] = exp.Index
+				break
+			}
+		}
+	}
+	return exports
+}
+
+// resolveOperation returns the local export index for an operation index
+// sent by the peer. Peers that sent a SETUP frame use their import indexes.
+// Otherwise the peer resolved the export index from our operations table.
+func (i *Handler) resolveOperation(requestType operations.RequestType, index uint32) uint32 {
+	if i.exports == nil {
+		return index
+	}
+	if exportIndex, ok := i.exports[operationKey{requestType, index}]; ok {
+		return exportIndex
+	}
+	return math.MaxUint32
+}
+
+// operation returns the local export index for a request. With composite
+// metadata the operation index entry is used if present, otherwise the
+// operation is found by the routing entry. If the operation cannot be
+// determined, an error is sent and false is returned.
+func (i *Handler) operation(streamID uint32, requestType operations.RequestType, md []byte) (uint32, bool) {
+	if i.mimeTypes.Metadata != metadata.MimeTypeComposite {
+		if !i.checkMetadata(streamID, md) {
+			return 0, false
+		}
+		return i.resolveOperation(requestType, binary.BigEndian.Uint32(md)), true
+	}
+
+	c, err := metadata.ParseComposite(md)
+	if err != nil {
+		i.sendFrame(&frames.Error{
+			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
+			Data:     err.Error(),
+		})
+		return 0, false
+	}
+	if index, ok := c.OperationIndex(); ok {
+		return i.resolveOperation(requestType, index), true
+	}
+	if route, ok := c.Route(); ok {
+		if namespace, operation, ok := metadata.SplitRoute(route); ok {
+			return exportIndex(requestType, namespace, operation), true
+		}
+	}
+
+	i.sendFrame(&frames.Error{
+		StreamID: streamID,
+		Code:     frames.ErrCodeInvalid,
+		Data:     "Invalid metadata",
+	})
+	return 0, false
+}
+
+// handlerMetadata returns the request metadata passed to handlers.
+func (i *Handler) handlerMetadata(md []byte) []byte {
+	if i.mimeTypes.Metadata == metadata.MimeTypeComposite {
+		return md
+	}
+	return md[8:]
+}
+
+// exportIndex returns the index of a local export or
+// math.MaxUint32 if there is no such export.
+func exportIndex(requestType operations.RequestType, namespace, operation string) uint32 {
+	for _, op := range invoke.GetOperationsTable() {
+		if op.Direction == operations.Export &&
+			op.Type == requestType &&
+			op.Namespace == namespace &&
+			op.Operation == operation {
+			return op.Index
+		}
+	}
+	return math.MaxUint32
+}
+
 func (i *Handler) checkMetadata(streamID uint32, metadata []byte) bool {
 	if len(metadata) < 8 { // 48 before... but why?
 		i.SendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "Invalid metadata",
 		})
 		return false
@@ -686,12 +1033,24 @@ type DoRequest interface {
 	DoRequest(n int)
 }
 
+type DoCancel interface {
+	DoCancel()
+}
+
 type requestStream struct {
 	ctx      context.Context
 	streamID uint32
 	flux.Sink[payload.Payload]
-	sub  rx.Subscription
 	sink flux.Sink[payload.Payload]
+	// halves counts the directions of a channel that are not terminated.
+	halves int32
+
+	mu  sync.Mutex
+	sub rx.Subscription
+	// pending counts the items requested before the subscription to
+	// the handler was made.
+	pending   int
+	cancelled bool
 }
 
 var _ = (proxy.Stream)((*requestStream)(nil))
@@ -704,12 +1063,59 @@ func (r *requestStream) StreamID() uint32 {
 	return r.streamID
 }
 
+// subscribe passes the initial request and the REQUEST_N and CANCEL
+// frames that arrived before it to sub.
+func (r *requestStream) subscribe(sub rx.Subscription, initialN int) {
+	r.mu.Lock()
+	r.sub = sub
+	n := addRequest(initialN, r.pending)
+	cancelled := r.cancelled
+	r.mu.Unlock()
+	if cancelled {
+		sub.Cancel()
+		return
+	}
+	sub.Request(n)
+}
+
 func (r *requestStream) Request(n int) {
-	r.sub.Request(n)
+	r.mu.Lock()
+	sub := r.sub
+	if sub == nil {
+		r.pending = addRequest(r.pending, n)
+	}
+	r.mu.Unlock()
+	if sub != nil {
+		sub.Request(n)
+	}
 }
 
 func (r *requestStream) DoRequest(n int) {
-	r.sub.Request(n)
+	r.Request(n)
+}
+
+// halfClose terminates one direction of the stream and
+// returns true when the stream is no longer active.
+func (r *requestStream) halfClose() bool {
+	return atomic.AddInt32(&r.halves, -1) <= 0
+}
+
+func (r *requestStream) DoCancel() {
+	r.mu.Lock()
+	r.cancelled = true
+	sub := r.sub
+	r.mu.Unlock()
+	if sub != nil {
+		sub.Cancel()
+	}
+}
+
+// addRequest adds requests for n items, up to rx.RequestMax.
+func addRequest(requested, n int) int {
+	if n >= rx.RequestMax-requested {
+		return rx.RequestMax
+	}
+	return requested + n
 }
 
 func (r *requestStream) OnNext(p payload.Payload) {
@@ -0,0 +1,240 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/handler"
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/operations"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/rxtest"
+)
+
+// peer records the frames that a handler sends.
+type peer struct {
+	t      *testing.T
+	frames chan frames.Frame
+}
+
+func newPeer(t *testing.T, mode handler.Mode) (*handler.Handler, *peer) {
+	p := peer{t: t, frames: make(chan frames.Frame, 100)}
+	h := handler.New(context.Background(), mode)
+	h.SetFrameSender(func(f frames.Frame) error {
+		p.frames <- f
+		return nil
+	})
+	return h, &p
+}
+
+// next returns the next frame the handler sent.
+func (p *peer) next() frames.Frame {
+	p.t.Helper()
+	select {
+	case f := <-p.frames:
+		return f
+	case <-time.After(time.Second):
+		p.t.Fatal("no frame sent")
+		return nil
+	}
+}
+
+// none checks that the handler sends no further frame.
+func (p *peer) none() {
+	p.t.Helper()
+	select {
+	case f := <-p.frames:
+		p.t.Fatalf("unexpected frame %#v", f)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// exportIndex returns the index of an exported operation.
+func exportIndex(t *testing.T, requestType operations.RequestType, namespace, operation string) uint32 {
+	for _, op := range invoke.GetOperationsTable() {
+		if op.Direction == operations.Export && op.Type == requestType &&
+			op.Namespace == namespace && op.Operation == operation {
+			return op.Index
+		}
+	}
+	t.Fatalf("%s/%s is not exported", namespace, operation)
+	return 0
+}
+
+func operationMetadata(index uint32) []byte {
+	md := make([]byte, 8)
+	binary.BigEndian.PutUint32(md, index)
+	return md
+}
+
+// eager emits count payloads whatever is requested.
+func eager(count int) flux.Flux[payload.Payload] {
+	return flux.Create(func(sink flux.Sink[payload.Payload]) {
+		for i := 0; i < count; i++ {
+			sink.Next(payload.New([]byte{byte(i)}, nil))
+		}
+		sink.Complete()
+	})
+}
+
+func TestRequestStreamRequestN(t *testing.T) {
+	invoke.ExportRequestStream("handler.test", "eager", func(ctx context.Context, p payload.Payload) flux.Flux[payload.Payload] {
+		return eager(5)
+	})
+	index := exportIndex(t, operations.RequestStream, "handler.test", "eager")
+	h, p := newPeer(t, handler.ServerMode)
+
+	require.NoError(t, h.HandleFrame(&frames.RequestPayload{
+		FrameType: frames.FrameTypeRequestStream,
+		StreamID:  1,
+		Metadata:  operationMetadata(index),
+		InitialN:  2,
+	}))
+	// A REQUEST_N frame that arrives before the handler subscribed
+	// adds to the initial request.
+	require.NoError(t, h.HandleFrame(&frames.RequestN{StreamID: 1, N: 1}))
+	for i := 0; i < 3; i++ {
+		f := p.next().(*frames.Payload)
+		assert.Equal(t, []byte{byte(i)}, f.Data)
+		assert.False(t, f.Complete)
+	}
+	p.none()
+
+	require.NoError(t, h.HandleFrame(&frames.RequestN{StreamID: 1, N: 2}))
+	for i := 3; i < 5; i++ {
+		f := p.next().(*frames.Payload)
+		assert.Equal(t, []byte{byte(i)}, f.Data)
+	}
+	assert.True(t, p.next().(*frames.Payload).Complete)
+}
+
+func TestRequestStreamOverflow(t *testing.T) {
+	invoke.ExportRequestStream("handler.test", "flood", func(ctx context.Context, p payload.Payload) flux.Flux[payload.Payload] {
+		return eager(1000)
+	})
+	index := exportIndex(t, operations.RequestStream, "handler.test", "flood")
+	h, p := newPeer(t, handler.ServerMode)
+
+	require.NoError(t, h.HandleFrame(&frames.RequestPayload{
+		FrameType: frames.FrameTypeRequestStream,
+		StreamID:  1,
+		Metadata:  operationMetadata(index),
+		InitialN:  1,
+	}))
+	// The stream fails without sending more than was requested.
+	f := p.next()
+	if first, ok := f.(*frames.Payload); ok {
+		assert.Equal(t, []byte{0}, first.Data)
+		f = p.next()
+	}
+	e := f.(*frames.Error)
+	assert.Equal(t, uint32(1), e.StreamID)
+	assert.Contains(t, e.Data, flux.ErrOverflow.Error())
+}
+
+func TestRequestChannelRequestN(t *testing.T) {
+	invoke.ExportRequestChannel("handler.test", "eager", func(ctx context.Context, p payload.Payload, in flux.Flux[payload.Payload]) flux.Flux[payload.Payload] {
+		return eager(3)
+	})
+	index := exportIndex(t, operations.RequestChannel, "handler.test", "eager")
+	h, p := newPeer(t, handler.ServerMode)
+
+	require.NoError(t, h.HandleFrame(&frames.RequestPayload{
+		FrameType: frames.FrameTypeRequestChannel,
+		StreamID:  1,
+		Metadata:  operationMetadata(index),
+		InitialN:  1,
+	}))
+	assert.Equal(t, []byte{0}, p.next().(*frames.Payload).Data)
+	p.none()
+
+	require.NoError(t, h.HandleFrame(&frames.RequestN{StreamID: 1, N: 5}))
+	assert.Equal(t, []byte{1}, p.next().(*frames.Payload).Data)
+	assert.Equal(t, []byte{2}, p.next().(*frames.Payload).Data)
+	assert.True(t, p.next().(*frames.Payload).Complete)
+}
+
+func TestRequestStreamRequester(t *testing.T) {
+	h, p := newPeer(t, handler.ClientMode)
+	streamID := uint32(1)
+
+	rxtest.Flux(h.RequestStream(context.Background(), payload.New([]byte("request")))).
+		ThenRequest(2).
+		Then(func() {
+			f := p.next().(*frames.RequestPayload)
+			assert.Equal(t, frames.FrameTypeRequestStream, f.FrameType)
+			assert.Equal(t, uint32(2), f.InitialN)
+			streamID = f.StreamID
+			for _, data := range []string{"a", "b"} {
+				require.NoError(t, h.HandleFrame(&frames.Payload{StreamID: streamID, Data: []byte(data), Next: true}))
+			}
+		}).
+		ExpectNextMatches(hasData("a")).
+		ExpectNextMatches(hasData("b")).
+		ThenRequest(1).
+		Then(func() {
+			assert.Equal(t, &frames.RequestN{StreamID: streamID, N: 1}, p.next())
+			require.NoError(t, h.HandleFrame(&frames.Payload{StreamID: streamID, Data: []byte("c"), Next: true, Complete: true}))
+		}).
+		ExpectNextMatches(hasData("c")).
+		ExpectComplete().
+		Verify(t)
+}
+
+func TestRequestStreamRequesterError(t *testing.T) {
+	h, p := newPeer(t, handler.ClientMode)
+
+	rxtest.Flux(h.RequestStream(context.Background(), payload.New([]byte("request")))).
+		ThenRequest(1).
+		Then(func() {
+			f := p.next().(*frames.RequestPayload)
+			require.NoError(t, h.HandleFrame(&frames.Error{StreamID: f.StreamID, Code: frames.ErrCodeApplicationError, Data: "boom"}))
+		}).
+		ExpectErrorMatches(func(err error) bool { return err.Error() == "boom" }).
+		Verify(t)
+}
+
+// hasData matches a payload with the given data.
+func hasData(data string) func(payload.Payload) bool {
+	return func(p payload.Payload) bool {
+		return string(p.Data()) == data
+	}
+}
+
+// applicationError returns the APPLICATION_ERROR data of an apperror
+// with code and message, encoded by hand so that the test does not
+// import apperror and its decoder.
+func applicationError(code, message string) string {
+	b := []byte{0x82}
+	for _, s := range []string{"code", code, "message", message} {
+		b = append(b, 0xa0|byte(len(s)))
+		b = append(b, s...)
+	}
+	return string(b)
+}
+
+func TestApplicationErrorDecoded(t *testing.T) {
+	h, p := newPeer(t, handler.ClientMode)
+
+	rxtest.Mono(h.RequestResponse(context.Background(), payload.New(nil))).
+		Then(func() {
+			f := p.next().(*frames.RequestPayload)
+			require.NoError(t, h.HandleFrame(&frames.Error{
+				StreamID: f.StreamID,
+				Code:     frames.ErrCodeApplicationError,
+				Data:     applicationError("invalid_argument", "name is required"),
+			}))
+		}).
+		ExpectErrorMatches(func(err error) bool {
+			return fmt.Sprintf("%T", err) == "*apperror.Error" && err.Error() == "name is required"
+		}).
+		Verify(t)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/metadata"
+)
+
+// The protocol version sent in SETUP. Servers reject clients with a
+// different major version or a newer minor version.
+const (
+	MajorVersion uint16 = 0
+	MinorVersion uint16 = 2
+)
+
+// DefaultDataMimeType is the data MIME type of
+// connections that do not announce one.
+const DefaultDataMimeType = metadata.MimeTypeMsgPack
+
+// metadataMimeTypes are the supported request metadata MIME types.
+// Without a MIME type, metadata holds the operation index.
+var metadataMimeTypes = []string{
+	"",
+	metadata.MimeTypeComposite,
+}
+
+// dataMimeTypes are the supported request data MIME types.
+var dataMimeTypes = []string{
+	"",
+	metadata.MimeTypeMsgPack,
+	metadata.MimeTypeJSON,
+	metadata.MimeTypeOctetStream,
+}
+
+// checkSetup returns the ERROR frame to reject a SETUP frame with
+// or nil if the server supports the parameters it requests.
+func checkSetup(f *frames.Setup) *frames.Error {
+	if !validMimeType(f.MimeMetadata) || !validMimeType(f.MimeData) {
+		return &frames.Error{
+			Code: frames.ErrCodeInvalidSetup,
+			Data: "invalid MIME type",
+		}
+	}
+
+	var reason string
+	switch {
+	case f.MajorVersion != MajorVersion || f.MinorVersion > MinorVersion:
+		reason = fmt.Sprintf("unsupported protocol version %d.%d", f.MajorVersion, f.MinorVersion)
+	case f.Lease:
+		reason = "leasing is not supported"
+	case len(f.Token) > 0:
+		reason = "resumption is not supported"
+	case !contains(metadataMimeTypes, f.MimeMetadata):
+		reason = "unsupported metadata MIME type " + f.MimeMetadata
+	case !contains(dataMimeTypes, f.MimeData):
+		reason = "unsupported data MIME type " + f.MimeData
+	default:
+		return nil
+	}
+
+	return &frames.Error{
+		Code: frames.ErrCodeUnsupportedSetup,
+		Data: reason,
+	}
+}
+
+// validMimeType returns true if the MIME type only contains
+// printable US-ASCII characters as required by the protocol.
+func validMimeType(mimeType string) bool {
+	for i := 0; i < len(mimeType); i++ {
+		if c := mimeType[i]; c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
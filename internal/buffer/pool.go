@@ -0,0 +1,79 @@
+package buffer
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	minPooledSize = 1 << 8
+	maxPooledSize = 1 << 24
+	numClasses    = 17 // minPooledSize << 16 == maxPooledSize
+)
+
+// pools holds buffers by size class. Class n holds
+// buffers with a capacity of minPooledSize << n.
+var pools [numClasses]sync.Pool
+
+// Pooled is a reference-counted byte buffer from a pool. It is returned
+// to the pool when the last reference is released, after which its bytes
+// must not be used.
+type Pooled struct {
+	b    []byte
+	refs atomic.Int32
+}
+
+// Get returns a buffer of length size with one reference. Buffers larger
+// than the largest size class are allocated and never pooled.
+func Get(size int) *Pooled {
+	class, ok := sizeClass(size)
+	if !ok {
+		p := &Pooled{b: make([]byte, size)}
+		p.refs.Store(1)
+		return p
+	}
+	p, _ := pools[class].Get().(*Pooled)
+	if p == nil {
+		p = &Pooled{b: make([]byte, minPooledSize<<class)}
+	}
+	p.b = p.b[:size]
+	p.refs.Store(1)
+	return p
+}
+
+// Bytes returns the contents of the buffer.
+func (p *Pooled) Bytes() []byte {
+	return p.b
+}
+
+// Retain adds a reference to the buffer.
+func (p *Pooled) Retain() {
+	p.refs.Add(1)
+}
+
+// Release removes a reference to the buffer and
+// returns it to its pool if it was the last one.
+func (p *Pooled) Release() {
+	refs := p.refs.Add(-1)
+	if refs > 0 {
+		return
+	}
+	if refs < 0 {
+		panic("buffer: released more often than retained")
+	}
+	if class, ok := sizeClass(cap(p.b)); ok && minPooledSize<<class == cap(p.b) {
+		pools[class].Put(p)
+	}
+}
+
+// sizeClass returns the smallest size class that fits size.
+func sizeClass(size int) (int, bool) {
+	if size > maxPooledSize {
+		return 0, false
+	}
+	if size <= minPooledSize {
+		return 0, true
+	}
+	return bits.Len(uint(size-1)) - bits.Len(uint(minPooledSize-1)), true
+}
@@ -0,0 +1,41 @@
+package buffer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nanobus/iota/go/internal/buffer"
+)
+
+func TestPooled(t *testing.T) {
+	for _, size := range []int{0, 1, 256, 257, 4096, 1 << 20} {
+		p := buffer.Get(size)
+		assert.Len(t, p.Bytes(), size)
+		assert.GreaterOrEqual(t, cap(p.Bytes()), size)
+		p.Release()
+	}
+}
+
+func TestPooledLarge(t *testing.T) {
+	p := buffer.Get(1<<24 + 1)
+	assert.Len(t, p.Bytes(), 1<<24+1)
+	p.Release()
+}
+
+func TestPooledRetain(t *testing.T) {
+	p := buffer.Get(16)
+	p.Retain()
+	p.Release()
+	p.Release()
+	assert.Panics(t, p.Release)
+}
+
+func BenchmarkPooled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p := buffer.Get(1024)
+		p.Bytes()[0] = 1
+		p.Release()
+	}
+}
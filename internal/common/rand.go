@@ -19,3 +19,11 @@ func RandIntn(n int) (v int) {
 	defaultRandMutex.Unlock()
 	return
 }
+
+// RandInt63n returns a random int64.
+func RandInt63n(n int64) (v int64) {
+	defaultRandMutex.Lock()
+	v = defaultRand.Int63n(n)
+	defaultRandMutex.Unlock()
+	return
+}
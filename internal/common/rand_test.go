@@ -12,3 +12,8 @@ func TestRandIntn(t *testing.T) {
 	n := common.RandIntn(10)
 	assert.True(t, n >= 0 && n < 10)
 }
+
+func TestRandInt63n(t *testing.T) {
+	n := common.RandInt63n(10)
+	assert.True(t, n >= 0 && n < 10)
+}
@@ -22,8 +22,12 @@ func (f *Cancel) Decode(header *FrameHeader, payload []byte) error {
 	return nil
 }
 
+func (f *Cancel) Flags() FrameFlag {
+	return 0
+}
+
 func (f *Cancel) Encode(buf []byte) {
-	ResetFrameHeader(buf, f.StreamID, FrameTypeCancel, 0)
+	ResetFrameHeader(buf, f.StreamID, FrameTypeCancel, f.Flags())
 }
 
 func (f *Cancel) Size() uint32 {
@@ -0,0 +1,50 @@
+package frames
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ErrIncompleteHeader is returned for frames shorter than a frame header.
+var ErrIncompleteHeader = errors.New("incomplete frame header")
+
+// Decode decodes a frame, without its length prefix, from raw.
+// The frame points into raw.
+func Decode(raw []byte) (Frame, error) {
+	if len(raw) < FrameHeaderLen {
+		return nil, ErrIncompleteHeader
+	}
+	header := ParseFrameHeader(raw)
+	buffer := raw[FrameHeaderLen:]
+
+	var f Frame
+	switch header.Type() {
+	case FrameTypeSetup:
+		f = &Setup{}
+	case FrameTypeKeepalive:
+		f = &Keepalive{}
+	case FrameTypeRequestResponse, FrameTypeRequestFNF,
+		FrameTypeRequestStream, FrameTypeRequestChannel:
+		f = &RequestPayload{}
+	case FrameTypeRequestN:
+		f = &RequestN{}
+	case FrameTypeCancel:
+		f = &Cancel{}
+	case FrameTypePayload:
+		f = &Payload{}
+	case FrameTypeError:
+		f = &Error{}
+	case FrameTypeExt:
+		f = &Ext{}
+	default:
+		return nil, &InvalidFrameError{
+			StreamID: header.StreamID(),
+			Type:     header.Type(),
+			Reason:   "unknown frame type " + strconv.Itoa(int(header.Type())),
+		}
+	}
+	if err := f.Decode(&header, buffer); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
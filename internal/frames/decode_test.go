@@ -0,0 +1,172 @@
+package frames_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/internal/frames"
+)
+
+func encode(f frames.Frame) []byte {
+	buf := make([]byte, f.Size())
+	f.Encode(buf)
+	return buf
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	tests := map[string]struct {
+		raw    []byte
+		reason string
+	}{
+		"setup": {
+			encode(&frames.Setup{MimeMetadata: "application/x.rsocket.composite-metadata.v0"})[:20],
+			"metadata MIME type length 43 exceeds the remaining 1 bytes",
+		},
+		"setup version": {
+			encode(&frames.Setup{})[:frames.FrameHeaderLen+1],
+			"major version is truncated",
+		},
+		"request stream": {
+			encode(&frames.RequestPayload{FrameType: frames.FrameTypeRequestStream, StreamID: 3})[:frames.FrameHeaderLen+2],
+			"initial request N is truncated",
+		},
+		"request metadata": {
+			encode(&frames.RequestPayload{FrameType: frames.FrameTypeRequestResponse, StreamID: 3, Metadata: []byte("metadata")})[:frames.FrameHeaderLen+5],
+			"metadata length 8 exceeds the remaining 2 bytes",
+		},
+		"payload metadata length": {
+			encode(&frames.Payload{StreamID: 3, Metadata: []byte("metadata")})[:frames.FrameHeaderLen+1],
+			"metadata length is truncated",
+		},
+		"request n": {
+			encode(&frames.RequestN{StreamID: 3, N: 1})[:frames.FrameHeaderLen],
+			"request N is truncated",
+		},
+		"error": {
+			encode(&frames.Error{StreamID: 3, Code: frames.ErrCodeInvalid})[:frames.FrameHeaderLen+3],
+			"error code is truncated",
+		},
+		"keepalive": {
+			encode(&frames.Keepalive{})[:frames.FrameHeaderLen+7],
+			"last received position is truncated",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := frames.Decode(tt.raw)
+			require.ErrorIs(t, err, frames.ErrInvalidFrame)
+			var ferr *frames.InvalidFrameError
+			require.ErrorAs(t, err, &ferr)
+			assert.Equal(t, tt.reason, ferr.Reason)
+
+			e := frames.InvalidFrame(0, err)
+			assert.Equal(t, frames.ParseFrameHeader(tt.raw).StreamID(), e.StreamID)
+			assert.Equal(t, frames.ErrCodeInvalid, e.Code)
+			assert.Equal(t, err.Error(), e.Data)
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	_, err := frames.Decode([]byte{0, 0, 0, 1})
+	assert.ErrorIs(t, err, frames.ErrIncompleteHeader)
+
+	h := frames.NewFrameHeader(1, frames.FrameTypeResume, 0)
+	_, err = frames.Decode(h.Bytes())
+	assert.ErrorIs(t, err, frames.ErrInvalidFrame)
+	assert.EqualError(t, err, "invalid RESUME frame: unknown frame type 13")
+}
+
+func TestSetupResumeToken(t *testing.T) {
+	s := frames.Setup{
+		MinorVersion: 2,
+		Token:        []byte("token"),
+		MimeData:     "application/json",
+		Data:         []byte("data"),
+	}
+	f, err := frames.Decode(encode(&s))
+	require.NoError(t, err)
+	assert.Equal(t, &s, f)
+}
+
+// fuzzFrames decodes arbitrary frames. Decoding must not panic and
+// frames that decode must encode to bytes that decode to the same frame.
+func fuzzFrames(f *testing.F, seeds ...frames.Frame) {
+	for _, seed := range seeds {
+		raw := encode(seed)
+		f.Add(raw)
+		f.Add(raw[:len(raw)/2])
+	}
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		decoded, err := frames.Decode(raw)
+		if err != nil {
+			return
+		}
+		raw2 := encode(decoded)
+		decoded2, err := frames.Decode(raw2)
+		require.NoError(t, err)
+		if raw3 := encode(decoded2); !bytes.Equal(raw2, raw3) {
+			t.Fatalf("%x encodes as %x and then as %x", raw, raw2, raw3)
+		}
+	})
+}
+
+func FuzzSetup(f *testing.F) {
+	fuzzFrames(f,
+		&frames.Setup{MinorVersion: 2, Data: []byte("operations")},
+		&frames.Setup{
+			MinorVersion:         2,
+			TimeBetweenKeepalive: time.Second,
+			MaxLifetime:          time.Minute,
+			Token:                []byte("token"),
+			MimeMetadata:         "message/x.rsocket.composite-metadata.v0",
+			MimeData:             "application/x-msgpack",
+			Metadata:             []byte("metadata"),
+			Data:                 []byte("operations"),
+			Lease:                true,
+		},
+	)
+}
+
+func FuzzRequestPayload(f *testing.F) {
+	fuzzFrames(f,
+		&frames.RequestPayload{FrameType: frames.FrameTypeRequestResponse, StreamID: 1, Metadata: []byte("metadata"), Data: []byte("data")},
+		&frames.RequestPayload{FrameType: frames.FrameTypeRequestFNF, StreamID: 1, Data: []byte("data")},
+		&frames.RequestPayload{FrameType: frames.FrameTypeRequestStream, StreamID: 1, Metadata: []byte("metadata"), InitialN: 10},
+		&frames.RequestPayload{FrameType: frames.FrameTypeRequestChannel, StreamID: 1, Data: []byte("data"), InitialN: 1, Complete: true},
+	)
+}
+
+func FuzzPayload(f *testing.F) {
+	fuzzFrames(f,
+		&frames.Payload{StreamID: 1, Metadata: []byte("metadata"), Data: []byte("data"), Next: true},
+		&frames.Payload{StreamID: 1, Complete: true},
+	)
+}
+
+func FuzzRequestN(f *testing.F) {
+	fuzzFrames(f, &frames.RequestN{StreamID: 1, N: 10})
+}
+
+func FuzzCancel(f *testing.F) {
+	fuzzFrames(f, &frames.Cancel{StreamID: 1})
+}
+
+func FuzzError(f *testing.F) {
+	fuzzFrames(f, &frames.Error{StreamID: 1, Code: frames.ErrCodeApplicationError, Data: "boom"})
+}
+
+func FuzzKeepalive(f *testing.F) {
+	fuzzFrames(f, &frames.Keepalive{LastReceivedPosition: 10, Data: []byte("data"), Respond: true})
+}
+
+func FuzzExt(f *testing.F) {
+	fuzzFrames(f,
+		&frames.Ext{StreamID: 1, ExtendedType: 7, Metadata: []byte("metadata"), Data: []byte("data"), Ignore: true},
+		&frames.Ext{ExtendedType: 0x7FFFFFFF, Data: []byte("data")},
+	)
+}
@@ -1,7 +1,11 @@
 package frames
 
 import (
+	"context"
 	"encoding/binary"
+	"errors"
+
+	"github.com/nanobus/iota/go/payload"
 )
 
 // https://rsocket.io/about/protocol#error-frame-0x0b
@@ -12,6 +16,53 @@ type Error struct {
 	Data     string
 }
 
+// errorData is implemented by errors that encode their own
+// APPLICATION_ERROR data, such as apperror.Error.
+type errorData interface {
+	ErrorData() string
+}
+
+// NewError creates an ERROR frame that sends err on a stream.
+// Errors that are or wrap a *payload.Error keep their code. Canceled
+// and expired contexts are sent as CANCELED. Any other error is sent
+// as APPLICATION_ERROR.
+func NewError(streamID uint32, err error) *Error {
+	var (
+		derr errorData
+		perr *payload.Error
+	)
+	switch {
+	case errors.As(err, &derr):
+		return &Error{
+			StreamID: streamID,
+			Code:     ErrCodeApplicationError,
+			Data:     derr.ErrorData(),
+		}
+	case errors.As(err, &perr):
+		return &Error{
+			StreamID: streamID,
+			Code:     ErrCode(perr.Code),
+			Data:     perr.Message,
+		}
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return &Error{
+			StreamID: streamID,
+			Code:     ErrCodeCanceled,
+			Data:     err.Error(),
+		}
+	}
+	return &Error{
+		StreamID: streamID,
+		Code:     ErrCodeApplicationError,
+		Data:     err.Error(),
+	}
+}
+
+// Err returns the error carried by the frame as a *payload.Error.
+func (f *Error) Err() error {
+	return payload.NewError(payload.ErrCode(f.Code), f.Data)
+}
+
 func (f *Error) GetStreamID() uint32 {
 	return f.StreamID
 }
@@ -21,8 +72,12 @@ func (f *Error) Type() FrameType {
 }
 
 func (f *Error) Decode(header *FrameHeader, payload []byte) error {
-	code := binary.BigEndian.Uint32(payload)
-	data := string(payload[4:])
+	r := newReader(header, payload)
+	code := r.uint32("error code")
+	data := string(r.rest())
+	if r.err != nil {
+		return r.err
+	}
 
 	*f = Error{
 		StreamID: header.StreamID(),
@@ -33,9 +88,13 @@ func (f *Error) Decode(header *FrameHeader, payload []byte) error {
 	return nil
 }
 
+func (f *Error) Flags() FrameFlag {
+	return 0
+}
+
 func (f *Error) Encode(buf []byte) {
 	payload := buf
-	ResetFrameHeader(payload, f.StreamID, FrameTypeError, 0)
+	ResetFrameHeader(payload, f.StreamID, FrameTypeError, f.Flags())
 	payload = payload[FrameHeaderLen:]
 	binary.BigEndian.PutUint32(payload, uint32(f.Code))
 	payload = payload[4:]
@@ -0,0 +1,51 @@
+package frames_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/payload"
+)
+
+func TestErrorCodes(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		code frames.ErrCode
+		data string
+	}{
+		"application": {errors.New("boom"), frames.ErrCodeApplicationError, "boom"},
+		"typed":       {fmt.Errorf("wrapped: %w", payload.NewError(payload.ErrCodeRejected, "busy")), frames.ErrCodeRejected, "busy"},
+		"canceled":    {context.Canceled, frames.ErrCodeCanceled, "context canceled"},
+		"deadline":    {context.DeadlineExceeded, frames.ErrCodeCanceled, "context deadline exceeded"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := frames.NewError(5, tt.err)
+			assert.Equal(t, tt.code, e.Code)
+			assert.Equal(t, tt.data, e.Data)
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	e := frames.NewError(5, payload.NewError(payload.ErrCodeInvalid, "bad request"))
+
+	buf := make([]byte, e.Size())
+	e.Encode(buf)
+
+	var e2 frames.Error
+	f := frames.ParseFrameHeader(buf)
+	require.NoError(t, e2.Decode(&f, buf[frames.FrameHeaderLen:]))
+	assert.Equal(t, *e, e2)
+
+	var perr *payload.Error
+	require.True(t, errors.As(e2.Err(), &perr))
+	assert.Equal(t, payload.ErrCodeInvalid, perr.Code)
+	assert.Equal(t, "bad request", perr.Message)
+}
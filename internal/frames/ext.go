@@ -0,0 +1,120 @@
+package frames
+
+import (
+	"encoding/binary"
+	"strconv"
+)
+
+// https://rsocket.io/about/protocol#ext-extension-frame-0x3f
+
+// Ext is a frame of a protocol extension identified by its extended type.
+// Peers that do not support the extension drop the frame if Ignore is set
+// and reject it otherwise.
+type Ext struct {
+	StreamID     uint32
+	ExtendedType uint32
+	Metadata     []byte
+	Data         []byte
+	Ignore       bool
+}
+
+// maxExtendedType is the largest extended type. The most significant
+// bit of the extended type field is reserved.
+const maxExtendedType = 0x7FFFFFFF
+
+func (f *Ext) GetStreamID() uint32 {
+	return f.StreamID
+}
+
+func (f *Ext) Type() FrameType {
+	return FrameTypeExt
+}
+
+func (f *Ext) Decode(header *FrameHeader, payload []byte) error {
+	flags := header.Flag()
+	hasMetadata := flags.Check(FlagMetadata)
+	ignore := flags.Check(FlagIgnore)
+	metadata := emptyBuffer
+
+	r := newReader(header, payload)
+	extendedType := r.uint32("extended type") & maxExtendedType
+	if r.err == nil && extendedType == 0 {
+		r.fail("extended type must be greater than 0")
+	}
+	if hasMetadata {
+		metadata = r.metadata()
+	}
+	data := r.rest()
+	if r.err != nil {
+		return r.err
+	}
+
+	*f = Ext{
+		StreamID:     header.StreamID(),
+		ExtendedType: extendedType,
+		Metadata:     metadata,
+		Data:         data,
+		Ignore:       ignore,
+	}
+
+	return nil
+}
+
+func (f *Ext) Flags() FrameFlag {
+	var flags FrameFlag
+	if f.Ignore {
+		flags |= FlagIgnore
+	}
+	if len(f.Metadata) > 0 {
+		flags |= FlagMetadata
+	}
+	return flags
+}
+
+func (f *Ext) Encode(buf []byte) {
+	metadataLen := uint32(len(f.Metadata))
+	payload := buf
+
+	ResetFrameHeader(payload, f.StreamID, FrameTypeExt, f.Flags())
+	payload = payload[FrameHeaderLen:]
+	binary.BigEndian.PutUint32(payload, f.ExtendedType&maxExtendedType)
+	payload = payload[4:]
+
+	if metadataLen > 0 {
+		var mdLengthBuf [4]byte
+		binary.BigEndian.PutUint32(mdLengthBuf[:], metadataLen)
+		copy(payload[0:3], mdLengthBuf[1:])
+		payload = payload[3:]
+		copy(payload, f.Metadata)
+		payload = payload[metadataLen:]
+	}
+
+	copy(payload, f.Data)
+}
+
+func (f *Ext) Size() uint32 {
+	size := uint32(FrameHeaderLen + 4)
+
+	size += uint32(len(f.Metadata) + len(f.Data))
+	metadataLen := uint32(len(f.Metadata))
+	if metadataLen > 0 {
+		size += 3
+	}
+	return size
+}
+
+// UnsupportedExt returns the ERROR frame that rejects an EXT frame
+// with an extended type that is not supported and the IGNORE flag not
+// set. Extensions on stream 0 apply to the connection, so rejecting
+// them is a connection error.
+func UnsupportedExt(f *Ext) *Error {
+	code := ErrCodeRejected
+	if f.StreamID == 0 {
+		code = ErrCodeConnectionError
+	}
+	return &Error{
+		StreamID: f.StreamID,
+		Code:     code,
+		Data:     "unsupported extension " + strconv.FormatUint(uint64(f.ExtendedType), 10),
+	}
+}
@@ -0,0 +1,40 @@
+package frames_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/internal/frames"
+)
+
+func TestExt(t *testing.T) {
+	for name, ext := range map[string]frames.Ext{
+		"metadata": {StreamID: 3, ExtendedType: 7, Metadata: []byte("metadata"), Data: []byte("data")},
+		"ignore":   {ExtendedType: 0x7FFFFFFF, Metadata: []byte{}, Data: []byte("data"), Ignore: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f, err := frames.Decode(encode(&ext))
+			require.NoError(t, err)
+			assert.Equal(t, &ext, f)
+		})
+	}
+}
+
+func TestExtInvalid(t *testing.T) {
+	_, err := frames.Decode(encode(&frames.Ext{StreamID: 3}))
+	assert.ErrorIs(t, err, frames.ErrInvalidFrame)
+	assert.EqualError(t, err, "invalid EXT frame: extended type must be greater than 0")
+
+	_, err = frames.Decode(encode(&frames.Ext{StreamID: 3, ExtendedType: 7})[:frames.FrameHeaderLen+2])
+	assert.EqualError(t, err, "invalid EXT frame: extended type is truncated")
+}
+
+func TestUnsupportedExt(t *testing.T) {
+	e := frames.UnsupportedExt(&frames.Ext{StreamID: 3, ExtendedType: 7})
+	assert.Equal(t, &frames.Error{StreamID: 3, Code: frames.ErrCodeRejected, Data: "unsupported extension 7"}, e)
+
+	e = frames.UnsupportedExt(&frames.Ext{ExtendedType: 7})
+	assert.Equal(t, frames.ErrCodeConnectionError, e.Code)
+}
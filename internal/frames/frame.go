@@ -3,6 +3,7 @@ package frames
 type Frame interface {
 	GetStreamID() uint32
 	Type() FrameType
+	Flags() FrameFlag
 	Decode(header *FrameHeader, payload []byte) error
 	Size() uint32
 	Encode(buffer []byte)
@@ -0,0 +1,59 @@
+package frames
+
+import (
+	"encoding/binary"
+)
+
+// https://rsocket.io/about/protocol/#keepalive-frame-0x03
+
+type Keepalive struct {
+	LastReceivedPosition uint64
+	Data                 []byte
+	Respond              bool
+}
+
+func (f *Keepalive) GetStreamID() uint32 {
+	return 0
+}
+
+func (f *Keepalive) Type() FrameType {
+	return FrameTypeKeepalive
+}
+
+func (f *Keepalive) Decode(header *FrameHeader, payload []byte) error {
+	r := newReader(header, payload)
+	position := r.uint64("last received position") & 0x7FFFFFFFFFFFFFFF
+	data := r.rest()
+	if r.err != nil {
+		return r.err
+	}
+
+	*f = Keepalive{
+		LastReceivedPosition: position,
+		Data:                 data,
+		Respond:              header.Flag().Check(FlagRespond),
+	}
+
+	return nil
+}
+
+func (f *Keepalive) Flags() FrameFlag {
+	if f.Respond {
+		return FlagRespond
+	}
+	return 0
+}
+
+func (f *Keepalive) Encode(buf []byte) {
+	payload := buf
+
+	ResetFrameHeader(payload, 0, FrameTypeKeepalive, f.Flags())
+	payload = payload[FrameHeaderLen:]
+	binary.BigEndian.PutUint64(payload, f.LastReceivedPosition&0x7FFFFFFFFFFFFFFF)
+	payload = payload[8:]
+	copy(payload, f.Data)
+}
+
+func (f *Keepalive) Size() uint32 {
+	return uint32(FrameHeaderLen + 8 + len(f.Data))
+}
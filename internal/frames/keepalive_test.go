@@ -0,0 +1,28 @@
+package frames_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/internal/frames"
+)
+
+func TestKeepalive(t *testing.T) {
+	k := frames.Keepalive{
+		LastReceivedPosition: 1234,
+		Data:                 []byte("ping"),
+		Respond:              true,
+	}
+
+	buf := make([]byte, k.Size())
+	k.Encode(buf)
+
+	var k2 frames.Keepalive
+	h := frames.ParseFrameHeader(buf)
+	require.NoError(t, k2.Decode(&h, buf[frames.FrameHeaderLen:]))
+
+	assert.Equal(t, frames.FrameTypeKeepalive, h.Type())
+	assert.Equal(t, k, k2)
+}
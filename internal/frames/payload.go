@@ -31,19 +31,19 @@ func (f *Payload) Decode(header *FrameHeader, payload []byte) error {
 	next := flags.Check(FlagNext)
 	metadata := emptyBuffer
 
+	r := newReader(header, payload)
 	if hasMetadata {
-		var mdLengthBuf [4]byte
-		copy(mdLengthBuf[1:], payload[0:3])
-		mdLength := binary.BigEndian.Uint32(mdLengthBuf[:])
-		payload = payload[3:]
-		metadata = payload[:mdLength]
-		payload = payload[mdLength:]
+		metadata = r.metadata()
+	}
+	data := r.rest()
+	if r.err != nil {
+		return r.err
 	}
 
 	*f = Payload{
 		StreamID: header.StreamID(),
 		Metadata: metadata,
-		Data:     payload,
+		Data:     data,
 		Follows:  follows,
 		Complete: complete,
 		Next:     next,
@@ -52,9 +52,7 @@ func (f *Payload) Decode(header *FrameHeader, payload []byte) error {
 	return nil
 }
 
-func (f *Payload) Encode(buf []byte) {
-	metadataLen := uint32(len(f.Metadata))
-	payload := buf
+func (f *Payload) Flags() FrameFlag {
 	var flags FrameFlag
 	if f.Follows {
 		flags |= FlagFollow
@@ -65,11 +63,17 @@ func (f *Payload) Encode(buf []byte) {
 	if f.Next {
 		flags |= FlagNext
 	}
-	if metadataLen > 0 {
+	if len(f.Metadata) > 0 {
 		flags |= FlagMetadata
 	}
+	return flags
+}
+
+func (f *Payload) Encode(buf []byte) {
+	metadataLen := uint32(len(f.Metadata))
+	payload := buf
 
-	ResetFrameHeader(payload, f.StreamID, FrameTypePayload, flags)
+	ResetFrameHeader(payload, f.StreamID, FrameTypePayload, f.Flags())
 	payload = payload[FrameHeaderLen:]
 
 	if metadataLen > 0 {
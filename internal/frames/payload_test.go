@@ -54,3 +54,20 @@ func fill(buf []byte) {
 		b++
 	}
 }
+
+func TestTrailingData(t *testing.T) {
+	for _, f := range []Frame{
+		&Payload{StreamID: 1, Metadata: []byte("metadata"), Data: []byte("data"), Next: true},
+		&RequestPayload{FrameType: FrameTypeRequestStream, StreamID: 1, Metadata: []byte("metadata"), Data: []byte("data"), InitialN: 10},
+	} {
+		full := make([]byte, f.Size())
+		f.Encode(full)
+
+		data := TrailingData(f)
+		require.Equal(t, "data", string(data))
+		prefix := make([]byte, int(f.Size())-len(data))
+		f.Encode(prefix)
+		assert.Equal(t, full, append(prefix, data...))
+	}
+	assert.Nil(t, TrailingData(&Cancel{StreamID: 1}))
+}
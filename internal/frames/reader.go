@@ -0,0 +1,135 @@
+package frames
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+)
+
+// ErrInvalidFrame is matched by the errors returned
+// for frames that cannot be decoded.
+var ErrInvalidFrame = errors.New("invalid frame")
+
+// InvalidFrameError describes a frame that cannot be decoded,
+// such as one with a field that is truncated or longer than the frame.
+type InvalidFrameError struct {
+	StreamID uint32
+	Type     FrameType
+	Reason   string
+}
+
+func (e *InvalidFrameError) Error() string {
+	return "invalid " + e.Type.String() + " frame: " + e.Reason
+}
+
+func (e *InvalidFrameError) Is(target error) bool {
+	return target == ErrInvalidFrame
+}
+
+// InvalidFrame returns the ERROR frame that tells the peer that a frame
+// it sent on a stream could not be decoded because of err.
+func InvalidFrame(streamID uint32, err error) *Error {
+	var ferr *InvalidFrameError
+	if errors.As(err, &ferr) {
+		streamID = ferr.StreamID
+	}
+	return &Error{
+		StreamID: streamID,
+		Code:     ErrCodeInvalid,
+		Data:     err.Error(),
+	}
+}
+
+// reader reads the fields of a frame. Reading past the end of
+// the frame records an error and returns zero values.
+type reader struct {
+	header *FrameHeader
+	buf    []byte
+	err    error
+}
+
+func newReader(header *FrameHeader, buf []byte) reader {
+	return reader{header: header, buf: buf}
+}
+
+func (r *reader) fail(reason string) {
+	if r.err == nil {
+		r.err = &InvalidFrameError{
+			StreamID: r.header.StreamID(),
+			Type:     r.header.Type(),
+			Reason:   reason,
+		}
+	}
+	r.buf = nil
+}
+
+// next returns the next n bytes of a fixed size field.
+func (r *reader) next(field string, n int) []byte {
+	if len(r.buf) < n {
+		r.fail(field + " is truncated")
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+// bytes returns the next n bytes of a field whose length
+// was read from the frame.
+func (r *reader) bytes(field string, n int) []byte {
+	if len(r.buf) < n {
+		r.fail(field + " length " + strconv.Itoa(n) + " exceeds the remaining " + strconv.Itoa(len(r.buf)) + " bytes")
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) uint8(field string) uint8 {
+	if b := r.next(field, 1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) uint16(field string) uint16 {
+	if b := r.next(field, 2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) uint24(field string) uint32 {
+	if b := r.next(field, 3); b != nil {
+		return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+	}
+	return 0
+}
+
+func (r *reader) uint32(field string) uint32 {
+	if b := r.next(field, 4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) uint64(field string) uint64 {
+	if b := r.next(field, 8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+// metadata reads metadata prefixed with its 24-bit length.
+func (r *reader) metadata() []byte {
+	n := r.uint24("metadata length")
+	return r.bytes("metadata", int(n))
+}
+
+// rest returns the remaining bytes of the frame.
+func (r *reader) rest() []byte {
+	b := r.buf
+	r.buf = r.buf[len(r.buf):]
+	return b
+}
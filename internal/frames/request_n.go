@@ -20,7 +20,11 @@ func (f *RequestN) Type() FrameType {
 }
 
 func (f *RequestN) Decode(header *FrameHeader, payload []byte) error {
-	n := binary.BigEndian.Uint32(payload)
+	r := newReader(header, payload)
+	n := r.uint32("request N")
+	if r.err != nil {
+		return r.err
+	}
 
 	*f = RequestN{
 		StreamID: header.StreamID(),
@@ -30,9 +34,13 @@ func (f *RequestN) Decode(header *FrameHeader, payload []byte) error {
 	return nil
 }
 
+func (f *RequestN) Flags() FrameFlag {
+	return 0
+}
+
 func (f *RequestN) Encode(buf []byte) {
 	payload := buf
-	ResetFrameHeader(payload, f.StreamID, FrameTypeRequestN, 0)
+	ResetFrameHeader(payload, f.StreamID, FrameTypeRequestN, f.Flags())
 	payload = payload[FrameHeaderLen:]
 	binary.BigEndian.PutUint32(payload, f.N)
 }
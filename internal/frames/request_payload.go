@@ -37,9 +37,9 @@ func (f *RequestPayload) Decode(header *FrameHeader, payload []byte) error {
 	initialN := uint32(1)
 	complete := true
 
+	r := newReader(header, payload)
 	if frameType == FrameTypeRequestStream || frameType == FrameTypeRequestChannel {
-		initialN = binary.BigEndian.Uint32(payload)
-		payload = payload[4:]
+		initialN = r.uint32("initial request N")
 	}
 	if frameType == FrameTypeRequestChannel {
 		complete = flags.Check(FlagComplete)
@@ -47,19 +47,18 @@ func (f *RequestPayload) Decode(header *FrameHeader, payload []byte) error {
 
 	metadata := emptyBuffer
 	if hasMetadata {
-		var mdLengthBuf [4]byte
-		copy(mdLengthBuf[1:], payload[0:3])
-		mdLength := binary.BigEndian.Uint32(mdLengthBuf[:])
-		payload = payload[3:]
-		metadata = payload[:mdLength]
-		payload = payload[mdLength:]
+		metadata = r.metadata()
+	}
+	data := r.rest()
+	if r.err != nil {
+		return r.err
 	}
 
 	*f = RequestPayload{
 		FrameType: frameType,
 		StreamID:  header.StreamID(),
 		Metadata:  metadata,
-		Data:      payload,
+		Data:      data,
 		Follows:   follows,
 		Complete:  complete,
 		InitialN:  initialN,
@@ -68,19 +67,22 @@ func (f *RequestPayload) Decode(header *FrameHeader, payload []byte) error {
 	return nil
 }
 
-func (f *RequestPayload) Encode(buf []byte) {
-	payload := buf
-	metadataLen := uint32(len(f.Metadata))
+func (f *RequestPayload) Flags() FrameFlag {
 	var flags FrameFlag
 	if f.FrameType == FrameTypeRequestChannel && f.Complete {
 		flags |= FlagComplete
 	}
-
-	if metadataLen > 0 {
+	if len(f.Metadata) > 0 {
 		flags |= FlagMetadata
 	}
+	return flags
+}
+
+func (f *RequestPayload) Encode(buf []byte) {
+	payload := buf
+	metadataLen := uint32(len(f.Metadata))
 
-	ResetFrameHeader(payload, f.StreamID, f.FrameType, flags)
+	ResetFrameHeader(payload, f.StreamID, f.FrameType, f.Flags())
 	payload = payload[FrameHeaderLen:]
 
 	if f.FrameType == FrameTypeRequestStream || f.FrameType == FrameTypeRequestChannel {
@@ -35,36 +35,30 @@ func (f *Setup) Decode(header *FrameHeader, payload []byte) error {
 	lease := flags.Check(FlagLease)
 	var metadata []byte
 
-	major := binary.BigEndian.Uint16(payload)
-	minor := binary.BigEndian.Uint16(payload[2:])
-	timeBetweenKeepalive := time.Millisecond * time.Duration(binary.BigEndian.Uint32(payload[4:]))
-	maxLifetime := time.Millisecond * time.Duration(binary.BigEndian.Uint32(payload[8:]))
+	r := newReader(header, payload)
+	major := r.uint16("major version")
+	minor := r.uint16("minor version")
+	timeBetweenKeepalive := time.Millisecond * time.Duration(r.uint32("keepalive interval"))
+	maxLifetime := time.Millisecond * time.Duration(r.uint32("max lifetime"))
 	var token []byte
 
-	data := payload[12:]
-
 	if resume {
-		tokenLength := binary.BigEndian.Uint16(data)
-		token = data[2 : 2+tokenLength]
-		data = data[2+tokenLength:]
+		tokenLength := r.uint16("resume token length")
+		token = r.bytes("resume token", int(tokenLength))
 	}
 
-	metadataMimeLength := data[0]
-	mimeMetadata := string(data[1 : 1+metadataMimeLength])
-	data = data[1+metadataMimeLength:]
+	metadataMimeLength := r.uint8("metadata MIME type length")
+	mimeMetadata := string(r.bytes("metadata MIME type", int(metadataMimeLength)))
 
-	dataMimeLength := data[0]
-	mimeData := string(data[1 : 1+dataMimeLength])
-	data = data[1+dataMimeLength:]
-	payload = data
+	dataMimeLength := r.uint8("data MIME type length")
+	mimeData := string(r.bytes("data MIME type", int(dataMimeLength)))
 
 	if hasMetadata {
-		var mdLengthBuf [4]byte
-		copy(mdLengthBuf[1:], data[0:3])
-		mdLength := binary.BigEndian.Uint32(mdLengthBuf[:])
-		data = data[3:]
-		metadata = data[:mdLength]
-		payload = data[mdLength:]
+		metadata = r.metadata()
+	}
+	payload = r.rest()
+	if r.err != nil {
+		return r.err
 	}
 
 	*f = Setup{
@@ -83,28 +77,32 @@ func (f *Setup) Decode(header *FrameHeader, payload []byte) error {
 	return nil
 }
 
-func (f *Setup) Encode(buf []byte) {
-	tokenLen := uint16(len(f.Token))
-	metadataLen := uint32(len(f.Metadata))
-	payload := buf
+func (f *Setup) Flags() FrameFlag {
 	var flags FrameFlag
 	if f.Lease {
 		flags |= FlagLease
 	}
-	if tokenLen > 0 {
+	if len(f.Token) > 0 {
 		flags |= FlagResume
 	}
-	if metadataLen > 0 {
+	if len(f.Metadata) > 0 {
 		flags |= FlagMetadata
 	}
+	return flags
+}
+
+func (f *Setup) Encode(buf []byte) {
+	tokenLen := uint16(len(f.Token))
+	metadataLen := uint32(len(f.Metadata))
+	payload := buf
 
-	ResetFrameHeader(payload, 0, FrameTypeSetup, flags)
+	ResetFrameHeader(payload, 0, FrameTypeSetup, f.Flags())
 	payload = payload[FrameHeaderLen:]
 
 	binary.BigEndian.PutUint16(payload, f.MajorVersion)
 	binary.BigEndian.PutUint16(payload[2:], f.MinorVersion)
-	binary.BigEndian.PutUint32(payload[4:], uint32(f.TimeBetweenKeepalive)/uint32(time.Millisecond))
-	binary.BigEndian.PutUint32(payload[8:], uint32(f.MaxLifetime)/uint32(time.Millisecond))
+	binary.BigEndian.PutUint32(payload[4:], uint32(f.TimeBetweenKeepalive/time.Millisecond))
+	binary.BigEndian.PutUint32(payload[8:], uint32(f.MaxLifetime/time.Millisecond))
 
 	payload = payload[12:]
 
@@ -142,7 +140,7 @@ func (f *Setup) Size() uint32 {
 
 	metadataLen := uint32(len(f.Metadata))
 	tokenLen := uint32(len(f.Token))
-	size += metadataLen + tokenLen + uint32(len(f.Data)+len(f.Token)+len(f.MimeMetadata)+len(f.MimeData))
+	size += metadataLen + tokenLen + uint32(len(f.Data)+len(f.MimeMetadata)+len(f.MimeData))
 	if tokenLen > 0 {
 		size += 2
 	}
@@ -3,6 +3,7 @@ package frames_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/nanobus/iota/go/internal/frames"
 	"github.com/stretchr/testify/assert"
@@ -28,3 +29,21 @@ func TestSetup(t *testing.T) {
 
 	assert.Equal(t, s2, s)
 }
+
+func TestSetupKeepalive(t *testing.T) {
+	s := frames.Setup{
+		MajorVersion:         1,
+		TimeBetweenKeepalive: 20 * time.Second,
+		MaxLifetime:          90 * time.Second,
+	}
+
+	buf := make([]byte, s.Size())
+	s.Encode(buf)
+
+	var s2 frames.Setup
+	f := frames.ParseFrameHeader(buf)
+	require.NoError(t, s2.Decode(&f, buf[frames.FrameHeaderLen:]))
+
+	assert.Equal(t, 20*time.Second, s2.TimeBetweenKeepalive)
+	assert.Equal(t, 90*time.Second, s2.MaxLifetime)
+}
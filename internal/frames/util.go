@@ -1,19 +1,7 @@
 package frames
 
-import (
-	"encoding/binary"
-)
-
 var emptyBuffer = []byte{}
 
-func readString(payload []byte) (string, []byte) {
-	strLen := binary.BigEndian.Uint16(payload[:2])
-	payload = payload[2:]
-	strBytes := payload[:strLen]
-	str := string(strBytes)
-	return str, payload[strLen:]
-}
-
 func splitPayloads(frames []Frame, streamID uint32, next, complete bool, maxFrameSize uint32, data, metadata []byte) []Frame {
 	for {
 		lenMd := uint32(len(metadata))
@@ -59,3 +47,19 @@ func splitPayloads(frames []Frame, streamID uint32, next, complete bool, maxFram
 
 	return frames
 }
+
+// TrailingData returns the data that Encode copies to the end of f.
+// Encoding f into a buffer of f.Size() minus the length of the data
+// writes the rest of the frame, so large payloads can be written
+// after it without being copied.
+func TrailingData(f Frame) []byte {
+	switch v := f.(type) {
+	case *Payload:
+		return v.Data
+	case *RequestPayload:
+		return v.Data
+	case *Ext:
+		return v.Data
+	}
+	return nil
+}
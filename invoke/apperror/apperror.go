@@ -0,0 +1,168 @@
+// Package apperror sends application errors with a machine-readable
+// code and details in APPLICATION_ERROR frames. Importing it makes
+// invoke.ParseError decode them.
+package apperror
+
+import (
+	"sort"
+
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/msgpack"
+	"github.com/nanobus/iota/go/payload"
+)
+
+func init() {
+	invoke.SetErrorDecoder(Parse)
+}
+
+// Error is an application error with a machine-readable code and details,
+// such as validation field errors or retry-after hints. Handlers return it
+// to fail a request and requesters receive it back from Block.
+//
+// It is sent as the data of an APPLICATION_ERROR frame, encoded as a
+// MessagePack map:
+//
+//	{
+//	  "code":    string,
+//	  "message": string,
+//	  "details": map[string]any  // omitted when empty
+//	}
+//
+// With at most three fields the map is a fixmap. Its first byte
+// (0x80-0x8f) is a UTF-8 continuation byte, which cannot start valid
+// UTF-8 text, so receivers tell it apart from the plain text message of
+// other errors.
+type Error struct {
+	Code    string
+	Message string
+	Details map[string]any
+}
+
+var _ = (msgpack.Codec)((*Error)(nil))
+
+// New creates an application error.
+func New(code, message string, details map[string]any) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the error as a *payload.Error with
+// the APPLICATION_ERROR code.
+func (e *Error) Unwrap() error {
+	return payload.NewError(payload.ErrCodeApplicationError, e.Message)
+}
+
+// ErrorData returns the ERROR frame data for the error.
+func (e *Error) ErrorData() string {
+	data, err := msgpack.ToBytes(e)
+	if err != nil {
+		return e.Message
+	}
+	return string(data)
+}
+
+func (e *Error) Encode(encoder msgpack.Writer) error {
+	if e == nil {
+		encoder.WriteNil()
+		return nil
+	}
+	numFields := uint32(2)
+	if len(e.Details) > 0 {
+		numFields++
+	}
+	encoder.WriteMapSize(numFields)
+	encoder.WriteString("code")
+	encoder.WriteString(e.Code)
+	encoder.WriteString("message")
+	encoder.WriteString(e.Message)
+	if len(e.Details) > 0 {
+		encoder.WriteString("details")
+		keys := make([]string, 0, len(e.Details))
+		for k := range e.Details {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		encoder.WriteMapSize(uint32(len(keys)))
+		for _, k := range keys {
+			encoder.WriteString(k)
+			encoder.WriteAny(e.Details[k])
+		}
+	}
+
+	return encoder.Err()
+}
+
+func (e *Error) Decode(decoder msgpack.Reader) error {
+	numFields, err := decoder.ReadMapSize()
+	if err != nil {
+		return err
+	}
+
+	for numFields > 0 {
+		numFields--
+		field, err := decoder.ReadString()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case "code":
+			e.Code, err = decoder.ReadString()
+		case "message":
+			e.Message, err = decoder.ReadString()
+		case "details":
+			e.Details, err = decodeDetails(decoder)
+		default:
+			err = decoder.Skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeDetails(decoder msgpack.Reader) (map[string]any, error) {
+	size, err := decoder.ReadMapSize()
+	if err != nil {
+		return nil, err
+	}
+	details := make(map[string]any, size)
+	for size > 0 {
+		size--
+		key, err := decoder.ReadString()
+		if err != nil {
+			return nil, err
+		}
+		if details[key], err = decoder.ReadAny(); err != nil {
+			return nil, err
+		}
+	}
+	return details, nil
+}
+
+// Parse decodes APPLICATION_ERROR data encoded by Error. It returns nil
+// if data is not an encoded Error.
+func Parse(data string) error {
+	if !isFixMap(data) {
+		return nil
+	}
+	var e Error
+	decoder := msgpack.NewDecoder([]byte(data))
+	if err := e.Decode(&decoder); err != nil || e.Code == "" {
+		return nil
+	}
+	return &e
+}
+
+// isFixMap reports whether data starts like an encoded Error.
+func isFixMap(data string) bool {
+	return len(data) > 0 && data[0]&0xf0 == 0x80
+}
@@ -0,0 +1,58 @@
+package apperror_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/invoke/apperror"
+	"github.com/nanobus/iota/go/payload"
+)
+
+func TestErrorRoundTrip(t *testing.T) {
+	sent := apperror.New("invalid_argument", "name is required", map[string]any{
+		"field":      "name",
+		"retryAfter": int64(30),
+	})
+
+	f := frames.NewError(1, sent)
+	assert.Equal(t, frames.ErrCodeApplicationError, f.Code)
+
+	err := invoke.ParseError(payload.ErrCode(f.Code), f.Data)
+	var received *apperror.Error
+	require.True(t, errors.As(err, &received))
+	assert.Equal(t, sent, received)
+	assert.Equal(t, "name is required", err.Error())
+
+	var perr *payload.Error
+	require.True(t, errors.As(err, &perr))
+	assert.Equal(t, payload.ErrCodeApplicationError, perr.Code)
+}
+
+func TestErrorWithoutDetails(t *testing.T) {
+	sent := apperror.New("not_found", "no such user", nil)
+
+	f := frames.NewError(1, sent)
+	err := invoke.ParseError(payload.ErrCode(f.Code), f.Data)
+	assert.Equal(t, sent, err)
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	err := invoke.ParseError(payload.ErrCodeApplicationError, "boom")
+	assert.Equal(t, payload.NewError(payload.ErrCodeApplicationError, "boom"), err)
+
+	err = invoke.ParseError(payload.ErrCodeApplicationError, "")
+	assert.Equal(t, payload.NewError(payload.ErrCodeApplicationError, ""), err)
+}
+
+func TestParseErrorUTF8Message(t *testing.T) {
+	// Map16 and map32 prefixes can start UTF-8 text.
+	for _, message := range []string{"\u0790 failed", "\u07d0 failed"} {
+		err := invoke.ParseError(payload.ErrCodeApplicationError, message)
+		assert.Equal(t, payload.NewError(payload.ErrCodeApplicationError, message), err)
+	}
+}
@@ -0,0 +1,32 @@
+package invoke
+
+import (
+	"github.com/nanobus/iota/go/payload"
+)
+
+// ErrorDecoder decodes structured APPLICATION_ERROR data. It returns nil
+// if data is a plain text message.
+type ErrorDecoder func(data string) error
+
+var errorDecoder ErrorDecoder
+
+// SetErrorDecoder sets the decoder ParseError uses for APPLICATION_ERROR
+// data. Importing the apperror package sets it, as handler and the wasmrs
+// host and guest do.
+func SetErrorDecoder(decoder ErrorDecoder) {
+	errorDecoder = decoder
+}
+
+// ParseError creates the error received in an ERROR frame. APPLICATION_ERROR
+// data is decoded by the decoder set with SetErrorDecoder. Otherwise the
+// data is the error message of a *payload.Error.
+func ParseError(code payload.ErrCode, data string) error {
+	if code == payload.ErrCodeApplicationError {
+		if errorDecoder != nil {
+			if err := errorDecoder(data); err != nil {
+				return err
+			}
+		}
+	}
+	return payload.NewError(code, data)
+}
@@ -2,6 +2,7 @@ package invoke
 
 import (
 	"context"
+	"sync"
 
 	"github.com/nanobus/iota/go/operations"
 	"github.com/nanobus/iota/go/payload"
@@ -14,6 +15,12 @@ type (
 	FireAndForgetHandler   func(context.Context, payload.Payload)
 	RequestStreamHandler   func(context.Context, payload.Payload) flux.Flux[payload.Payload]
 	RequestChannelHandler  func(context.Context, payload.Payload, flux.Flux[payload.Payload]) flux.Flux[payload.Payload]
+
+	// ExtensionHandler handles the EXT frames of a protocol extension.
+	// It is called in the order frames are received and must not block.
+	// The payload is only valid until it returns. Use payload.Retain to
+	// keep it.
+	ExtensionHandler func(ctx context.Context, streamID uint32, p payload.Payload)
 )
 
 type HandlerInfo struct {
@@ -34,6 +41,10 @@ type Handlers struct {
 }
 
 var (
+	// mu guards the registry below. Imports can be added while
+	// connections read the operations table.
+	mu sync.RWMutex
+
 	requestResponseHandlers    = make([]RequestResponseHandler, 0, 20)
 	requestResponseHandlerInfo = make([]HandlerInfo, 0, 20)
 
@@ -52,23 +63,32 @@ var (
 	requestChannelImports  = make([]HandlerInfo, 0, 20)
 )
 
+// GetOperations returns a snapshot of the registered operations.
 func GetOperations() Operations {
+	mu.RLock()
+	defer mu.RUnlock()
 	return Operations{
 		Exported: Handlers{
-			RequestResponse: requestResponseHandlerInfo,
-			FireAndForget:   fireAndForgetHandlerInfo,
-			RequestStream:   requestStreamHandlerInfo,
-			RequestChannel:  requestChannelHandlerInfo,
+			RequestResponse: snapshot(requestResponseHandlerInfo),
+			FireAndForget:   snapshot(fireAndForgetHandlerInfo),
+			RequestStream:   snapshot(requestStreamHandlerInfo),
+			RequestChannel:  snapshot(requestChannelHandlerInfo),
 		},
 		Imported: Handlers{
-			RequestResponse: requestResponseImports,
-			FireAndForget:   requestFNFImports,
-			RequestStream:   requestStreamImports,
-			RequestChannel:  requestChannelImports,
+			RequestResponse: snapshot(requestResponseImports),
+			FireAndForget:   snapshot(requestFNFImports),
+			RequestStream:   snapshot(requestStreamImports),
+			RequestChannel:  snapshot(requestChannelImports),
 		},
 	}
 }
 
+func snapshot(infos []HandlerInfo) []HandlerInfo {
+	return append([]HandlerInfo(nil), infos...)
+}
+
+// GetOperationsTable returns a snapshot of the registered operations as
+// a table.
 func GetOperationsTable() operations.Table {
 	opers := GetOperations()
 	exports := opers.Exported
@@ -110,11 +130,15 @@ func convertOperations(handlers []HandlerInfo, requestType operations.RequestTyp
 }
 
 func ExportRequestResponse(namespace, operation string, handler RequestResponseHandler) {
+	mu.Lock()
+	defer mu.Unlock()
 	requestResponseHandlers = append(requestResponseHandlers, handler)
 	requestResponseHandlerInfo = append(requestResponseHandlerInfo, HandlerInfo{namespace, operation})
 }
 
 func GetRequestResponseHandler(operationID uint32) RequestResponseHandler {
+	mu.RLock()
+	defer mu.RUnlock()
 	if operationID >= uint32(len(requestResponseHandlers)) {
 		return nil
 	}
@@ -122,11 +146,15 @@ func GetRequestResponseHandler(operationID uint32) RequestResponseHandler {
 }
 
 func ExportFireAndForget(namespace, operation string, handler FireAndForgetHandler) {
+	mu.Lock()
+	defer mu.Unlock()
 	fireAndForgetHandlers = append(fireAndForgetHandlers, handler)
 	fireAndForgetHandlerInfo = append(fireAndForgetHandlerInfo, HandlerInfo{namespace, operation})
 }
 
 func GetFireAndForgetHandler(operationID uint32) FireAndForgetHandler {
+	mu.RLock()
+	defer mu.RUnlock()
 	if operationID >= uint32(len(fireAndForgetHandlers)) {
 		return nil
 	}
@@ -134,11 +162,15 @@ func GetFireAndForgetHandler(operationID uint32) FireAndForgetHandler {
 }
 
 func ExportRequestStream(namespace, operation string, handler RequestStreamHandler) {
+	mu.Lock()
+	defer mu.Unlock()
 	requestStreamHandlers = append(requestStreamHandlers, handler)
 	requestStreamHandlerInfo = append(requestStreamHandlerInfo, HandlerInfo{namespace, operation})
 }
 
 func GetRequestStreamHandler(operationID uint32) RequestStreamHandler {
+	mu.RLock()
+	defer mu.RUnlock()
 	if operationID >= uint32(len(requestStreamHandlers)) {
 		return nil
 	}
@@ -146,11 +178,15 @@ func GetRequestStreamHandler(operationID uint32) RequestStreamHandler {
 }
 
 func ExportRequestChannel(namespace, operation string, handler RequestChannelHandler) {
+	mu.Lock()
+	defer mu.Unlock()
 	requestChannelHandlers = append(requestChannelHandlers, handler)
 	requestChannelHandlerInfo = append(requestChannelHandlerInfo, HandlerInfo{namespace, operation})
 }
 
 func GetRequestChannelHandler(operationID uint32) RequestChannelHandler {
+	mu.RLock()
+	defer mu.RUnlock()
 	if operationID >= uint32(len(requestChannelHandlers)) {
 		return nil
 	}
@@ -158,6 +194,8 @@ func GetRequestChannelHandler(operationID uint32) RequestChannelHandler {
 }
 
 func ImportRequestResponse(namespace, operation string) uint32 {
+	mu.Lock()
+	defer mu.Unlock()
 	for i, op := range requestResponseImports {
 		if op.Namespace == namespace && op.Operation == operation {
 			return uint32(i)
@@ -169,6 +207,8 @@ func ImportRequestResponse(namespace, operation string) uint32 {
 }
 
 func ImportFireAndForget(namespace, operation string) uint32 {
+	mu.Lock()
+	defer mu.Unlock()
 	for i, op := range requestFNFImports {
 		if op.Namespace == namespace && op.Operation == operation {
 			return uint32(i)
@@ -180,6 +220,8 @@ func ImportFireAndForget(namespace, operation string) uint32 {
 }
 
 func ImportRequestStream(namespace, operation string) uint32 {
+	mu.Lock()
+	defer mu.Unlock()
 	for i, op := range requestStreamImports {
 		if op.Namespace == namespace && op.Operation == operation {
 			return uint32(i)
@@ -191,6 +233,8 @@ func ImportRequestStream(namespace, operation string) uint32 {
 }
 
 func ImportRequestChannel(namespace, operation string) uint32 {
+	mu.Lock()
+	defer mu.Unlock()
 	for i, op := range requestChannelImports {
 		if op.Namespace == namespace && op.Operation == operation {
 			return uint32(i)
@@ -0,0 +1,47 @@
+package invoke_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/operations"
+)
+
+func TestConcurrentImports(t *testing.T) {
+	const imports = 50
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < imports; i++ {
+			invoke.ImportRequestResponse("invoke.test", fmt.Sprint("op", i))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < imports; i++ {
+			invoke.GetOperationsTable()
+		}
+	}()
+	wg.Wait()
+
+	n := 0
+	for _, op := range invoke.GetOperationsTable() {
+		if op.Direction == operations.Import && op.Type == operations.RequestResponse && op.Namespace == "invoke.test" {
+			n++
+		}
+	}
+	assert.Equal(t, imports, n)
+}
+
+func TestGetOperationsSnapshot(t *testing.T) {
+	ops := invoke.GetOperations()
+	before := len(ops.Imported.RequestStream)
+	invoke.ImportRequestStream("invoke.test", fmt.Sprint("snapshot", before))
+	assert.Len(t, ops.Imported.RequestStream, before)
+	assert.Len(t, invoke.GetOperations().Imported.RequestStream, before+1)
+}
@@ -2,9 +2,13 @@ package invoke
 
 import (
 	"encoding/binary"
+	"errors"
 	"strings"
 )
 
+// ErrInvalidPortIO is returned when port metadata is malformed.
+var ErrInvalidPortIO = errors.New("invoke: invalid port metadata")
+
 type PortIO struct {
 	Port     string
 	Data     []byte
@@ -13,12 +17,21 @@ type PortIO struct {
 }
 
 func (f *PortIO) Decode(data []byte) error {
+	if len(data) < 3 {
+		return ErrInvalidPortIO
+	}
 	flags := Flags(data[0])
+	if flags.Check(flagFinal) {
+		flags |= FlagNext | FlagComplete
+	}
 	complete := flags.Check(FlagComplete)
 	next := flags.Check(FlagNext)
 	data = data[1:]
 	portLength := binary.BigEndian.Uint16(data)
 	data = data[2:]
+	if len(data) < int(portLength) {
+		return ErrInvalidPortIO
+	}
 	port := string(data[:portLength])
 	data = data[portLength:]
 
@@ -43,6 +56,9 @@ func (f *PortIO) Encode() []byte {
 	if f.Next {
 		flags |= FlagNext
 	}
+	if f.Next && f.Complete {
+		flags = flagFinal
+	}
 
 	payload[0] = byte(flags)
 	payload = payload[1:]
@@ -58,6 +74,9 @@ func (f *PortIO) Encode() []byte {
 type Flags uint8
 
 func (f Flags) String() string {
+	if f.Check(flagFinal) {
+		f |= FlagNext | FlagComplete
+	}
 	var _foo [2]string
 	foo := _foo[:0]
 	if f.Check(FlagNext) {
@@ -70,8 +89,14 @@ func (f Flags) String() string {
 }
 
 // All frame flags
+//
+// Until FlagNext and FlagComplete had bits of their own, both were 0x01,
+// which then flagged a final item: the only payload such peers send.
+// For compatibility with them, 0x01 still flags a final item, is encoded
+// for one and decodes as FlagNext|FlagComplete.
 const (
-	FlagNext Flags = 1
+	flagFinal Flags = 1 << iota
+	FlagNext
 	FlagComplete
 )
 
@@ -0,0 +1,35 @@
+package invoke_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/invoke"
+)
+
+func TestPortIO(t *testing.T) {
+	for _, port := range []invoke.PortIO{
+		{Port: "in", Next: true},
+		{Port: "in", Complete: true},
+		{Port: "in", Next: true, Complete: true},
+	} {
+		var decoded invoke.PortIO
+		require.NoError(t, decoded.Decode(port.Encode()))
+		port.Data = []byte{}
+		assert.Equal(t, port, decoded)
+	}
+
+	var decoded invoke.PortIO
+	assert.Equal(t, invoke.ErrInvalidPortIO, decoded.Decode([]byte{0x02, 0, 3, 'i'}))
+}
+
+func TestPortIOFinalItemCompatibility(t *testing.T) {
+	// Peers that predate FlagNext flag a final item with 0x01.
+	legacy := []byte{0x01, 0, 2, 'i', 'n'}
+	var port invoke.PortIO
+	require.NoError(t, port.Decode(legacy))
+	assert.Equal(t, invoke.PortIO{Port: "in", Data: []byte{}, Next: true, Complete: true}, port)
+	assert.Equal(t, legacy, port.Encode())
+}
@@ -0,0 +1,323 @@
+// Package ports carries several named ports over one request channel,
+// in the style of flow-based programming. Each payload of the channel
+// has invoke.PortIO metadata that names its port and tells whether it
+// holds an item or completes the port.
+package ports
+
+import (
+	"sync"
+
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/transform"
+)
+
+// Channel returns the flux of a request channel handler that reads the
+// input ports of in and writes its output ports. setup declares the
+// ports and subscribes to the inputs before in is subscribed to. The
+// flux completes once every output port completed and fails with the
+// first error of an output port or of in. It ignores the demand of its
+// subscriber: the payloads of the output ports are emitted as they are
+// written, so subscribers that request fewer must buffer or drop the
+// rest, as handlers do with proxy.Responder.
+func Channel(in flux.Flux[payload.Payload], setup func(*Demux, *Mux)) flux.Flux[payload.Payload] {
+	return flux.Create(func(sink flux.Sink[payload.Payload]) {
+		d := NewDemux()
+		m := NewMux(sink)
+		d.fail = m.fail
+		sink.OnSubscribe(flux.OnSubscribe{
+			// Demand is ignored; see above.
+			Request: func(int) {},
+			Cancel:  d.Cancel,
+		})
+		setup(d, m)
+		m.Seal()
+		d.Subscribe(in)
+	})
+}
+
+// Demux splits the payloads of a request channel into typed input
+// ports.
+type Demux struct {
+	// fail is called with the errors of in when Channel created d.
+	fail func(error)
+
+	mu     sync.Mutex
+	inputs map[string]input
+	sub    rx.Subscription
+	done   bool
+}
+
+// input is the processor of an input port.
+type input interface {
+	next(payload.Payload)
+	complete()
+	error(error)
+}
+
+// NewDemux returns a demultiplexer without ports.
+func NewDemux() *Demux {
+	return &Demux{
+		inputs: make(map[string]input),
+	}
+}
+
+// Input declares the input port named port and returns the flux of its
+// items, decoded by t. Items are buffered until they are requested.
+// Items that arrive before the flux is subscribed to are dropped, so
+// subscribe before the demultiplexer subscribes to its channel.
+func Input[T any](d *Demux, port string, t transform.Transform[T]) flux.Flux[T] {
+	p := &inputPort[T]{
+		Processor: flux.NewMulticastProcessor[T](flux.OverflowBuffer(0)),
+		decode:    t.Decode,
+	}
+	d.mu.Lock()
+	if _, ok := d.inputs[port]; ok {
+		d.mu.Unlock()
+		panic("ports: duplicate input " + port)
+	}
+	d.inputs[port] = p
+	d.mu.Unlock()
+	return p
+}
+
+// Subscribe subscribes to in and passes its payloads on to the input
+// ports. Payloads for ports that were not declared are ignored. A
+// payload that fails to decode fails its port. Malformed metadata and
+// the terminal signal of in are passed on to all ports.
+func (d *Demux) Subscribe(in flux.Flux[payload.Payload]) {
+	in.Subscribe(flux.Subscribe[payload.Payload]{
+		OnNext: func(p payload.Payload) {
+			var port invoke.PortIO
+			if err := port.Decode(p.Metadata()); err != nil {
+				d.terminate(err)
+				if d.fail != nil {
+					d.fail(err)
+				}
+				d.Cancel()
+				return
+			}
+			d.mu.Lock()
+			i, ok := d.inputs[port.Port]
+			d.mu.Unlock()
+			if !ok {
+				return
+			}
+			if port.Next {
+				i.next(p)
+			}
+			if port.Complete {
+				i.complete()
+			}
+		},
+		OnComplete: func() {
+			d.terminate(nil)
+		},
+		OnError: func(err error) {
+			d.terminate(err)
+			if d.fail != nil {
+				d.fail(err)
+			}
+		},
+	})
+	d.mu.Lock()
+	d.sub = in.Subscription()
+	done := d.done
+	d.mu.Unlock()
+	if done {
+		d.Cancel()
+	}
+}
+
+// Cancel cancels the subscription to the channel. The input ports do
+// not receive further items.
+func (d *Demux) Cancel() {
+	d.mu.Lock()
+	d.done = true
+	sub := d.sub
+	d.sub = nil
+	d.mu.Unlock()
+	if sub != nil {
+		sub.Cancel()
+	}
+}
+
+// terminate completes all input ports, or fails them with err.
+func (d *Demux) terminate(err error) {
+	d.mu.Lock()
+	inputs := make([]input, 0, len(d.inputs))
+	for _, i := range d.inputs {
+		inputs = append(inputs, i)
+	}
+	d.mu.Unlock()
+	for _, i := range inputs {
+		if err != nil {
+			i.error(err)
+		} else {
+			i.complete()
+		}
+	}
+}
+
+type inputPort[T any] struct {
+	flux.Processor[T]
+	decode func(payload.Payload) (T, error)
+}
+
+func (p *inputPort[T]) next(raw payload.Payload) {
+	value, err := p.decode(raw)
+	if err != nil {
+		p.Error(err)
+		return
+	}
+	p.Next(value)
+}
+
+func (p *inputPort[T]) complete() {
+	p.Complete()
+}
+
+func (p *inputPort[T]) error(err error) {
+	p.Error(err)
+}
+
+// Mux merges typed output ports into the payloads of a request channel.
+// The payloads and terminal signal of the channel are passed to its sink
+// one at a time, whichever goroutines the output ports are written from.
+type Mux struct {
+	sink flux.Sink[payload.Payload]
+
+	// mu guards the fields below and serializes the calls to sink.
+	mu      sync.Mutex
+	ports   map[string]struct{}
+	pending int
+	sealed  bool
+	done    bool
+}
+
+// NewMux returns a multiplexer without ports that writes to sink.
+func NewMux(sink flux.Sink[payload.Payload]) *Mux {
+	return &Mux{
+		sink:  sink,
+		ports: make(map[string]struct{}),
+	}
+}
+
+// Output declares the output port named port and returns the sink of
+// its items, encoded by t. Completing the sink completes the port and
+// failing it fails the channel.
+func Output[T any](m *Mux, port string, t transform.Transform[T]) flux.Sink[T] {
+	m.mu.Lock()
+	if m.sealed {
+		m.mu.Unlock()
+		panic("ports: output declared after Seal")
+	}
+	if _, ok := m.ports[port]; ok {
+		m.mu.Unlock()
+		panic("ports: duplicate output " + port)
+	}
+	m.ports[port] = struct{}{}
+	m.pending++
+	m.mu.Unlock()
+	return &outputPort[T]{
+		m:      m,
+		port:   port,
+		encode: t.Encode,
+	}
+}
+
+// Seal marks that all output ports are declared. The channel completes
+// once it is sealed and every output port completed.
+func (m *Mux) Seal() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sealed = true
+	m.finish()
+}
+
+// fail fails the channel with err unless it is done.
+func (m *Mux) fail(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done {
+		return
+	}
+	m.done = true
+	m.sink.Error(err)
+}
+
+// finish completes the channel once it is sealed and has no pending
+// output ports. It is called with mu held.
+func (m *Mux) finish() {
+	if m.done || !m.sealed || m.pending > 0 {
+		return
+	}
+	m.done = true
+	m.sink.Complete()
+}
+
+type outputPort[T any] struct {
+	m      *Mux
+	port   string
+	encode func(T) (payload.Payload, error)
+
+	// done is guarded by m.mu.
+	done bool
+}
+
+func (o *outputPort[T]) Next(value T) {
+	p, err := o.encode(value)
+	if err != nil {
+		o.Error(err)
+		return
+	}
+	metadata := invoke.PortIO{
+		Port: o.port,
+		Next: true,
+	}
+	o.m.mu.Lock()
+	defer o.m.mu.Unlock()
+	if o.done || o.m.done {
+		return
+	}
+	o.m.sink.Next(payload.New(p.Data(), metadata.Encode()))
+}
+
+func (o *outputPort[T]) Complete() {
+	metadata := invoke.PortIO{
+		Port:     o.port,
+		Complete: true,
+	}
+	o.m.mu.Lock()
+	defer o.m.mu.Unlock()
+	if o.done {
+		return
+	}
+	o.done = true
+	o.m.pending--
+	if o.m.done {
+		return
+	}
+	o.m.sink.Next(payload.New(nil, metadata.Encode()))
+	o.m.finish()
+}
+
+func (o *outputPort[T]) Error(err error) {
+	o.m.mu.Lock()
+	if o.done {
+		o.m.mu.Unlock()
+		return
+	}
+	o.done = true
+	o.m.mu.Unlock()
+	o.m.fail(err)
+}
+
+// OnSubscribe requests all items of the source that o is subscribed to.
+func (o *outputPort[T]) OnSubscribe(sub flux.OnSubscribe) {
+	if sub.Request != nil {
+		sub.Request(rx.RequestMax)
+	}
+}
@@ -0,0 +1,184 @@
+package ports_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/invoke/ports"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/rxtest"
+	"github.com/nanobus/iota/go/transform"
+)
+
+// next returns the payload of value on port.
+func next(t *testing.T, port string, value int) payload.Payload {
+	p, err := transform.Int.Encode(value)
+	require.NoError(t, err)
+	return payload.New(p.Data(), metadata(invoke.PortIO{Port: port, Next: true}))
+}
+
+// metadata encodes port, which Encode needs to be addressable for.
+func metadata(port invoke.PortIO) []byte {
+	return port.Encode()
+}
+
+// complete returns the payload that completes port.
+func complete(port string) payload.Payload {
+	return payload.New(nil, metadata(invoke.PortIO{Port: port, Complete: true}))
+}
+
+// frames describes the payloads of f as "port:value" or "port:complete".
+func frames(f flux.Flux[payload.Payload]) flux.Flux[string] {
+	return flux.Map(f, func(p payload.Payload) (string, error) {
+		var port invoke.PortIO
+		if err := port.Decode(p.Metadata()); err != nil {
+			return "", err
+		}
+		if port.Complete {
+			return port.Port + ":complete", nil
+		}
+		value, err := transform.Int.Decode(p)
+		return fmt.Sprintf("%s:%d", port.Port, value), err
+	})
+}
+
+// sum sends the doubles of the "in" port to "doubled" and their sum to
+// "sum" once "in" completes.
+func sum(d *ports.Demux, m *ports.Mux) {
+	doubled := ports.Output(m, "doubled", transform.Int)
+	total := ports.Output(m, "sum", transform.Int)
+	n := 0
+	ports.Input(d, "in", transform.Int).Subscribe(flux.Subscribe[int]{
+		OnNext: func(value int) {
+			n += value
+			doubled.Next(value * 2)
+		},
+		OnComplete: func() {
+			doubled.Complete()
+			total.Next(n)
+			total.Complete()
+		},
+		OnError: func(err error) {
+			total.Error(err)
+		},
+	})
+}
+
+func TestChannel(t *testing.T) {
+	in := flux.NewProcessor[payload.Payload]()
+	rxtest.Flux(frames(ports.Channel(in, sum))).
+		ThenRequest(rx.RequestMax).
+		Then(func() {
+			in.Next(next(t, "in", 1))
+			in.Next(next(t, "unknown", 5))
+			in.Next(next(t, "in", 2))
+			in.Next(complete("in"))
+		}).
+		ExpectNext("doubled:2", "doubled:4", "doubled:complete", "sum:3", "sum:complete").
+		ExpectComplete().
+		Verify(t)
+}
+
+func TestChannelCompletesInputs(t *testing.T) {
+	in := flux.FromSlice([]payload.Payload{next(t, "in", 4)})
+	rxtest.Flux(frames(ports.Channel(in, sum))).
+		ThenRequest(rx.RequestMax).
+		ExpectNext("doubled:8", "doubled:complete", "sum:4", "sum:complete").
+		ExpectComplete().
+		Verify(t)
+}
+
+func TestChannelWithoutOutputs(t *testing.T) {
+	in := flux.NewProcessor[payload.Payload]()
+	rxtest.Flux(ports.Channel(in, func(*ports.Demux, *ports.Mux) {})).
+		ThenRequest(rx.RequestMax).
+		ExpectComplete().
+		Verify(t)
+}
+
+func TestChannelErrors(t *testing.T) {
+	boom := errors.New("boom")
+
+	in := flux.NewProcessor[payload.Payload]()
+	rxtest.Flux(frames(ports.Channel(in, sum))).
+		ThenRequest(rx.RequestMax).
+		Then(func() { in.Error(boom) }).
+		ExpectError(boom).
+		Verify(t)
+
+	in = flux.NewProcessor[payload.Payload]()
+	rxtest.Flux(frames(ports.Channel(in, sum))).
+		ThenRequest(rx.RequestMax).
+		Then(func() { in.Next(payload.New(nil, []byte{1})) }).
+		ExpectError(invoke.ErrInvalidPortIO).
+		Verify(t)
+
+	in = flux.NewProcessor[payload.Payload]()
+	rxtest.Flux(frames(ports.Channel(in, sum))).
+		ThenRequest(rx.RequestMax).
+		Then(func() {
+			in.Next(payload.New([]byte{0xc0}, metadata(invoke.PortIO{Port: "in", Next: true})))
+		}).
+		ExpectErrorMatches(func(err error) bool { return err != nil }).
+		Verify(t)
+}
+
+func TestDuplicatePorts(t *testing.T) {
+	d := ports.NewDemux()
+	ports.Input(d, "in", transform.Int)
+	assert.Panics(t, func() { ports.Input(d, "in", transform.String) })
+
+	m := ports.NewMux(flux.NewProcessor[payload.Payload]())
+	ports.Output(m, "out", transform.Int)
+	assert.Panics(t, func() { ports.Output(m, "out", transform.Int) })
+	m.Seal()
+	assert.Panics(t, func() { ports.Output(m, "late", transform.Int) })
+}
+
+// recorder is a sink that is not safe for concurrent use, so that the
+// race detector catches calls that the multiplexer does not serialize.
+type recorder struct {
+	flux.Sink[payload.Payload]
+	items    int
+	complete bool
+}
+
+func (r *recorder) Next(payload.Payload) { r.items++ }
+func (r *recorder) Complete()            { r.complete = true }
+
+func TestMuxConcurrentOutputs(t *testing.T) {
+	const outputs, items = 4, 100
+	var r recorder
+	m := ports.NewMux(&r)
+	sinks := make([]flux.Sink[int], outputs)
+	for i := range sinks {
+		sinks[i] = ports.Output(m, fmt.Sprint("out", i), transform.Int)
+	}
+	m.Seal()
+
+	var wg sync.WaitGroup
+	for _, sink := range sinks {
+		sink := sink
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < items; i++ {
+				sink.Next(i)
+			}
+			sink.Complete()
+		}()
+	}
+	wg.Wait()
+
+	// Each port sends its items and the payload that completes it.
+	assert.Equal(t, outputs*(items+1), r.items)
+	assert.True(t, r.complete)
+}
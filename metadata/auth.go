@@ -0,0 +1,99 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Well-known authentication types.
+const (
+	AuthTypeSimple = "simple"
+	AuthTypeBearer = "bearer"
+)
+
+var wellKnownAuthTypes = map[byte]string{
+	0x00: AuthTypeSimple,
+	0x01: AuthTypeBearer,
+}
+
+// Authentication is the content of an authentication entry.
+type Authentication struct {
+	Type    string
+	Payload []byte
+}
+
+// BearerAuthentication creates bearer token authentication.
+func BearerAuthentication(token string) Authentication {
+	return Authentication{
+		Type:    AuthTypeBearer,
+		Payload: []byte(token),
+	}
+}
+
+// SimpleAuthentication creates username and password authentication.
+func SimpleAuthentication(username, password string) Authentication {
+	payload := make([]byte, 2, 2+len(username)+len(password))
+	binary.BigEndian.PutUint16(payload, uint16(len(username)))
+	payload = append(payload, username...)
+	payload = append(payload, password...)
+	return Authentication{
+		Type:    AuthTypeSimple,
+		Payload: payload,
+	}
+}
+
+// BearerToken returns the token of bearer authentication.
+func (a Authentication) BearerToken() (string, bool) {
+	if a.Type != AuthTypeBearer {
+		return "", false
+	}
+	return string(a.Payload), true
+}
+
+// Credentials returns the username and password of simple authentication.
+func (a Authentication) Credentials() (username, password string, ok bool) {
+	if a.Type != AuthTypeSimple || len(a.Payload) < 2 {
+		return "", "", false
+	}
+	n := int(binary.BigEndian.Uint16(a.Payload))
+	if len(a.Payload) < 2+n {
+		return "", "", false
+	}
+	return string(a.Payload[2 : 2+n]), string(a.Payload[2+n:]), true
+}
+
+// Encode encodes the authentication entry content.
+func (a Authentication) Encode() []byte {
+	for id, name := range wellKnownAuthTypes {
+		if name == a.Type {
+			buf := make([]byte, 0, 1+len(a.Payload))
+			buf = append(buf, id|wellKnownFlag)
+			return append(buf, a.Payload...)
+		}
+	}
+	buf := make([]byte, 0, 1+len(a.Type)+len(a.Payload))
+	buf = append(buf, byte(len(a.Type)))
+	buf = append(buf, a.Type...)
+	return append(buf, a.Payload...)
+}
+
+// ParseAuthentication parses the content of an authentication entry.
+func ParseAuthentication(data []byte) (Authentication, error) {
+	if len(data) == 0 {
+		return Authentication{}, errors.New("metadata: empty authentication metadata")
+	}
+	b := data[0]
+	data = data[1:]
+	if b&wellKnownFlag != 0 {
+		name, ok := wellKnownAuthTypes[b&^wellKnownFlag]
+		if !ok {
+			return Authentication{}, errors.New("metadata: unknown well-known authentication type")
+		}
+		return Authentication{Type: name, Payload: data}, nil
+	}
+	n := int(b)
+	if n == 0 || len(data) < n {
+		return Authentication{}, errors.New("metadata: invalid authentication type")
+	}
+	return Authentication{Type: string(data[:n]), Payload: data[n:]}, nil
+}
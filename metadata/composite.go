@@ -0,0 +1,206 @@
+package metadata
+
+import (
+	"errors"
+	"strconv"
+)
+
+const (
+	maxMimeTypeLength = 128
+	maxEntryLength    = 1<<24 - 1
+	wellKnownFlag     = 0x80
+)
+
+var errTruncated = errors.New("metadata: truncated composite metadata")
+
+// Entry is a single entry of composite metadata.
+type Entry struct {
+	MimeType string
+	Content  []byte
+}
+
+// Composite is parsed composite metadata.
+type Composite []Entry
+
+// ParseComposite parses composite metadata.
+// Entry content refers to the memory of data.
+func ParseComposite(data []byte) (Composite, error) {
+	var c Composite
+	for len(data) > 0 {
+		var mimeType string
+		b := data[0]
+		data = data[1:]
+		if b&wellKnownFlag != 0 {
+			id := b &^ wellKnownFlag
+			name, ok := wellKnownMimeTypes[id]
+			if !ok {
+				return nil, errors.New("metadata: unknown well-known MIME type 0x" + strconv.FormatUint(uint64(id), 16))
+			}
+			mimeType = name
+		} else {
+			n := int(b) + 1
+			if len(data) < n {
+				return nil, errTruncated
+			}
+			mimeType = string(data[:n])
+			data = data[n:]
+		}
+
+		if len(data) < 3 {
+			return nil, errTruncated
+		}
+		n := int(data[0])<<16 | int(data[1])<<8 | int(data[2])
+		data = data[3:]
+		if len(data) < n {
+			return nil, errTruncated
+		}
+		c = append(c, Entry{
+			MimeType: mimeType,
+			Content:  data[:n:n],
+		})
+		data = data[n:]
+	}
+	return c, nil
+}
+
+// Get returns the content of the first entry with the MIME type.
+func (c Composite) Get(mimeType string) ([]byte, bool) {
+	for _, e := range c {
+		if e.MimeType == mimeType {
+			return e.Content, true
+		}
+	}
+	return nil, false
+}
+
+// Route returns the first routing tag.
+func (c Composite) Route() (string, bool) {
+	content, ok := c.Get(MimeTypeRouting)
+	if !ok {
+		return "", false
+	}
+	tags, err := ParseRouting(content)
+	if err != nil || len(tags) == 0 {
+		return "", false
+	}
+	return tags[0], true
+}
+
+// OperationIndex returns the operation index entry.
+func (c Composite) OperationIndex() (uint32, bool) {
+	content, ok := c.Get(MimeTypeOperationIndex)
+	if !ok || len(content) != 4 {
+		return 0, false
+	}
+	return uint32(content[0])<<24 | uint32(content[1])<<16 | uint32(content[2])<<8 | uint32(content[3]), true
+}
+
+// Tracing returns the Zipkin tracing entry.
+func (c Composite) Tracing() (Tracing, bool) {
+	content, ok := c.Get(MimeTypeTracingZipkin)
+	if !ok {
+		return Tracing{}, false
+	}
+	t, err := ParseTracing(content)
+	return t, err == nil
+}
+
+// Authentication returns the authentication entry.
+func (c Composite) Authentication() (Authentication, bool) {
+	content, ok := c.Get(MimeTypeAuthentication)
+	if !ok {
+		return Authentication{}, false
+	}
+	a, err := ParseAuthentication(content)
+	return a, err == nil
+}
+
+// Builder builds composite metadata.
+type Builder struct {
+	entries Composite
+	err     error
+}
+
+// NewBuilder creates an empty builder.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Add adds an entry.
+func (b *Builder) Add(mimeType string, content []byte) *Builder {
+	if l := len(mimeType); l == 0 || l > maxMimeTypeLength {
+		b.setErr(errors.New("metadata: invalid MIME type length " + strconv.Itoa(l)))
+	} else if len(content) > maxEntryLength {
+		b.setErr(errors.New("metadata: entry for " + mimeType + " is too large"))
+	}
+	b.entries = append(b.entries, Entry{
+		MimeType: mimeType,
+		Content:  content,
+	})
+	return b
+}
+
+// Route adds a routing entry with the given tags.
+func (b *Builder) Route(tags ...string) *Builder {
+	content, err := EncodeRouting(tags...)
+	b.setErr(err)
+	return b.Add(MimeTypeRouting, content)
+}
+
+// OperationIndex adds the index of the operation to invoke.
+func (b *Builder) OperationIndex(index uint32) *Builder {
+	return b.Add(MimeTypeOperationIndex, []byte{
+		byte(index >> 24), byte(index >> 16), byte(index >> 8), byte(index),
+	})
+}
+
+// Tracing adds a Zipkin tracing entry.
+func (b *Builder) Tracing(t Tracing) *Builder {
+	content, err := t.Encode()
+	b.setErr(err)
+	return b.Add(MimeTypeTracingZipkin, content)
+}
+
+// Authentication adds an authentication entry.
+func (b *Builder) Authentication(a Authentication) *Builder {
+	return b.Add(MimeTypeAuthentication, a.Encode())
+}
+
+// BearerToken adds a bearer token authentication entry.
+func (b *Builder) BearerToken(token string) *Builder {
+	return b.Authentication(BearerAuthentication(token))
+}
+
+// Build encodes the entries.
+func (b *Builder) Build() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	size := 0
+	for _, e := range b.entries {
+		size += 1 + 3 + len(e.Content)
+		if _, ok := wellKnownID(e.MimeType); !ok {
+			size += len(e.MimeType)
+		}
+	}
+
+	buf := make([]byte, 0, size)
+	for _, e := range b.entries {
+		if id, ok := wellKnownID(e.MimeType); ok {
+			buf = append(buf, id|wellKnownFlag)
+		} else {
+			buf = append(buf, byte(len(e.MimeType)-1))
+			buf = append(buf, e.MimeType...)
+		}
+		n := len(e.Content)
+		buf = append(buf, byte(n>>16), byte(n>>8), byte(n))
+		buf = append(buf, e.Content...)
+	}
+	return buf, nil
+}
+
+func (b *Builder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,122 @@
+package metadata_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/metadata"
+)
+
+func TestComposite(t *testing.T) {
+	tracing := metadata.Tracing{
+		TraceIDHigh:  1,
+		TraceID:      2,
+		SpanID:       3,
+		ParentSpanID: 4,
+	}
+	md, err := metadata.NewBuilder().
+		Route(metadata.Route("greeting.v1", "sayHello")).
+		OperationIndex(7).
+		Tracing(tracing).
+		BearerToken("secret").
+		Add("application/x.custom", []byte("custom")).
+		Build()
+	require.NoError(t, err)
+
+	// Well-known MIME types are encoded as a single byte ID.
+	assert.Equal(t, byte(0xFE), md[0])
+
+	c, err := metadata.ParseComposite(md)
+	require.NoError(t, err)
+	require.Len(t, c, 5)
+
+	route, ok := c.Route()
+	require.True(t, ok)
+	assert.Equal(t, "greeting.v1/sayHello", route)
+	namespace, operation, ok := metadata.SplitRoute(route)
+	require.True(t, ok)
+	assert.Equal(t, "greeting.v1", namespace)
+	assert.Equal(t, "sayHello", operation)
+
+	index, ok := c.OperationIndex()
+	require.True(t, ok)
+	assert.Equal(t, uint32(7), index)
+
+	tr, ok := c.Tracing()
+	require.True(t, ok)
+	assert.Equal(t, tracing, tr)
+
+	auth, ok := c.Authentication()
+	require.True(t, ok)
+	token, ok := auth.BearerToken()
+	require.True(t, ok)
+	assert.Equal(t, "secret", token)
+
+	custom, ok := c.Get("application/x.custom")
+	require.True(t, ok)
+	assert.Equal(t, "custom", string(custom))
+}
+
+func TestCompositeTruncated(t *testing.T) {
+	md, err := metadata.NewBuilder().Route("a/b").Build()
+	require.NoError(t, err)
+
+	for i := 1; i < len(md); i++ {
+		_, err := metadata.ParseComposite(md[:i])
+		assert.Error(t, err, "length %d", i)
+	}
+}
+
+func TestBuilderInvalidMimeType(t *testing.T) {
+	_, err := metadata.NewBuilder().Add("", nil).Build()
+	assert.Error(t, err)
+}
+
+func TestRouting(t *testing.T) {
+	data, err := metadata.EncodeRouting("one", "two")
+	require.NoError(t, err)
+	tags, err := metadata.ParseRouting(data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "two"}, tags)
+
+	_, err = metadata.EncodeRouting("")
+	assert.Error(t, err)
+	_, err = metadata.ParseRouting([]byte{5, 'a'})
+	assert.Error(t, err)
+}
+
+func TestAuthentication(t *testing.T) {
+	a, err := metadata.ParseAuthentication(metadata.SimpleAuthentication("user", "pass").Encode())
+	require.NoError(t, err)
+	username, password, ok := a.Credentials()
+	require.True(t, ok)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "pass", password)
+
+	custom := metadata.Authentication{Type: "hmac", Payload: []byte("signature")}
+	a, err = metadata.ParseAuthentication(custom.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, custom, a)
+}
+
+func TestTracing(t *testing.T) {
+	for name, tracing := range map[string]metadata.Tracing{
+		"empty":   {NotSampled: true},
+		"64 bit":  {Debug: true, TraceID: 1, SpanID: 2},
+		"128 bit": {TraceIDHigh: 1, TraceID: 2, SpanID: 3},
+		"parent":  {TraceID: 1, SpanID: 2, ParentSpanID: 3},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := tracing.Encode()
+			require.NoError(t, err)
+			parsed, err := metadata.ParseTracing(data)
+			require.NoError(t, err)
+			assert.Equal(t, tracing, parsed)
+		})
+	}
+
+	_, err := metadata.Tracing{Debug: true, NotSampled: true}.Encode()
+	assert.Error(t, err)
+}
@@ -0,0 +1,68 @@
+// Package metadata implements the RSocket composite metadata extension
+// (message/x.rsocket.composite-metadata.v0) and its well-known routing,
+// tracing and authentication entries.
+package metadata
+
+import "context"
+
+// Well-known MIME types used in composite metadata.
+const (
+	MimeTypeJSON           = "application/json"
+	MimeTypeOctetStream    = "application/octet-stream"
+	MimeTypeMsgPack        = "application/x-msgpack"
+	MimeTypeMimeType       = "message/x.rsocket.mime-type.v0"
+	MimeTypeAcceptMimeType = "message/x.rsocket.accept-mime-types.v0"
+	MimeTypeAuthentication = "message/x.rsocket.authentication.v0"
+	MimeTypeTracingZipkin  = "message/x.rsocket.tracing-zipkin.v0"
+	MimeTypeRouting        = "message/x.rsocket.routing.v0"
+	MimeTypeComposite      = "message/x.rsocket.composite-metadata.v0"
+
+	// MimeTypeOperationIndex is an entry holding the 4 byte big-endian
+	// index of the operation to invoke, as resolved from the
+	// operations table.
+	MimeTypeOperationIndex = "message/x.wasmrs.operation-index.v0"
+)
+
+// wellKnownMimeTypes maps the IDs of the well-known MIME types
+// supported by this package to their names.
+var wellKnownMimeTypes = map[byte]string{
+	0x05: MimeTypeJSON,
+	0x06: MimeTypeOctetStream,
+	0x7A: MimeTypeMimeType,
+	0x7B: MimeTypeAcceptMimeType,
+	0x7C: MimeTypeAuthentication,
+	0x7D: MimeTypeTracingZipkin,
+	0x7E: MimeTypeRouting,
+	0x7F: MimeTypeComposite,
+}
+
+func wellKnownID(mimeType string) (byte, bool) {
+	for id, name := range wellKnownMimeTypes {
+		if name == mimeType {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// MimeTypes are the MIME types of request metadata and data
+// negotiated in the SETUP frame of a connection.
+type MimeTypes struct {
+	Metadata string
+	Data     string
+}
+
+type mimeTypesKey struct{}
+
+// WithMimeTypes returns a copy of ctx carrying the MIME types.
+func WithMimeTypes(ctx context.Context, mimeTypes MimeTypes) context.Context {
+	return context.WithValue(ctx, mimeTypesKey{}, mimeTypes)
+}
+
+// MimeTypesFromContext returns the MIME types of the connection a
+// request arrived on. It is available from the context passed to
+// invoke handler functions.
+func MimeTypesFromContext(ctx context.Context) (MimeTypes, bool) {
+	m, ok := ctx.Value(mimeTypesKey{}).(MimeTypes)
+	return m, ok
+}
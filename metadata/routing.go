@@ -0,0 +1,54 @@
+package metadata
+
+import (
+	"errors"
+	"strings"
+)
+
+// EncodeRouting encodes routing metadata.
+// Each tag is at most 255 bytes long.
+func EncodeRouting(tags ...string) ([]byte, error) {
+	size := 0
+	for _, tag := range tags {
+		if len(tag) == 0 || len(tag) > 255 {
+			return nil, errors.New("metadata: invalid routing tag length")
+		}
+		size += 1 + len(tag)
+	}
+	buf := make([]byte, 0, size)
+	for _, tag := range tags {
+		buf = append(buf, byte(len(tag)))
+		buf = append(buf, tag...)
+	}
+	return buf, nil
+}
+
+// ParseRouting parses routing metadata into its tags.
+func ParseRouting(data []byte) ([]string, error) {
+	var tags []string
+	for len(data) > 0 {
+		n := int(data[0])
+		data = data[1:]
+		if len(data) < n {
+			return nil, errors.New("metadata: truncated routing metadata")
+		}
+		tags = append(tags, string(data[:n]))
+		data = data[n:]
+	}
+	return tags, nil
+}
+
+// Route returns the routing tag for an operation.
+func Route(namespace, operation string) string {
+	return namespace + "/" + operation
+}
+
+// SplitRoute splits a routing tag created by Route
+// into the namespace and operation.
+func SplitRoute(route string) (namespace, operation string, ok bool) {
+	i := strings.LastIndexByte(route, '/')
+	if i < 0 {
+		return "", "", false
+	}
+	return route[:i], route[i+1:], true
+}
@@ -0,0 +1,99 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Zipkin tracing flags.
+const (
+	tracingDebug      = 0x80
+	tracingIDs        = 0x40
+	tracingNotSampled = 0x20
+	tracingParentID   = 0x10
+	tracingTraceID128 = 0x08
+)
+
+// Tracing is the content of a Zipkin tracing entry.
+// A zero TraceIDHigh means a 64 bit trace ID.
+type Tracing struct {
+	Debug        bool
+	NotSampled   bool
+	TraceIDHigh  uint64
+	TraceID      uint64
+	SpanID       uint64
+	ParentSpanID uint64
+}
+
+// Encode encodes the tracing entry content.
+func (t Tracing) Encode() ([]byte, error) {
+	if t.Debug && t.NotSampled {
+		return nil, errors.New("metadata: a debug trace is always sampled")
+	}
+	var flags byte
+	if t.Debug {
+		flags |= tracingDebug
+	}
+	if t.NotSampled {
+		flags |= tracingNotSampled
+	}
+	if t.TraceID == 0 && t.SpanID == 0 {
+		return []byte{flags}, nil
+	}
+
+	flags |= tracingIDs
+	if t.TraceIDHigh != 0 {
+		flags |= tracingTraceID128
+	}
+	if t.ParentSpanID != 0 {
+		flags |= tracingParentID
+	}
+	buf := make([]byte, 1, 33)
+	buf[0] = flags
+	if t.TraceIDHigh != 0 {
+		buf = binary.BigEndian.AppendUint64(buf, t.TraceIDHigh)
+	}
+	buf = binary.BigEndian.AppendUint64(buf, t.TraceID)
+	buf = binary.BigEndian.AppendUint64(buf, t.SpanID)
+	if t.ParentSpanID != 0 {
+		buf = binary.BigEndian.AppendUint64(buf, t.ParentSpanID)
+	}
+	return buf, nil
+}
+
+// ParseTracing parses the content of a Zipkin tracing entry.
+func ParseTracing(data []byte) (Tracing, error) {
+	if len(data) == 0 {
+		return Tracing{}, errors.New("metadata: empty tracing metadata")
+	}
+	flags := data[0]
+	data = data[1:]
+	t := Tracing{
+		Debug:      flags&tracingDebug != 0,
+		NotSampled: flags&tracingNotSampled != 0,
+	}
+	if flags&tracingIDs == 0 {
+		return t, nil
+	}
+
+	size := 16
+	if flags&tracingTraceID128 != 0 {
+		size += 8
+	}
+	if flags&tracingParentID != 0 {
+		size += 8
+	}
+	if len(data) < size {
+		return Tracing{}, errors.New("metadata: truncated tracing metadata")
+	}
+	if flags&tracingTraceID128 != 0 {
+		t.TraceIDHigh = binary.BigEndian.Uint64(data)
+		data = data[8:]
+	}
+	t.TraceID = binary.BigEndian.Uint64(data)
+	t.SpanID = binary.BigEndian.Uint64(data[8:])
+	if flags&tracingParentID != 0 {
+		t.ParentSpanID = binary.BigEndian.Uint64(data[16:])
+	}
+	return t, nil
+}
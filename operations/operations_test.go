@@ -0,0 +1,78 @@
+package operations_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/operations"
+)
+
+var table = operations.Table{
+	{Index: 0, Type: operations.RequestResponse, Direction: operations.Export, Namespace: "greeting.v1", Operation: "sayHello"},
+	{Index: 1, Type: operations.RequestStream, Direction: operations.Import, Namespace: "greeting.v1", Operation: "names"},
+}
+
+func TestFromBytes(t *testing.T) {
+	decoded, err := operations.FromBytes(table.ToBytes())
+	require.NoError(t, err)
+	assert.Equal(t, table, decoded)
+}
+
+func TestFromBytesInvalid(t *testing.T) {
+	raw := table.ToBytes()
+	_, err := operations.FromBytes(raw[:3])
+	assert.ErrorIs(t, err, operations.ErrInvalidMagicNumber)
+
+	tests := map[string]struct {
+		raw    []byte
+		reason string
+	}{
+		"header": {
+			raw[:8],
+			"header is truncated",
+		},
+		"operations": {
+			raw[:20],
+			"2 operations exceed the table",
+		},
+		"namespace": {
+			raw[:len(raw)-20],
+			"operation 1 namespace is truncated",
+		},
+		"name": {
+			raw[:len(raw)-4],
+			"operation 1 name is truncated",
+		},
+		"reserved": {
+			append(raw[:len(raw)-2:len(raw)-2], 0, 1),
+			"operation 1 reserved field is truncated",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := operations.FromBytes(tt.raw)
+			assert.ErrorIs(t, err, operations.ErrInvalidTable)
+			assert.EqualError(t, err, "invalid operations table: "+tt.reason)
+		})
+	}
+}
+
+func FuzzFromBytes(f *testing.F) {
+	raw := table.ToBytes()
+	for i := 0; i <= len(raw); i += 7 {
+		f.Add(raw[:i])
+	}
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		decoded, err := operations.FromBytes(raw)
+		if err != nil {
+			return
+		}
+		roundTrip, err := operations.FromBytes(decoded.ToBytes())
+		require.NoError(t, err)
+		if len(decoded) > 0 {
+			assert.Equal(t, decoded, roundTrip)
+		}
+	})
+}
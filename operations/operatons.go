@@ -63,31 +63,49 @@ func (d Direction) String() string {
 var Magic = []byte{0x00, 0x77, 0x72, 0x73}
 var ErrInvalidMagicNumber = errors.New("invalid magic number")
 
+// FromBytes decodes an operations table. Truncated tables and
+// fields longer than the table return an error.
 func FromBytes(buf []byte) (Table, error) {
-	if !bytes.Equal(buf[0:4], Magic) {
+	if len(buf) < 4 || !bytes.Equal(buf[0:4], Magic) {
 		return nil, ErrInvalidMagicNumber
 	}
 	buf = buf[4:]
 
 	// Read version.
+	if len(buf) < 6 {
+		return nil, invalidTable("header is truncated")
+	}
 	version := binary.BigEndian.Uint16(buf)
 	if version != 1 {
-		return nil, errors.New("Unknown operation table version %d" + strconv.Itoa(int(version)))
+		return nil, errors.New("unknown operation table version " + strconv.Itoa(int(version)))
 	}
 	buf = buf[2:]
 	numOps := binary.BigEndian.Uint32(buf)
-	operations := make(Table, numOps)
 	buf = buf[4:]
+	// Each operation takes at least minOperationSize bytes.
+	if uint64(numOps)*minOperationSize > uint64(len(buf)) {
+		return nil, invalidTable(strconv.FormatUint(uint64(numOps), 10) + " operations exceed the table")
+	}
+	operations := make(Table, numOps)
 	for i := 0; i < int(numOps); i++ {
+		if len(buf) < 8 {
+			return nil, invalidOperation(i, "is truncated")
+		}
 		opType := RequestType(buf[0])
 		dir := Direction(buf[1])
 		id := binary.BigEndian.Uint32(buf[2:6])
-		nsLen := binary.BigEndian.Uint16(buf[6:8])
+		nsLen := int(binary.BigEndian.Uint16(buf[6:8]))
 		buf = buf[8:]
+		if len(buf) < nsLen+2 {
+			return nil, invalidOperation(i, "namespace is truncated")
+		}
 		ns := string(buf[:nsLen])
 		buf = buf[nsLen:]
-		opLen := binary.BigEndian.Uint16(buf[0:2])
+		opLen := int(binary.BigEndian.Uint16(buf[0:2]))
 		buf = buf[2:]
+		if len(buf) < opLen+2 {
+			return nil, invalidOperation(i, "name is truncated")
+		}
 		op := string(buf[:opLen])
 		buf = buf[opLen:]
 		operations[i] = Operation{
@@ -97,13 +115,45 @@ func FromBytes(buf []byte) (Table, error) {
 			Namespace: ns,
 			Operation: op,
 		}
-		reservedLen := binary.BigEndian.Uint16(buf)
-		buf = buf[2+reservedLen:] // Reserved
+		reservedLen := int(binary.BigEndian.Uint16(buf))
+		buf = buf[2:]
+		if len(buf) < reservedLen {
+			return nil, invalidOperation(i, "reserved field is truncated")
+		}
+		buf = buf[reservedLen:] // Reserved
 	}
 
 	return operations, nil
 }
 
+// minOperationSize is the size of an operation with
+// an empty namespace, name and reserved field.
+const minOperationSize = 12
+
+// ErrInvalidTable is matched by the errors returned
+// for operations tables that cannot be decoded.
+var ErrInvalidTable = errors.New("invalid operations table")
+
+type tableError struct {
+	reason string
+}
+
+func invalidTable(reason string) error {
+	return &tableError{reason}
+}
+
+func invalidOperation(i int, reason string) error {
+	return &tableError{"operation " + strconv.Itoa(i) + " " + reason}
+}
+
+func (e *tableError) Error() string {
+	return ErrInvalidTable.Error() + ": " + e.reason
+}
+
+func (e *tableError) Is(target error) bool {
+	return target == ErrInvalidTable
+}
+
 func (t Table) ToBytes() []byte {
 	size := uint32(10)
 	for _, op := range t {
@@ -0,0 +1,87 @@
+package payload
+
+// Buffer is reference-counted memory that borrowed payloads point into.
+type Buffer interface {
+	Retain()
+	Release()
+}
+
+// Borrowed is a payload whose metadata and data point into memory that
+// is reused once the handler function or OnNext callback it is passed to
+// returns, such as a pooled frame buffer or the memory of a WebAssembly
+// guest. This includes values decoded from it that share its memory,
+// like strings read by the msgpack decoder. Use Retain to keep the
+// payload for longer.
+type Borrowed struct {
+	metadata []byte
+	data     []byte
+	buf      Buffer
+	retained bool
+}
+
+// Borrow creates a payload pointing into buf. If buf is nil, the memory
+// is not reference-counted and Retain copies the payload.
+func Borrow(data, metadata []byte, buf Buffer) *Borrowed {
+	return &Borrowed{
+		metadata: metadata,
+		data:     data,
+		buf:      buf,
+	}
+}
+
+func (p *Borrowed) Metadata() []byte {
+	return p.metadata
+}
+
+func (p *Borrowed) Data() []byte {
+	return p.data
+}
+
+// Retain returns a payload that stays valid until it is released.
+func (p *Borrowed) Retain() Payload {
+	if p.buf == nil {
+		return New(clone(p.data), clone(p.metadata))
+	}
+	p.buf.Retain()
+	return &Borrowed{
+		metadata: p.metadata,
+		data:     p.data,
+		buf:      p.buf,
+		retained: true,
+	}
+}
+
+// Release returns the memory of a retained payload to its pool.
+// The payload must not be used afterwards.
+func (p *Borrowed) Release() {
+	if p.retained {
+		p.retained = false
+		p.buf.Release()
+	}
+}
+
+// Retain returns a payload that can be used after the handler function
+// or OnNext callback p was passed to returns. Borrowed payloads are
+// retained and other payloads are returned as is. Passing the result to
+// Release allows pooled memory to be reused. Otherwise it is garbage
+// collected.
+func Retain(p Payload) Payload {
+	if b, ok := p.(*Borrowed); ok {
+		return b.Retain()
+	}
+	return p
+}
+
+// Release releases a payload returned by Retain.
+func Release(p Payload) {
+	if b, ok := p.(*Borrowed); ok {
+		b.Release()
+	}
+}
+
+func clone(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
@@ -0,0 +1,49 @@
+package payload_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nanobus/iota/go/payload"
+)
+
+type countingBuffer struct {
+	refs int
+}
+
+func (b *countingBuffer) Retain()  { b.refs++ }
+func (b *countingBuffer) Release() { b.refs-- }
+
+func TestRetainCopiesUnpooledMemory(t *testing.T) {
+	memory := []byte("metadata|data")
+	p := payload.Borrow(memory[9:], memory[:8], nil)
+
+	retained := payload.Retain(p)
+	copy(memory, "XXXXXXXXXXXXX")
+
+	assert.Equal(t, "data", string(retained.Data()))
+	assert.Equal(t, "metadata", string(retained.Metadata()))
+}
+
+func TestRetainReferencesPooledMemory(t *testing.T) {
+	buf := countingBuffer{refs: 1}
+	p := payload.Borrow([]byte("data"), nil, &buf)
+
+	retained := payload.Retain(p)
+	assert.Equal(t, 2, buf.refs)
+	assert.Equal(t, "data", string(retained.Data()))
+
+	payload.Release(retained)
+	payload.Release(retained)
+	assert.Equal(t, 1, buf.refs)
+
+	// Payloads that were not retained are released by their owner.
+	payload.Release(p)
+	assert.Equal(t, 1, buf.refs)
+}
+
+func TestRetainOwnedPayload(t *testing.T) {
+	p := payload.New([]byte("data"))
+	assert.Same(t, p, payload.Retain(p))
+}
@@ -0,0 +1,78 @@
+package payload
+
+import "strconv"
+
+// ErrCode is the error code carried by an RSocket ERROR frame.
+type ErrCode uint32
+
+const (
+	// ErrCodeInvalidSetup means the SETUP frame is invalid
+	// and the connection is closed.
+	ErrCodeInvalidSetup ErrCode = 0x00000001
+	// ErrCodeUnsupportedSetup means a parameter of the SETUP frame, such
+	// as the protocol version or a MIME type, is not supported by the
+	// server and the connection is closed.
+	ErrCodeUnsupportedSetup ErrCode = 0x00000002
+	// ErrCodeRejectedSetup means the server rejected the SETUP frame,
+	// for example because the client could not be authenticated,
+	// and the connection is closed.
+	ErrCodeRejectedSetup ErrCode = 0x00000003
+	// ErrCodeConnectionError means the connection is being terminated.
+	ErrCodeConnectionError ErrCode = 0x00000101
+	// ErrCodeConnectionClose means the connection is being closed
+	// after outstanding streams finish.
+	ErrCodeConnectionClose ErrCode = 0x00000102
+	// ErrCodeApplicationError means the responder's application logic failed.
+	ErrCodeApplicationError ErrCode = 0x00000201
+	// ErrCodeRejected means the responder rejected the request
+	// without processing it.
+	ErrCodeRejected ErrCode = 0x00000202
+	// ErrCodeCanceled means the responder canceled the request
+	// but may have started processing it.
+	ErrCodeCanceled ErrCode = 0x00000203
+	// ErrCodeInvalid means the request is invalid.
+	ErrCodeInvalid ErrCode = 0x00000204
+)
+
+func (c ErrCode) String() string {
+	switch c {
+	case ErrCodeInvalidSetup:
+		return "INVALID_SETUP"
+	case ErrCodeUnsupportedSetup:
+		return "UNSUPPORTED_SETUP"
+	case ErrCodeRejectedSetup:
+		return "REJECTED_SETUP"
+	case ErrCodeConnectionError:
+		return "CONNECTION_ERROR"
+	case ErrCodeConnectionClose:
+		return "CONNECTION_CLOSE"
+	case ErrCodeApplicationError:
+		return "APPLICATION_ERROR"
+	case ErrCodeRejected:
+		return "REJECTED"
+	case ErrCodeCanceled:
+		return "CANCELED"
+	case ErrCodeInvalid:
+		return "INVALID"
+	}
+	return "0x" + strconv.FormatUint(uint64(c), 16)
+}
+
+// Error is an error received in, or to be sent as, an ERROR frame.
+// Use errors.As to get the code of an error returned by Block.
+type Error struct {
+	Code    ErrCode
+	Message string
+}
+
+// NewError creates an error with the given code and message.
+func NewError(code ErrCode, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
@@ -0,0 +1,23 @@
+package payload_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nanobus/iota/go/payload"
+)
+
+func TestError(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", payload.NewError(payload.ErrCodeRejected, "busy"))
+
+	var perr *payload.Error
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, payload.ErrCodeRejected, perr.Code)
+	assert.Equal(t, "busy", perr.Error())
+	assert.Equal(t, "REJECTED", perr.Code.String())
+	assert.Equal(t, "0x301", payload.ErrCode(0x301).String())
+	assert.Equal(t, "UNSUPPORTED_SETUP", payload.ErrCodeUnsupportedSetup.String())
+}
@@ -2,6 +2,8 @@ package proxy
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 
 	"github.com/nanobus/iota/go/internal/frames"
 	"github.com/nanobus/iota/go/payload"
@@ -9,7 +11,14 @@ import (
 	"github.com/nanobus/iota/go/rx/flux"
 )
 
+// Flux returns the flux of a stream or channel request. The payloads of
+// in are sent only as the responder requests them with REQUEST_N frames.
 func Flux(ctx context.Context, request frames.RequestPayload, in flux.Flux[payload.Payload], sendFrame func(frames.Frame) error, register func(Stream)) flux.Flux[payload.Payload] {
+	if in != nil {
+		// Payloads wait in the buffer until the responder requests
+		// them, so borrowed payloads are retained.
+		in = flux.OnBackpressureBuffer(flux.Map(in, retain), flux.OverflowBuffer(0))
+	}
 	p := flux.NewProcessor[payload.Payload]()
 	ss := streamFlux{
 		ctx:       ctx,
@@ -27,17 +36,33 @@ func Flux(ctx context.Context, request frames.RequestPayload, in flux.Flux[paylo
 	return &ss
 }
 
+// ResponderBufferSize is how many payloads a stream or channel handler
+// may emit beyond the REQUEST_N credit of the requester before its
+// stream fails with flux.ErrOverflow.
+const ResponderBufferSize = 256
+
+// Responder holds the payloads that the flux of a stream or channel
+// handler emits beyond what is requested from it, up to
+// ResponderBufferSize.
+func Responder(f flux.Flux[payload.Payload]) flux.Flux[payload.Payload] {
+	return flux.OnBackpressureBuffer(f, flux.OverflowBuffer(ResponderBufferSize))
+}
+
 type streamFlux struct {
 	ctx context.Context
 	flux.Processor[payload.Payload]
 
-	subscribed bool
+	subscribed atomic.Bool
 	request    frames.RequestPayload
 	in         flux.Flux[payload.Payload]
 	sendFrame  func(frames.Frame) error
 	register   func(Stream)
-	first      bool
-	complete   bool
+
+	// mu guards first and complete. OnComplete is called by the loop
+	// that receives frames while Request is called by subscribers.
+	mu       sync.Mutex
+	first    bool
+	complete bool
 }
 
 func (s *streamFlux) Context() context.Context {
@@ -53,8 +78,10 @@ func (s *streamFlux) OnNext(p payload.Payload) {
 }
 
 func (s *streamFlux) OnComplete() {
-	s.Processor.Complete()
+	s.mu.Lock()
 	s.complete = true
+	s.mu.Unlock()
+	s.Processor.Complete()
 }
 
 func (s *streamFlux) OnError(err error) {
@@ -64,7 +91,7 @@ func (s *streamFlux) OnError(err error) {
 func (s *streamFlux) Async() {
 	// If treated like an awaitable and not subscribed,
 	// then subscribe without callbacks.
-	if !s.subscribed {
+	if !s.subscribed.Load() {
 		s.Subscribe(flux.Subscribe[payload.Payload]{})
 	}
 }
@@ -74,14 +101,20 @@ func (s *streamFlux) Notify(fn rx.FnFinally) {
 }
 
 func (s *streamFlux) Request(n int) {
+	s.mu.Lock()
 	if s.complete {
+		s.mu.Unlock()
 		return
 	}
-	if s.first {
+	first := s.first
+	if first {
 		s.request.InitialN = uint32(n)
 		s.first = false
-		s.sendFrame(&s.request)
-
+	}
+	s.mu.Unlock()
+	if first {
+		// Subscribe to the inbound flux before sending the request
+		// so that REQUEST_N frames from the responder can be handled.
 		if s.in != nil {
 			s.in.Subscribe(flux.Subscribe[payload.Payload]{
 				OnNext: func(p payload.Payload) {
@@ -99,14 +132,13 @@ func (s *streamFlux) Request(n int) {
 					})
 				},
 				OnError: func(err error) {
-					s.sendFrame(&frames.Error{
-						StreamID: s.request.StreamID,
-						Data:     err.Error(),
-					})
+					s.sendFrame(frames.NewError(s.request.StreamID, err))
 				},
 				NoRequest: true,
 			})
 		}
+
+		s.sendFrame(&s.request)
 	} else {
 		s.sendFrame(&frames.RequestN{
 			StreamID: s.request.StreamID,
@@ -121,14 +153,27 @@ func (s *streamFlux) Cancel() {
 	})
 }
 
+func (s *streamFlux) DoCancel() {
+	if s.in == nil {
+		return
+	}
+	if sub := s.in.Subscription(); sub != nil {
+		sub.Cancel()
+	}
+}
+
 func (s *streamFlux) DoRequest(n int) {
 	sub := s.in.Subscription()
 	sub.Request(n)
 }
 
 func (s *streamFlux) Subscribe(sub flux.Subscribe[payload.Payload]) flux.Flux[payload.Payload] {
-	s.subscribed = true
+	s.subscribed.Store(true)
 	s.register(s)
 	s.Processor.Subscribe(sub)
 	return s
 }
+
+func retain(p payload.Payload) (payload.Payload, error) {
+	return payload.Retain(p), nil
+}
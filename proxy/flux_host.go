@@ -0,0 +1,19 @@
+//go:build !purego && !appengine && !wasm && !tinygo.wasm && !wasi
+// +build !purego,!appengine,!wasm,!tinygo.wasm,!wasi
+
+package proxy
+
+import (
+	"context"
+
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx/flux"
+)
+
+func (s *streamFlux) Block(sub flux.Subscribe[payload.Payload]) error {
+	return flux.Block[payload.Payload](s, sub)
+}
+
+func (s *streamFlux) BlockContext(ctx context.Context, sub flux.Subscribe[payload.Payload]) error {
+	return flux.BlockContext[payload.Payload](ctx, s, sub)
+}
@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"sync/atomic"
 
 	"github.com/nanobus/iota/go/internal/frames"
 	"github.com/nanobus/iota/go/payload"
@@ -25,7 +26,7 @@ type streamMono struct {
 	ctx context.Context
 	mono.Processor[payload.Payload]
 
-	subscribed bool
+	subscribed atomic.Bool
 	request    frames.RequestPayload
 	sendFrame  func(frames.Frame) error
 	register   func(Stream)
@@ -40,7 +41,8 @@ func (s *streamMono) StreamID() uint32 {
 }
 
 func (s *streamMono) OnNext(p payload.Payload) {
-	s.Processor.Success(p)
+	// The result is kept after OnNext returns, such as by Block.
+	s.Processor.Success(payload.Retain(p))
 }
 
 func (s *streamMono) OnComplete() {
@@ -54,7 +56,7 @@ func (s *streamMono) OnError(err error) {
 func (s *streamMono) Async() {
 	// If treated like an awaitable and not subscribed,
 	// then subscribe without callbacks.
-	if !s.subscribed {
+	if !s.subscribed.Load() {
 		s.Subscribe(mono.Subscribe[payload.Payload]{})
 	}
 }
@@ -64,9 +66,24 @@ func (s *streamMono) Notify(fn rx.FnFinally) {
 }
 
 func (s *streamMono) Subscribe(sub mono.Subscribe[payload.Payload]) mono.Mono[payload.Payload] {
-	s.subscribed = true
+	s.subscribed.Store(true)
 	s.register(s)
+	onRequest := sub.OnRequest
+	var cancel rx.FnCancel
+	sub.OnRequest = func(c rx.FnCancel) {
+		cancel = c
+	}
 	s.Processor.Subscribe(sub)
 	s.sendFrame(&s.request)
+	// Cancellation is offered once the request is sent so that the
+	// responder does not receive CANCEL before it.
+	if onRequest != nil {
+		onRequest(func() {
+			cancel()
+			s.sendFrame(&frames.Cancel{
+				StreamID: s.request.StreamID,
+			})
+		})
+	}
 	return s
 }
@@ -4,6 +4,8 @@
 package proxy
 
 import (
+	"context"
+
 	"github.com/nanobus/iota/go/payload"
 	"github.com/nanobus/iota/go/rx/mono"
 )
@@ -30,3 +32,7 @@ func (s *streamMono) Block() (ret payload.Payload, err error) {
 	<-done
 	return ret, err
 }
+
+func (s *streamMono) BlockContext(ctx context.Context) (payload.Payload, error) {
+	return mono.BlockContext[payload.Payload](ctx, s)
+}
@@ -0,0 +1,161 @@
+package proxy_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/proxy"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/rxtest"
+)
+
+// requester records the frames of a request and the stream it
+// registers.
+type requester struct {
+	mu     sync.Mutex
+	sent   []frames.Frame
+	stream proxy.Stream
+}
+
+func (r *requester) sendFrame(f frames.Frame) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, f)
+	return nil
+}
+
+func (r *requester) register(s proxy.Stream) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stream = s
+}
+
+func (r *requester) frames() []frames.Frame {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]frames.Frame(nil), r.sent...)
+}
+
+// await returns the nth frame of the request once it is sent. Requests
+// are sent asynchronously.
+func (r *requester) await(t *testing.T, n int) frames.Frame {
+	t.Helper()
+	require.Eventually(t, func() bool { return len(r.frames()) >= n }, time.Second, time.Millisecond)
+	return r.frames()[n-1]
+}
+
+func streamRequest() frames.RequestPayload {
+	return frames.RequestPayload{
+		FrameType: frames.FrameTypeRequestStream,
+		StreamID:  1,
+		Data:      []byte("request"),
+	}
+}
+
+// data matches a payload with the given data.
+func data(s string) func(payload.Payload) bool {
+	return func(p payload.Payload) bool {
+		return string(p.Data()) == s
+	}
+}
+
+func TestFluxRequestN(t *testing.T) {
+	var r requester
+	f := proxy.Flux(context.Background(), streamRequest(), nil, r.sendFrame, r.register)
+
+	rxtest.Flux(f).
+		ExpectNoEvent(10 * time.Millisecond).
+		ThenRequest(2).
+		Then(func() {
+			assert.Equal(t, uint32(2), r.await(t, 1).(*frames.RequestPayload).InitialN)
+			r.stream.OnNext(payload.New([]byte("a")))
+			r.stream.OnNext(payload.New([]byte("b")))
+		}).
+		ExpectNextMatches(data("a")).
+		ExpectNextMatches(data("b")).
+		ThenRequest(1).
+		Then(func() {
+			assert.Equal(t, &frames.RequestN{StreamID: 1, N: 1}, r.await(t, 2))
+			r.stream.OnNext(payload.New([]byte("c")))
+			r.stream.OnComplete()
+		}).
+		ExpectNextMatches(data("c")).
+		ExpectComplete().
+		Verify(t)
+}
+
+func TestFluxCancel(t *testing.T) {
+	var r requester
+	f := proxy.Flux(context.Background(), streamRequest(), nil, r.sendFrame, r.register)
+
+	rxtest.Flux(f).
+		ThenRequest(1).
+		Then(func() {
+			r.await(t, 1)
+			r.stream.OnNext(payload.New([]byte("a")))
+		}).
+		ExpectNextMatches(data("a")).
+		ThenCancel().
+		Verify(t)
+
+	assert.Equal(t, &frames.Cancel{StreamID: 1}, r.await(t, 2))
+}
+
+func TestMono(t *testing.T) {
+	var r requester
+	request := streamRequest()
+	request.FrameType = frames.FrameTypeRequestResponse
+	m := proxy.Mono(context.Background(), request, r.sendFrame, r.register)
+
+	boom := errors.New("boom")
+	rxtest.Mono(m).
+		Then(func() {
+			assert.Equal(t, &request, r.await(t, 1))
+			r.stream.OnNext(payload.New([]byte("a")))
+		}).
+		ExpectNextMatches(data("a")).
+		ExpectComplete().
+		Verify(t)
+
+	m = proxy.Mono(context.Background(), request, r.sendFrame, r.register)
+	rxtest.Mono(m).
+		Then(func() {
+			r.stream.OnError(boom)
+		}).
+		ExpectError(boom).
+		Verify(t)
+}
+
+// eager returns a flux that emits count payloads at once, regardless of
+// demand.
+func eager(count int) flux.Flux[payload.Payload] {
+	return flux.Create(func(sink flux.Sink[payload.Payload]) {
+		for i := 0; i < count; i++ {
+			sink.Next(payload.New([]byte{byte(i)}, nil))
+		}
+		sink.Complete()
+	})
+}
+
+func TestResponder(t *testing.T) {
+	rxtest.Flux(proxy.Responder(eager(5))).
+		ThenRequest(3).
+		ExpectNextCount(3).
+		ExpectNoEvent(10 * time.Millisecond).
+		ThenRequest(2).
+		ExpectNextCount(2).
+		ExpectComplete().
+		Verify(t)
+
+	rxtest.Flux(proxy.Responder(eager(proxy.ResponderBufferSize + 1))).
+		ExpectError(flux.ErrOverflow).
+		Verify(t)
+}
@@ -1,9 +1,17 @@
 package await
 
 import (
+	"errors"
+	"sync"
+
 	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/mono"
 )
 
+// ErrFailed is the error of awaitables that failed without reporting
+// an error of their own.
+var ErrFailed = errors.New("await: failed")
+
 type NotifyCallback func()
 
 type Awaitable interface {
@@ -11,8 +19,167 @@ type Awaitable interface {
 	Notify(rx.FnFinally)
 }
 
+// Fallible is an awaitable that reports the error it failed with.
+type Fallible interface {
+	Awaitable
+	Err() error
+}
+
 type Group []Awaitable
 
 func All(awaitables ...Awaitable) (Group, error) {
 	return awaitables, nil
 }
+
+// Err returns the error that a failed awaitable reports, or ErrFailed.
+func Err(a Awaitable) error {
+	if f, ok := a.(Fallible); ok {
+		if err := f.Err(); err != nil {
+			return err
+		}
+	}
+	return ErrFailed
+}
+
+// Cancel cancels the work of awaitables that can be cancelled: those
+// with a Cancel method, such as the monos of mono.Create, and fluxes,
+// through their subscription. The others keep running.
+func Cancel(awaitables ...Awaitable) {
+	for _, a := range awaitables {
+		switch c := a.(type) {
+		case interface{ Cancel() }:
+			c.Cancel()
+		case interface{ Subscription() rx.Subscription }:
+			if sub := c.Subscription(); sub != nil {
+				sub.Cancel()
+			}
+		}
+	}
+}
+
+// Mono returns an awaitable for m that reports the error of m. Like
+// Get, its Err may only be called once m is done.
+func Mono[T any](m mono.Mono[T]) Fallible {
+	return monoAwaitable[T]{m}
+}
+
+type monoAwaitable[T any] struct {
+	mono.Mono[T]
+}
+
+func (m monoAwaitable[T]) Err() error {
+	_, err := m.Get()
+	return err
+}
+
+func (m monoAwaitable[T]) Cancel() {
+	Cancel(m.Mono)
+}
+
+// Race returns an awaitable that is done once the first of awaitables is
+// done, with the signal of that awaitable. It completes right away if
+// there are no awaitables. The others keep running.
+func Race(awaitables ...Awaitable) Fallible {
+	return newFirst(awaitables, false)
+}
+
+// Any returns an awaitable that completes once the first of awaitables
+// completes. It fails once all of them failed or were cancelled, with
+// the error of the last that failed. It completes right away if there
+// are no awaitables. The others keep running.
+func Any(awaitables ...Awaitable) Fallible {
+	return newFirst(awaitables, true)
+}
+
+// first implements Race and Any.
+type first struct {
+	awaitables []Awaitable
+	// success makes first wait for an awaitable that completes.
+	success bool
+
+	mu        sync.Mutex
+	remaining int
+	done      bool
+	signal    rx.SignalType
+	err       error
+	callbacks []rx.FnFinally
+}
+
+func newFirst(awaitables []Awaitable, success bool) *first {
+	f := &first{
+		awaitables: awaitables,
+		success:    success,
+		remaining:  len(awaitables),
+	}
+	if len(awaitables) == 0 {
+		f.done = true
+		return f
+	}
+	for _, a := range awaitables {
+		a := a
+		a.Notify(func(signal rx.SignalType) {
+			f.notify(a, signal)
+		})
+	}
+	return f
+}
+
+func (f *first) Async() {
+	for _, a := range f.awaitables {
+		a.Async()
+	}
+}
+
+func (f *first) Notify(fn rx.FnFinally) {
+	f.mu.Lock()
+	if f.done {
+		signal := f.signal
+		f.mu.Unlock()
+		fn(signal)
+		return
+	}
+	f.callbacks = append(f.callbacks, fn)
+	f.mu.Unlock()
+}
+
+func (f *first) Err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+func (f *first) notify(a Awaitable, signal rx.SignalType) {
+	var err error
+	if signal == rx.SignalError {
+		err = Err(a)
+	}
+	f.mu.Lock()
+	if f.done {
+		f.mu.Unlock()
+		return
+	}
+	f.remaining--
+	if f.success && signal != rx.SignalComplete {
+		if err != nil {
+			f.err = err
+		}
+		if f.remaining > 0 {
+			f.mu.Unlock()
+			return
+		}
+		signal = rx.SignalError
+		if f.err == nil {
+			f.err = ErrFailed
+		}
+	} else {
+		f.err = err
+	}
+	f.done = true
+	f.signal = signal
+	callbacks := f.callbacks
+	f.callbacks = nil
+	f.mu.Unlock()
+	for _, fn := range callbacks {
+		fn(signal)
+	}
+}
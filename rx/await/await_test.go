@@ -0,0 +1,83 @@
+package await_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/await"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/mono"
+)
+
+// op returns a subscribed processor that completes when told to.
+func op() *mono.ProcessorImpl[int] {
+	p := mono.NewProcessor[int]()
+	p.Subscribe(mono.Subscribe[int]{})
+	return p
+}
+
+// signals records the signals of a.
+func signals(a await.Awaitable) *[]rx.SignalType {
+	var got []rx.SignalType
+	a.Async()
+	a.Notify(func(signal rx.SignalType) {
+		got = append(got, signal)
+	})
+	return &got
+}
+
+func TestRace(t *testing.T) {
+	a, b := op(), op()
+	boom := errors.New("boom")
+	race := await.Race(await.Mono[int](a), b)
+	got := signals(race)
+	assert.Empty(t, *got)
+
+	a.Error(boom)
+	b.Success(1)
+	assert.Equal(t, []rx.SignalType{rx.SignalError}, *got)
+	assert.Equal(t, boom, race.Err())
+
+	assert.Equal(t, []rx.SignalType{rx.SignalComplete}, *signals(await.Race()))
+}
+
+func TestAny(t *testing.T) {
+	a, b := op(), op()
+	boom := errors.New("boom")
+	got := signals(await.Any(await.Mono[int](a), b))
+	a.Error(boom)
+	assert.Empty(t, *got)
+	b.Success(1)
+	assert.Equal(t, []rx.SignalType{rx.SignalComplete}, *got)
+
+	a, b = op(), op()
+	first := await.Any(await.Mono[int](a), b)
+	got = signals(first)
+	a.Error(boom)
+	b.Error(errors.New("unreported"))
+	assert.Equal(t, []rx.SignalType{rx.SignalError}, *got)
+	// b does not report its error.
+	assert.Equal(t, await.ErrFailed, first.Err())
+}
+
+func TestCancel(t *testing.T) {
+	m := mono.Create(func(sink mono.Sink[int]) {})
+	m.Subscribe(mono.Subscribe[int]{})
+	got := signals(await.Mono(m))
+
+	var cancelled bool
+	f := flux.Create(func(sink flux.Sink[int]) {
+		sink.OnSubscribe(flux.OnSubscribe{
+			Request: func(int) {},
+			Cancel:  func() { cancelled = true },
+		})
+	})
+	f.Subscribe(flux.Subscribe[int]{})
+
+	await.Cancel(await.Mono(m), f)
+	assert.Equal(t, []rx.SignalType{rx.SignalCancel}, *got)
+	assert.True(t, cancelled)
+}
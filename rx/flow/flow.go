@@ -1,7 +1,8 @@
 package flow
 
 import (
-	"github.com/nanobus/iota/go/rx"
+	"sync"
+
 	"github.com/nanobus/iota/go/rx/await"
 	"github.com/nanobus/iota/go/rx/flux"
 	"github.com/nanobus/iota/go/rx/mono"
@@ -13,16 +14,21 @@ type (
 		monoSink mono.Sink[T]
 		fluxSink flux.Sink[T]
 
-		ended      bool
-		completed  int
-		awaitables []await.Awaitable
+		mu            sync.Mutex
+		ended         bool
+		compensations []StepFn
 	}
 
+	// StepFn starts the work of a step and returns what the flow awaits
+	// before it runs the next step. A step fails the flow by returning an
+	// error or if an awaitable it returns fails.
 	StepFn func() (await.Group, error)
 )
 
 func (f *Flow[T]) End() (await.Group, error) {
+	f.mu.Lock()
 	f.ended = true
+	f.mu.Unlock()
 	return nil, nil
 }
 
@@ -60,63 +66,82 @@ func (f *Flow[T]) Success(value T) (await.Group, error) {
 	return nil, nil
 }
 
+// Error fails the flow with err once the compensations of the steps that
+// succeeded have run.
 func (f *Flow[T]) Error(err error) (await.Group, error) {
-	if f.monoSink != nil {
-		f.monoSink.Error(err)
-	} else if f.fluxSink != nil {
-		f.fluxSink.Error(err)
-	}
-
 	return nil, err
 }
 
+// Compensate returns a step that runs step and, once it succeeded,
+// registers compensation. If the flow fails later on, the compensations
+// of the steps that succeeded run in reverse order before the flow
+// passes on its error.
+func (f *Flow[T]) Compensate(step, compensation StepFn) StepFn {
+	return func() (await.Group, error) {
+		t := &task{}
+		run([]StepFn{step}, t.running, func(err error) {
+			if err == nil {
+				f.mu.Lock()
+				f.compensations = append(f.compensations, compensation)
+				f.mu.Unlock()
+			}
+			t.finish(err)
+		})
+		return await.Group{t}, nil
+	}
+}
+
 func (f *Flow[T]) Mono() mono.Mono[T] {
 	return mono.Create(func(s mono.Sink[T]) {
 		f.monoSink = s
-		f.next()
+		f.start()
 	})
 }
 
 func (f *Flow[T]) Flux() flux.Flux[T] {
 	return flux.Create(func(s flux.Sink[T]) {
 		f.fluxSink = s
-		f.next()
+		f.start()
 	})
 }
 
-func (f *Flow[T]) next() {
-	if len(f.steps) == 0 {
-		return
-	}
+func (f *Flow[T]) start() {
+	run(f.steps, f.running, f.finish)
+}
 
-	f.completed = 0
-	step := f.steps[0]
-	awaitables, err := step()
-	if err != nil {
-		if f.monoSink != nil {
-			f.monoSink.Error(err)
-		} else if f.fluxSink != nil {
-			f.fluxSink.Error(err)
-		}
-		return
-	}
+// running reports whether the flow runs its next steps, which it does
+// until End.
+func (f *Flow[T]) running(await.Group) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return !f.ended
+}
 
-	if f.ended {
+// finish runs the compensations if the flow failed.
+func (f *Flow[T]) finish(err error) {
+	if err == nil {
 		return
 	}
-
-	f.awaitables = awaitables
-	for _, a := range f.awaitables {
-		a.Async() // Ensure task is running.
-		a.Notify(f.check)
+	f.mu.Lock()
+	compensations := make([]StepFn, 0, len(f.compensations))
+	for i := len(f.compensations) - 1; i >= 0; i-- {
+		compensations = append(compensations, f.compensations[i])
 	}
-
-	f.steps = f.steps[1:]
+	f.compensations = nil
+	f.mu.Unlock()
+	if len(compensations) == 0 {
+		f.fail(err)
+		return
+	}
+	run(compensations, nil, func(error) {
+		f.fail(err)
+	})
 }
 
-func (f *Flow[T]) check(rx.SignalType) {
-	f.completed++
-	if f.completed >= len(f.awaitables) {
-		f.next()
+func (f *Flow[T]) fail(err error) {
+	if f.monoSink != nil {
+		f.monoSink.Error(err)
+	} else if f.fluxSink != nil {
+		f.fluxSink.Error(err)
 	}
 }
@@ -0,0 +1,237 @@
+package flow_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/await"
+	"github.com/nanobus/iota/go/rx/flow"
+	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/rx/rxtest"
+)
+
+var errBoom = errors.New("boom")
+
+// op returns a subscribed processor that completes when told to.
+func op() *mono.ProcessorImpl[string] {
+	p := mono.NewProcessor[string]()
+	p.Subscribe(mono.Subscribe[string]{})
+	return p
+}
+
+// awaiting returns a step that awaits a and records name once it runs.
+func awaiting(ran *[]string, name string, a await.Awaitable) flow.StepFn {
+	return func() (await.Group, error) {
+		*ran = append(*ran, name)
+		if a == nil {
+			return nil, nil
+		}
+		return await.All(a)
+	}
+}
+
+func TestParallel(t *testing.T) {
+	a, b := op(), op()
+	var ran []string
+	f := flow.New[string]()
+	f.Steps(
+		flow.Parallel(
+			flow.Sequence(awaiting(&ran, "a1", a), awaiting(&ran, "a2", nil)),
+			flow.Sequence(awaiting(&ran, "b1", b)),
+		),
+		func() (await.Group, error) {
+			x, _ := a.Get()
+			y, _ := b.Get()
+			return f.Success(x + y)
+		},
+	)
+
+	rxtest.Mono(f.Mono()).
+		Then(func() {
+			assert.Equal(t, []string{"a1", "b1"}, ran)
+			a.Success("a")
+		}).
+		ExpectNoEvent(10 * time.Millisecond).
+		Then(func() {
+			assert.Equal(t, []string{"a1", "b1", "a2"}, ran)
+			b.Success("b")
+		}).
+		ExpectNext("ab").
+		ExpectComplete().
+		Verify(t)
+}
+
+func TestIfSwitch(t *testing.T) {
+	choose := func(n int) string {
+		f := flow.New[string]()
+		f.Steps(
+			flow.If(func() bool { return n > 0 }, nil, func() (await.Group, error) {
+				return f.Success("negative")
+			}),
+			flow.Switch(
+				flow.Case{When: func() bool { return n == 1 }, Then: func() (await.Group, error) {
+					return f.Success("one")
+				}},
+				flow.Case{Then: func() (await.Group, error) {
+					return f.Success("many")
+				}},
+			),
+		)
+		v, _ := f.Mono().Block()
+		return v
+	}
+	assert.Equal(t, "negative", choose(-1))
+	assert.Equal(t, "one", choose(1))
+	assert.Equal(t, "many", choose(2))
+}
+
+func TestAwaitedFailure(t *testing.T) {
+	a := op()
+	f := flow.New[string]()
+	f.Steps(func() (await.Group, error) {
+		return await.All(await.Mono[string](a))
+	}, func() (await.Group, error) {
+		return f.Success("unreachable")
+	})
+	rxtest.Mono(f.Mono()).
+		Then(func() { a.Error(errBoom) }).
+		ExpectError(errBoom).
+		Verify(t)
+}
+
+func TestRetry(t *testing.T) {
+	flaky := func(failures int) flow.StepFn {
+		return func() (await.Group, error) {
+			a := op()
+			if failures > 0 {
+				failures--
+				a.Error(errBoom)
+			} else {
+				a.Success("ok")
+			}
+			return await.All(a)
+		}
+	}
+	run := func(step flow.StepFn) error {
+		f := flow.New[string]()
+		f.Steps(step, func() (await.Group, error) {
+			return f.Success("done")
+		})
+		_, err := f.Mono().Block()
+		return err
+	}
+	assert.NoError(t, run(flow.Retry(flaky(2), 2)))
+	assert.Equal(t, await.ErrFailed, run(flow.Retry(flaky(2), 1)))
+}
+
+func TestTimeout(t *testing.T) {
+	s := rxtest.NewVirtualScheduler()
+	a := op()
+	f := flow.New[string]()
+	f.Steps(flow.Timeout(func() (await.Group, error) {
+		return await.All(a)
+	}, time.Second, s))
+	rxtest.Mono(f.Mono()).
+		WithVirtualTime(s).
+		ThenAdvance(time.Second).
+		ExpectError(rx.ErrTimeout).
+		Verify(t)
+}
+
+// cancellable returns a subscribed mono that never completes and
+// records whether it is cancelled.
+func cancellable(cancelled *atomic.Bool) mono.Mono[string] {
+	m := mono.Create(func(sink mono.Sink[string]) {})
+	m.Notify(func(signal rx.SignalType) {
+		if signal == rx.SignalCancel {
+			cancelled.Store(true)
+		}
+	})
+	m.Subscribe(mono.Subscribe[string]{})
+	return m
+}
+
+func TestTimeoutCancelsWork(t *testing.T) {
+	s := rxtest.NewVirtualScheduler()
+	var first, second atomic.Bool
+	var ran []string
+	f := flow.New[string]()
+	f.Steps(flow.Timeout(flow.Sequence(
+		awaiting(&ran, "first", await.Mono(cancellable(&first))),
+		awaiting(&ran, "second", await.Mono(cancellable(&second))),
+	), time.Second, s))
+	rxtest.Mono(f.Mono()).
+		WithVirtualTime(s).
+		ThenAdvance(time.Second).
+		ExpectError(rx.ErrTimeout).
+		Verify(t)
+
+	assert.True(t, first.Load())
+	assert.False(t, second.Load())
+	assert.Equal(t, []string{"first"}, ran)
+}
+
+func TestCompensate(t *testing.T) {
+	var ran []string
+	step := func(name string, err error) flow.StepFn {
+		return func() (await.Group, error) {
+			ran = append(ran, name)
+			return nil, err
+		}
+	}
+	f := flow.New[string]()
+	f.Steps(
+		f.Compensate(step("reserve", nil), step("release", nil)),
+		f.Compensate(step("charge", nil), step("refund", nil)),
+		f.Compensate(step("ship", errBoom), step("unreachable", nil)),
+	)
+	_, err := f.Mono().Block()
+	assert.Equal(t, errBoom, err)
+	assert.Equal(t, []string{"reserve", "charge", "ship", "refund", "release"}, ran)
+}
+
+func TestErrorCompensate(t *testing.T) {
+	released := op()
+	var ran []string
+	f := flow.New[string]()
+	f.Steps(
+		f.Compensate(awaiting(&ran, "reserve", nil), awaiting(&ran, "release", released)),
+		func() (await.Group, error) {
+			return f.Error(errBoom)
+		},
+	)
+	// The flow fails once its compensations are done, and only once.
+	rxtest.Mono(f.Mono()).
+		ExpectNoEvent(10 * time.Millisecond).
+		Then(func() {
+			assert.Equal(t, []string{"reserve", "release"}, ran)
+			released.Success("")
+		}).
+		ExpectError(errBoom).
+		Verify(t)
+}
+
+func TestParallelStartFailure(t *testing.T) {
+	a := op()
+	var ran []string
+	f := flow.New[string]()
+	f.Steps(flow.Parallel(
+		f.Compensate(awaiting(&ran, "a", a), awaiting(&ran, "undo a", nil)),
+		func() (await.Group, error) {
+			return nil, errBoom
+		},
+	))
+	// The step that started is awaited and compensated before the flow
+	// fails.
+	rxtest.Mono(f.Mono()).
+		ExpectNoEvent(10 * time.Millisecond).
+		Then(func() { a.Success("a") }).
+		ExpectError(errBoom).
+		Verify(t)
+	assert.Equal(t, []string{"a", "undo a"}, ran)
+}
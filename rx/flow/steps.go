@@ -0,0 +1,283 @@
+package flow
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/await"
+)
+
+// Case is a branch of Switch.
+type Case struct {
+	// When selects the branch. A nil When always selects it.
+	When func() bool
+	Then StepFn
+}
+
+// Sequence returns a step that runs steps one after another, like the
+// steps of a flow, and is done once the last of them is done.
+func Sequence(steps ...StepFn) StepFn {
+	return func() (await.Group, error) {
+		t := &task{}
+		run(steps, t.running, t.finish)
+		return await.Group{t}, nil
+	}
+}
+
+// Parallel returns a step that starts steps together and joins them:
+// the flow continues once all of them are done. Each of steps can be a
+// Sequence to run a branch of several steps. If one of steps fails to
+// start, the step fails once those that started before it are done, so
+// that the compensations they register run.
+func Parallel(steps ...StepFn) StepFn {
+	return func() (await.Group, error) {
+		var group await.Group
+		for _, step := range steps {
+			g, err := step()
+			if err != nil {
+				if len(group) == 0 {
+					return nil, err
+				}
+				t := &task{}
+				join(group, func(error) {
+					t.finish(err)
+				})
+				return await.Group{t}, nil
+			}
+			group = append(group, g...)
+		}
+		return group, nil
+	}
+}
+
+// If returns a step that runs then if cond returns true and otherwise
+// if it does not. A nil step does nothing.
+func If(cond func() bool, then, otherwise StepFn) StepFn {
+	return func() (await.Group, error) {
+		step := otherwise
+		if cond() {
+			step = then
+		}
+		if step == nil {
+			return nil, nil
+		}
+		return step()
+	}
+}
+
+// Switch returns a step that runs the first of cases that is selected.
+// It does nothing if none is.
+func Switch(cases ...Case) StepFn {
+	return func() (await.Group, error) {
+		for _, c := range cases {
+			if c.When == nil || c.When() {
+				return c.Then()
+			}
+		}
+		return nil, nil
+	}
+}
+
+// Retry returns a step that runs step again, up to retries times, while
+// it fails.
+func Retry(step StepFn, retries int) StepFn {
+	return func() (await.Group, error) {
+		t := &task{}
+		var attempt func(remaining int)
+		attempt = func(remaining int) {
+			run([]StepFn{step}, t.running, func(err error) {
+				if err != nil && remaining > 0 {
+					attempt(remaining - 1)
+					return
+				}
+				t.finish(err)
+			})
+		}
+		attempt(retries)
+		return await.Group{t}, nil
+	}
+}
+
+// Timeout returns a step that fails with rx.ErrTimeout if step is not
+// done within timeout on s. The work of step is then cancelled, as far
+// as await.Cancel can cancel it.
+func Timeout(step StepFn, timeout time.Duration, s rx.Scheduler) StepFn {
+	return func() (await.Group, error) {
+		t := &task{}
+		work := &task{}
+		cancel := s.Schedule(timeout, func() {
+			t.finish(rx.ErrTimeout)
+			work.Cancel()
+		})
+		run([]StepFn{step}, work.running, func(err error) {
+			cancel()
+			t.finish(err)
+		})
+		return await.Group{t}, nil
+	}
+}
+
+// run runs steps one after another, waiting for the group of each step
+// before running the next, and calls done once they are done or with the
+// first error. running, if set, is called with the group of each step
+// and once the group is done; steps stop without calling done if it
+// returns false.
+func run(steps []StepFn, running func(await.Group) bool, done func(error)) {
+	for len(steps) > 0 {
+		step := steps[0]
+		steps = steps[1:]
+		group, err := step()
+		if err != nil {
+			done(err)
+			return
+		}
+		if running != nil && !running(group) {
+			return
+		}
+		if len(group) == 0 {
+			continue
+		}
+		remaining := steps
+		join(group, func(err error) {
+			if err != nil {
+				done(err)
+				return
+			}
+			if running != nil && !running(nil) {
+				return
+			}
+			run(remaining, running, done)
+		})
+		return
+	}
+	done(nil)
+}
+
+// join calls done once all of group are done, with the error of the
+// first that failed.
+func join(group await.Group, done func(error)) {
+	var mu sync.Mutex
+	pending := len(group)
+	var failure error
+	for _, a := range group {
+		a := a
+		a.Async() // Ensure task is running.
+		a.Notify(func(signal rx.SignalType) {
+			var err error
+			if signal == rx.SignalError {
+				err = await.Err(a)
+			}
+			mu.Lock()
+			if err != nil && failure == nil {
+				failure = err
+			}
+			pending--
+			last := pending == 0
+			mu.Unlock()
+			if last {
+				done(failure)
+			}
+		})
+	}
+}
+
+// task is the awaitable of the steps that run other steps.
+type task struct {
+	mu        sync.Mutex
+	done      bool
+	cancelled bool
+	err       error
+	callbacks []rx.FnFinally
+	// group is what the step that runs awaits.
+	group await.Group
+}
+
+func (t *task) Async() {}
+
+func (t *task) Notify(fn rx.FnFinally) {
+	t.mu.Lock()
+	if t.done {
+		signal := t.signal()
+		t.mu.Unlock()
+		fn(signal)
+		return
+	}
+	t.callbacks = append(t.callbacks, fn)
+	t.mu.Unlock()
+}
+
+// running records group as the work of the step that runs and reports
+// whether t runs its next steps, which it does until it is cancelled.
+func (t *task) running(group await.Group) bool {
+	t.mu.Lock()
+	cancelled := t.cancelled
+	if !cancelled {
+		t.group = group
+	}
+	t.mu.Unlock()
+	if cancelled {
+		await.Cancel(group...)
+	}
+	return !cancelled
+}
+
+// Cancel stops t from running its next steps and cancels the work of the
+// step that runs. Unless t is done already, it is then done with
+// rx.SignalCancel.
+func (t *task) Cancel() {
+	t.mu.Lock()
+	if t.cancelled {
+		t.mu.Unlock()
+		return
+	}
+	t.cancelled = true
+	group := t.group
+	t.group = nil
+	var callbacks []rx.FnFinally
+	if !t.done {
+		t.done = true
+		callbacks = t.callbacks
+		t.callbacks = nil
+	}
+	t.mu.Unlock()
+	await.Cancel(group...)
+	for _, fn := range callbacks {
+		fn(rx.SignalCancel)
+	}
+}
+
+func (t *task) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// finish marks t as done unless it is already.
+func (t *task) finish(err error) {
+	t.mu.Lock()
+	if t.done {
+		t.mu.Unlock()
+		return
+	}
+	t.done = true
+	t.err = err
+	signal := t.signal()
+	callbacks := t.callbacks
+	t.callbacks = nil
+	t.mu.Unlock()
+	for _, fn := range callbacks {
+		fn(signal)
+	}
+}
+
+// signal is called with mu held.
+func (t *task) signal() rx.SignalType {
+	if t.cancelled {
+		return rx.SignalCancel
+	}
+	if t.err != nil {
+		return rx.SignalError
+	}
+	return rx.SignalComplete
+}
@@ -0,0 +1,165 @@
+//go:build !purego && !appengine && !wasm && !tinygo.wasm && !wasi
+// +build !purego,!appengine,!wasm,!tinygo.wasm,!wasi
+
+package flux
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nanobus/iota/go/rx"
+)
+
+// ToChannel subscribes to f and sends its items to the returned item
+// channel. At most bufferSize items, and at least one, are requested
+// ahead of the receiver. Another item is requested each time one is
+// received. The item channel is closed once f completes, fails or ctx
+// is done. The error channel then receives the error of f, or of ctx,
+// before it is closed. Once ctx is done, the subscription is cancelled.
+// A source that emits more items than requested waits until they are
+// received or ctx is done.
+func ToChannel[T any](ctx context.Context, f Flux[T], bufferSize int) (<-chan T, <-chan error) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	items := make(chan T)
+	errs := make(chan error, 1)
+	queue := make(chan T, bufferSize)
+	// done is closed once nothing receives from queue anymore.
+	done := make(chan struct{})
+	var mu sync.Mutex
+	var err error
+	up := new(upstream)
+
+	go func() {
+		defer close(errs)
+		defer close(items)
+		defer close(done)
+		for {
+			select {
+			case value, ok := <-queue:
+				if !ok {
+					mu.Lock()
+					if err != nil {
+						errs <- err
+					}
+					mu.Unlock()
+					return
+				}
+				select {
+				case items <- value:
+					up.Request(1)
+				case <-ctx.Done():
+					up.Cancel()
+					errs <- ctx.Err()
+					return
+				}
+			case <-ctx.Done():
+				up.Cancel()
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	// Sources may emit while they are subscribed to, so they are
+	// subscribed to once items are received from queue.
+	go func() {
+		subscribe(up, f, Subscribe[T]{
+			OnNext: func(value T) {
+				select {
+				case queue <- value:
+				case <-done:
+				}
+			},
+			OnComplete: func() {
+				close(queue)
+			},
+			OnError: func(e error) {
+				mu.Lock()
+				err = e
+				mu.Unlock()
+				close(queue)
+			},
+		})
+		up.Request(bufferSize)
+		// ctx may be done before the subscription could be cancelled.
+		if ctx.Err() != nil {
+			up.Cancel()
+		}
+	}()
+
+	return items, errs
+}
+
+// FromChannel emits the values received from ch as they are requested
+// and completes once ch is closed. Values are not received from ch
+// while none are requested.
+func FromChannel[T any](ch <-chan T) Flux[T] {
+	return Create(func(sink Sink[T]) {
+		var mu sync.Mutex
+		requested := 0
+		receiving := false
+		done := make(chan struct{})
+		var once sync.Once
+		receive := func() {
+			for {
+				mu.Lock()
+				if requested == 0 {
+					receiving = false
+					mu.Unlock()
+					return
+				}
+				mu.Unlock()
+				select {
+				case value, ok := <-ch:
+					if !ok {
+						sink.Complete()
+						return
+					}
+					mu.Lock()
+					if requested != rx.RequestMax {
+						requested--
+					}
+					mu.Unlock()
+					sink.Next(value)
+				case <-done:
+					return
+				}
+			}
+		}
+		sink.OnSubscribe(OnSubscribe{
+			Request: func(n int) {
+				mu.Lock()
+				requested = addRequest(requested, n)
+				start := !receiving
+				receiving = true
+				mu.Unlock()
+				if start {
+					go receive()
+				}
+			},
+			Cancel: func() {
+				once.Do(func() { close(done) })
+			},
+		})
+	})
+}
+
+// all backs All, which returns iter.Seq2 where it exists.
+func all[T any](ctx context.Context, f Flux[T]) func(yield func(T, error) bool) {
+	return func(yield func(T, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		items, errs := ToChannel(ctx, f, prefetch)
+		for value := range items {
+			if !yield(value, nil) {
+				return
+			}
+		}
+		if err := <-errs; err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
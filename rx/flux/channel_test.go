@@ -0,0 +1,162 @@
+package flux_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/rx/flux"
+)
+
+func TestToChannel(t *testing.T) {
+	items, errs := flux.ToChannel(context.Background(), ints(1, 2, 3), 2)
+	var values []int
+	for value := range items {
+		values = append(values, value)
+	}
+	assert.Equal(t, []int{1, 2, 3}, values)
+	assert.NoError(t, <-errs)
+
+	boom := errors.New("boom")
+	items, errs = flux.ToChannel(context.Background(), flux.Error[int](boom), 2)
+	_, ok := <-items
+	assert.False(t, ok)
+	assert.Equal(t, boom, <-errs)
+}
+
+func TestToChannelBackpressure(t *testing.T) {
+	var src source
+	ctx, cancel := context.WithCancel(context.Background())
+	items, errs := flux.ToChannel(ctx, src.flux(100), 4)
+	requested := func() int {
+		n, _ := src.state()
+		return n
+	}
+	assert.Eventually(t, func() bool { return requested() == 4 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 4, requested())
+
+	// Each item that is received is replaced.
+	assert.Equal(t, 0, <-items)
+	assert.Equal(t, 1, <-items)
+	assert.Eventually(t, func() bool { return requested() == 6 }, time.Second, time.Millisecond)
+
+	cancel()
+	for range items {
+	}
+	assert.Equal(t, context.Canceled, <-errs)
+	assert.Eventually(t, func() bool {
+		_, cancelled := src.state()
+		return cancelled
+	}, time.Second, time.Millisecond)
+}
+
+// eager emits count items as it is subscribed to, whatever is
+// requested, and closes emitted once it returns.
+func eager(count int, emitted chan struct{}) flux.Flux[int] {
+	return flux.Create(func(sink flux.Sink[int]) {
+		for i := 0; i < count; i++ {
+			sink.Next(i)
+		}
+		sink.Complete()
+		close(emitted)
+	})
+}
+
+func TestToChannelEagerSource(t *testing.T) {
+	emitted := make(chan struct{})
+	result := make(chan []int, 1)
+	go func() {
+		items, _ := flux.ToChannel(context.Background(), eager(10, emitted), 2)
+		var values []int
+		for value := range items {
+			values = append(values, value)
+		}
+		result <- values
+	}()
+	select {
+	case values := <-result:
+		assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, values)
+	case <-time.After(time.Second):
+		t.Fatal("ToChannel did not return")
+	}
+
+	// The source does not wait once ctx is done.
+	emitted = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	items, errs := flux.ToChannel(ctx, eager(10, emitted), 2)
+	assert.Equal(t, 0, <-items)
+	cancel()
+	for range items {
+	}
+	assert.Equal(t, context.Canceled, <-errs)
+	select {
+	case <-emitted:
+	case <-time.After(time.Second):
+		t.Fatal("the source still waits")
+	}
+}
+
+func TestFromChannel(t *testing.T) {
+	ch := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+	// Values are received as they are requested.
+	assert.Equal(t, []int{0, 1}, request(t, flux.FromChannel(ch), 2))
+	assert.Len(t, ch, 3)
+
+	close(ch)
+	assert.Equal(t, []int{2, 3, 4}, collect(t, flux.FromChannel(ch)))
+}
+
+func TestBlockContext(t *testing.T) {
+	var h hot
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := h.flux().BlockContext(ctx, flux.Subscribe[int]{})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Eventually(t, h.isCancelled, time.Second, time.Millisecond)
+
+	var values []int
+	err = ints(1, 2).BlockContext(context.Background(), flux.Subscribe[int]{
+		OnNext: func(value int) { values = append(values, value) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, values)
+}
+
+func TestAll(t *testing.T) {
+	var values []int
+	flux.All(context.Background(), ints(1, 2, 3))(func(value int, err error) bool {
+		require.NoError(t, err)
+		values = append(values, value)
+		return true
+	})
+	assert.Equal(t, []int{1, 2, 3}, values)
+
+	boom := errors.New("boom")
+	var got error
+	flux.All(context.Background(), flux.Error[int](boom))(func(_ int, err error) bool {
+		got = err
+		return true
+	})
+	assert.Equal(t, boom, got)
+
+	// Stopping early cancels the subscription.
+	var src source
+	values = nil
+	flux.All(context.Background(), src.flux(100))(func(value int, err error) bool {
+		values = append(values, value)
+		return len(values) < 2
+	})
+	assert.Equal(t, []int{0, 1}, values)
+	assert.Eventually(t, func() bool {
+		_, cancelled := src.state()
+		return cancelled
+	}, time.Second, time.Millisecond)
+}
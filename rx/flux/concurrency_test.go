@@ -0,0 +1,222 @@
+package flux_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/flux"
+)
+
+// serial records signals and fails if two of them overlap.
+type serial struct {
+	t       *testing.T
+	active  atomic.Int32
+	next    atomic.Int64
+	signals atomic.Int32
+	done    chan struct{}
+}
+
+func newSerial(t *testing.T) *serial {
+	return &serial{t: t, done: make(chan struct{})}
+}
+
+func (s *serial) enter() {
+	if !s.active.CompareAndSwap(0, 1) {
+		s.t.Error("signals overlap")
+	}
+}
+
+func (s *serial) leave() {
+	s.active.Store(0)
+}
+
+func (s *serial) subscribe(n int) flux.Subscribe[int] {
+	return flux.Subscribe[int]{
+		OnNext: func(int) {
+			s.enter()
+			defer s.leave()
+			s.next.Add(1)
+		},
+		OnComplete: func() {
+			s.enter()
+			defer s.leave()
+			s.signals.Add(1)
+		},
+		OnError: func(error) {
+			s.enter()
+			defer s.leave()
+			s.signals.Add(1)
+		},
+		Finally: func(rx.SignalType) {
+			close(s.done)
+		},
+		OnRequest: func(sub rx.Subscription) {
+			sub.Request(n)
+		},
+	}
+}
+
+func TestConcurrentNext(t *testing.T) {
+	const producers, items = 8, 500
+	s := newSerial(t)
+	p := flux.NewProcessor[int]()
+	p.Subscribe(s.subscribe(rx.RequestMax))
+
+	var wg sync.WaitGroup
+	for i := 0; i < producers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < items; j++ {
+				p.Next(j)
+			}
+		}()
+	}
+	wg.Wait()
+	p.Complete()
+	p.Complete()
+	p.Error(errors.New("late"))
+
+	<-s.done
+	assert.Equal(t, int64(producers*items), s.next.Load())
+	assert.Equal(t, int32(1), s.signals.Load())
+}
+
+func TestConcurrentRequest(t *testing.T) {
+	const total = 1000
+	var requested atomic.Int64
+	var emitted atomic.Int64
+	var sink flux.Sink[int]
+	ready := make(chan struct{})
+	f := flux.Create(func(s flux.Sink[int]) {
+		sink = s
+		s.OnSubscribe(flux.OnSubscribe{
+			Request: func(n int) {
+				requested.Add(int64(n))
+			},
+			Cancel: func() {},
+		})
+		close(ready)
+	})
+
+	s := newSerial(t)
+	var received atomic.Int64
+	f.Subscribe(flux.Subscribe[int]{
+		OnNext: func(int) {
+			s.enter()
+			defer s.leave()
+			received.Add(1)
+		},
+		OnComplete: func() {},
+		Finally:    func(rx.SignalType) { close(s.done) },
+		NoRequest:  true,
+	})
+	<-ready
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < total/10; j++ {
+				f.Subscription().Request(1)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for emitted.Load() < total {
+			if emitted.Load() < requested.Load() {
+				sink.Next(int(emitted.Add(1)))
+			}
+		}
+	}()
+	wg.Wait()
+	sink.Complete()
+
+	<-s.done
+	assert.Equal(t, int64(total), requested.Load())
+	assert.Equal(t, int64(total), received.Load())
+}
+
+func TestConcurrentCancel(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		var cancelled atomic.Int32
+		var sink flux.Sink[int]
+		f := flux.Create(func(s flux.Sink[int]) {
+			sink = s
+			s.OnSubscribe(flux.OnSubscribe{
+				Request: func(int) {},
+				Cancel: func() {
+					cancelled.Add(1)
+				},
+			})
+		})
+
+		s := newSerial(t)
+		var signal rx.SignalType
+		sub := s.subscribe(rx.RequestMax)
+		sub.Finally = func(st rx.SignalType) {
+			signal = st
+			close(s.done)
+		}
+		f.Subscribe(sub)
+
+		var wg sync.WaitGroup
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				sink.Next(j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			f.Subscription().Cancel()
+		}()
+		go func() {
+			defer wg.Done()
+			f.Subscription().Cancel()
+		}()
+		wg.Wait()
+		sink.Complete()
+
+		<-s.done
+		require.Equal(t, rx.SignalCancel, signal)
+		require.Equal(t, int32(1), cancelled.Load())
+		require.Zero(t, s.signals.Load())
+	}
+}
+
+func TestConcurrentNotify(t *testing.T) {
+	p := flux.NewProcessor[int]()
+	p.Subscribe(flux.Subscribe[int]{})
+
+	var wg sync.WaitGroup
+	var notified atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Notify(func(signal rx.SignalType) {
+				assert.Equal(t, rx.SignalComplete, signal)
+				notified.Add(1)
+			})
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.Complete()
+	}()
+	wg.Wait()
+
+	assert.Equal(t, int32(10), notified.Load())
+}
@@ -1,6 +1,9 @@
 package flux
 
 import (
+	"context"
+	"sync/atomic"
+
 	"github.com/nanobus/iota/go/rx"
 )
 
@@ -60,24 +63,44 @@ func (e errorFlow[T]) Block(_ Subscribe[T]) error {
 	return e.err
 }
 
+func (e errorFlow[T]) BlockContext(_ context.Context, _ Subscribe[T]) error {
+	return e.err
+}
+
 func (e errorFlow[T]) Subscription() rx.Subscription {
 	return nil
 }
 
-func Create[T any](source Source[T]) Flux[T] {
+// Create returns a flux that calls source for each subscription. Items
+// that source emits are passed on even if they were not requested,
+// unless an Overflow strategy is given, which then holds or drops them.
+func Create[T any](source Source[T], overflow ...Overflow) Flux[T] {
+	if len(overflow) > 0 {
+		source = withOverflow(source, overflow[0])
+	}
 	return &flux[T]{
 		source: source,
 	}
 }
 
+// withOverflow applies overflow to the items of source.
+func withOverflow[T any](source Source[T], overflow Overflow) Source[T] {
+	return func(sink Sink[T]) {
+		s := newOverflowSink(sink, overflow)
+		sink.OnSubscribe(s.subscription())
+		source(s)
+	}
+}
+
 type flux[T any] struct {
+	mu         mutex
 	source     Source[T]
 	subscriber *subscriber[T]
 	callbacks  []rx.FnFinally
 }
 
 func (f *flux[T]) Subscribe(sub Subscribe[T]) Flux[T] {
-	s := subscriber[T]{
+	s := &subscriber[T]{
 		doOnComplete: sub.OnComplete,
 		doOnError:    sub.OnError,
 		doOnNext:     sub.OnNext,
@@ -86,32 +109,56 @@ func (f *flux[T]) Subscribe(sub Subscribe[T]) Flux[T] {
 		f:            f,
 		noRequest:    sub.NoRequest,
 	}
-	f.subscriber = &s
-	f.source(&s)
+	f.mu.Lock()
+	f.subscriber = s
+	f.mu.Unlock()
+	f.source(s)
 	s.handleOnSubscribe()
 	return f
 }
 
 func (f *flux[T]) Subscription() rx.Subscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subscriber == nil {
+		return nil
+	}
 	return f.subscriber
 }
 
 func (f *flux[T]) Async() {}
 
 func (f *flux[T]) Notify(fn rx.FnFinally) {
-	if f.subscriber != nil && f.subscriber.closed {
-		fn(f.subscriber.signal)
-		return
+	f.mu.Lock()
+	if f.subscriber != nil {
+		if closed, signal, _ := f.subscriber.result(); closed {
+			f.mu.Unlock()
+			fn(signal)
+			return
+		}
 	}
 	f.callbacks = append(f.callbacks, fn)
+	f.mu.Unlock()
 }
 
+// result returns the result of the last subscription, if it is closed.
+func (f *flux[T]) result() (closed bool, err error) {
+	f.mu.Lock()
+	s := f.subscriber
+	f.mu.Unlock()
+	if s == nil {
+		return false, nil
+	}
+	closed, _, err = s.result()
+	return closed, err
+}
+
+// subscriber passes the signals of a source to the callbacks of a
+// subscription. Sources may signal from any goroutine. Signals are
+// delivered one at a time and in order, as Reactive Streams §1.3
+// requires: a signal that arrives while another is being delivered is
+// queued and delivered by the goroutine that is delivering.
 type subscriber[T any] struct {
-	closed    bool
-	n         int
-	err       error
-	sub       OnSubscribe
-	signal    rx.SignalType
 	f         *flux[T]
 	noRequest bool
 
@@ -121,63 +168,193 @@ type subscriber[T any] struct {
 	doOnRequest  rx.FnOnSubscribe
 	doFinally    rx.FnFinally
 
+	// n is the number of items that were requested and not received.
+	// RequestMax stands for an unbounded number of items.
+	n atomic.Int64
+
 	mu mutex
+	// sub is the subscription to the source.
+	sub OnSubscribe
+	// done is set once a terminal signal or cancellation is accepted.
+	// Later signals are dropped.
+	done bool
+	// closed is set once a terminal signal is delivered.
+	closed   bool
+	signal   rx.SignalType
+	err      error
+	emitting bool
+	queue    []event[T]
+
+	// requests serializes the requests to the source.
+	requests mutex
+}
+
+type eventKind uint8
+
+const (
+	eventNext eventKind = iota
+	eventComplete
+	eventError
+	eventCancel
+)
+
+// event is a signal that waits to be delivered.
+type event[T any] struct {
+	kind  eventKind
+	value T
+	err   error
 }
 
 func (s *subscriber[T]) Next(value T) {
-	if s.closed {
+	s.emit(event[T]{kind: eventNext, value: value})
+}
+
+func (s *subscriber[T]) Complete() {
+	s.emit(event[T]{kind: eventComplete})
+}
+
+func (s *subscriber[T]) Error(err error) {
+	s.emit(event[T]{kind: eventError, err: err})
+}
+
+// emit accepts a signal from the source unless a terminal signal or
+// cancellation was accepted before.
+func (s *subscriber[T]) emit(e event[T]) {
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
 		return
 	}
-	s.n--
-	if s.doOnNext != nil {
-		s.doOnNext(value)
+	if e.kind != eventNext {
+		s.done = true
 	}
+	s.dispatch(e)
+}
 
-	if s.n == 0 {
-		s.handleOnSubscribe()
+// dispatch delivers e, or queues it if another signal is being
+// delivered. It is called with mu held and releases it.
+func (s *subscriber[T]) dispatch(e event[T]) {
+	if s.emitting {
+		s.queue = append(s.queue, e)
+		s.mu.Unlock()
+		return
+	}
+	s.emitting = true
+	s.mu.Unlock()
+	for {
+		s.deliver(e)
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.emitting = false
+			s.mu.Unlock()
+			return
+		}
+		e = s.queue[0]
+		s.queue[0] = event[T]{}
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
 	}
 }
 
-func (s *subscriber[T]) Complete() {
-	if s.closed {
-		return
+func (s *subscriber[T]) deliver(e event[T]) {
+	switch e.kind {
+	case eventNext:
+		exhausted := s.consume()
+		if s.doOnNext != nil {
+			s.doOnNext(e.value)
+		}
+		if exhausted && s.n.Load() == 0 && !s.isDone() {
+			s.handleOnSubscribe()
+		}
+	case eventComplete:
+		s.close(rx.SignalComplete, nil)
+		if s.doOnComplete != nil {
+			s.doOnComplete()
+		}
+		s.finally(rx.SignalComplete)
+	case eventError:
+		s.close(rx.SignalError, e.err)
+		if s.doOnError != nil {
+			s.doOnError(e.err)
+		}
+		s.finally(rx.SignalError)
+	case eventCancel:
+		s.finally(rx.SignalCancel)
 	}
-	s.closed = true
-	if s.doOnComplete != nil {
-		s.doOnComplete()
+}
+
+// consume counts a received item against the requested items and
+// returns true if it was the last of them.
+func (s *subscriber[T]) consume() bool {
+	for {
+		n := s.n.Load()
+		if n <= 0 || n >= rx.RequestMax {
+			return false
+		}
+		if s.n.CompareAndSwap(n, n-1) {
+			return n == 1
+		}
 	}
-	s.finally(rx.SignalComplete)
 }
 
-func (s *subscriber[T]) Error(err error) {
-	if s.closed {
-		return
+// addDemand adds n requested items, up to RequestMax.
+func (s *subscriber[T]) addDemand(n int) {
+	for {
+		current := s.n.Load()
+		next := current + int64(n)
+		if next > rx.RequestMax {
+			next = rx.RequestMax
+		}
+		if s.n.CompareAndSwap(current, next) {
+			return
+		}
 	}
-	s.err = err
+}
+
+func (s *subscriber[T]) close(signal rx.SignalType, err error) {
+	s.mu.Lock()
 	s.closed = true
-	if s.doOnError != nil {
-		s.doOnError(err)
-	}
-	s.finally(rx.SignalError)
+	s.signal = signal
+	s.err = err
+	s.mu.Unlock()
+}
+
+func (s *subscriber[T]) isDone() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.done
+}
+
+func (s *subscriber[T]) result() (closed bool, signal rx.SignalType, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed, s.signal, s.err
 }
 
 func (s *subscriber[T]) finally(signal rx.SignalType) {
-	s.signal = signal
 	if s.doFinally != nil {
 		s.doFinally(signal)
 	}
-	for _, fn := range s.f.callbacks {
+	s.f.mu.Lock()
+	callbacks := s.f.callbacks
+	s.f.mu.Unlock()
+	for _, fn := range callbacks {
 		fn(signal)
 	}
 }
 
 func (s *subscriber[T]) OnSubscribe(sub OnSubscribe) {
-	if s.closed {
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
 		return
 	}
 	s.sub = sub
-	if s.n > 0 {
-		s.doRequest(s.n)
+	n := s.n.Load()
+	s.mu.Unlock()
+	// Items requested before the source subscribed are requested now.
+	if n > 0 && sub.Request != nil {
+		s.doRequest(int(n))
 	}
 }
 
@@ -192,25 +369,60 @@ func (s *subscriber[T]) handleOnSubscribe() {
 	}
 }
 
+// Request requests n more items. Requests for no items are ignored.
 func (s *subscriber[T]) Request(n int) {
-	s.n += n
-	if s.sub.Request != nil {
+	if n <= 0 {
+		return
+	}
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return
+	}
+	s.addDemand(n)
+	subscribed := s.sub.Request != nil
+	s.mu.Unlock()
+	if subscribed {
 		s.doRequest(n)
 	}
 }
 
+// request passes a request to the source unless the subscription is
+// done. It is called with requests held.
+func (s *subscriber[T]) request(n int) {
+	s.mu.Lock()
+	request := s.sub.Request
+	done := s.done
+	s.mu.Unlock()
+	if !done && request != nil {
+		request(n)
+	}
+}
+
 func (s *subscriber[T]) Cancel() {
-	s.closed = true
-	if s.sub.Cancel != nil {
-		s.sub.Cancel()
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return
 	}
-	if s.doFinally != nil {
-		s.doFinally(rx.SignalCancel)
+	s.done = true
+	s.queue = nil
+	cancel := s.sub.Cancel
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
 	}
+	s.mu.Lock()
+	s.dispatch(event[T]{kind: eventCancel})
 }
 
+// Close drops the signals that the source sends from now on.
 func (s *subscriber[T]) Close() {
+	s.mu.Lock()
+	s.done = true
 	s.closed = true
+	s.queue = nil
+	s.mu.Unlock()
 }
 
 func Map[S, D any](f Flux[S], tx rx.Transform[S, D]) Flux[D] {
@@ -4,6 +4,7 @@
 package flux
 
 import (
+	"context"
 	"sync"
 
 	"github.com/nanobus/iota/go/rx"
@@ -11,32 +12,50 @@ import (
 
 type Blockable[T any] interface {
 	Block(Subscribe[T]) error
+	// BlockContext is like Block but cancels the subscription and
+	// returns the error of ctx once ctx is done.
+	BlockContext(context.Context, Subscribe[T]) error
 }
 
 type mutex struct {
 	sync.Mutex
 }
 
+// doRequest passes a request to the source on another goroutine so
+// that sources that emit items right away do not run on the goroutine
+// of the subscriber. Requests reach the source one at a time.
 func (s *subscriber[T]) doRequest(n int) {
 	go func(n int) {
-		s.mu.Lock()
-		defer s.mu.Unlock()
-		s.sub.Request(n)
+		s.requests.Lock()
+		defer s.requests.Unlock()
+		s.request(n)
 	}(n)
 }
 
 func (f *flux[T]) Block(sub Subscribe[T]) (err error) {
-	if f.subscriber.closed {
-		return f.subscriber.err
+	if closed, err := f.result(); closed {
+		return err
 	}
 
 	return Block(Flux[T](f), sub)
 }
 
+func (f *flux[T]) BlockContext(ctx context.Context, sub Subscribe[T]) error {
+	if closed, err := f.result(); closed {
+		return err
+	}
+
+	return BlockContext(ctx, Flux[T](f), sub)
+}
+
 func (b mapper[S, D]) Block(sub Subscribe[D]) (err error) {
 	return Block(Flux[D](b), sub)
 }
 
+func (b mapper[S, D]) BlockContext(ctx context.Context, sub Subscribe[D]) error {
+	return BlockContext(ctx, Flux[D](b), sub)
+}
+
 func Block[T any](f Flux[T], sub Subscribe[T]) (err error) {
 	s := sub
 	done := make(chan struct{}, 1)
@@ -57,3 +76,46 @@ func Block[T any](f Flux[T], sub Subscribe[T]) (err error) {
 	<-done
 	return err
 }
+
+// BlockContext subscribes to f and waits for it to complete. Once ctx
+// is done, it cancels the subscription and returns the error of ctx.
+func BlockContext[T any](ctx context.Context, f Flux[T], sub Subscribe[T]) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s := sub
+	var err error
+	var once sync.Once
+	done := make(chan struct{})
+	s.OnError = func(e error) {
+		err = e
+		if sub.OnError != nil {
+			sub.OnError(e)
+		}
+	}
+	s.Finally = func(signal rx.SignalType) {
+		if sub.Finally != nil {
+			sub.Finally(signal)
+		}
+		once.Do(func() { close(done) })
+	}
+	subscriptions := make(chan rx.Subscription, 1)
+	go func() {
+		f.Subscribe(s)
+		subscriptions <- f.Subscription()
+	}()
+
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		// Subscribe may not have returned yet, so the subscription is
+		// cancelled once it has.
+		go func() {
+			if subscription := <-subscriptions; subscription != nil {
+				subscription.Cancel()
+			}
+		}()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,16 @@
+//go:build go1.23 && !purego && !appengine && !wasm && !tinygo.wasm && !wasi
+// +build go1.23,!purego,!appengine,!wasm,!tinygo.wasm,!wasi
+
+package flux
+
+import (
+	"context"
+	"iter"
+)
+
+// All returns an iterator over the items of f for use in a range loop.
+// An error of f, or of ctx, is yielded last with the zero value.
+// Stopping the loop early cancels the subscription.
+func All[T any](ctx context.Context, f Flux[T]) iter.Seq2[T, error] {
+	return all(ctx, f)
+}
@@ -0,0 +1,16 @@
+//go:build !go1.23 && !purego && !appengine && !wasm && !tinygo.wasm && !wasi
+// +build !go1.23,!purego,!appengine,!wasm,!tinygo.wasm,!wasi
+
+package flux
+
+import (
+	"context"
+)
+
+// All returns an iterator over the items of f with the signature of
+// iter.Seq2, which Go releases before 1.23 lack, so it is called with
+// a yield function. An error of f, or of ctx, is yielded last with the
+// zero value. Returning false from yield cancels the subscription.
+func All[T any](ctx context.Context, f Flux[T]) func(yield func(T, error) bool) {
+	return all(ctx, f)
+}
@@ -0,0 +1,32 @@
+//go:build go1.23
+
+package flux_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/rx/flux"
+)
+
+func TestAllRange(t *testing.T) {
+	var values []int
+	for value, err := range flux.All(context.Background(), ints(1, 2, 3)) {
+		require.NoError(t, err)
+		values = append(values, value)
+	}
+	assert.Equal(t, []int{1, 2, 3}, values)
+}
+
+func TestAllEagerSource(t *testing.T) {
+	emitted := make(chan struct{})
+	for value, err := range flux.All(context.Background(), eager(100, emitted)) {
+		require.NoError(t, err)
+		assert.Equal(t, 0, value)
+		break
+	}
+	<-emitted
+}
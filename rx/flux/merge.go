@@ -0,0 +1,251 @@
+package flux
+
+import (
+	"github.com/nanobus/iota/go/rx"
+)
+
+// prefetch is how many items are requested at a time from each of the
+// fluxes that FlatMap, Merge and Zip subscribe to, and how many items
+// All buffers.
+const prefetch = 32
+
+// FlatMap maps the items of f to fluxes and emits their items as they
+// arrive. At most concurrency fluxes are subscribed to at once, or any
+// number if concurrency is 0 or less. Items from the fluxes are queued
+// until they are requested.
+func FlatMap[T, R any](f Flux[T], mapper func(T) Flux[R], concurrency int) Flux[R] {
+	return Create(func(sink Sink[R]) {
+		m := merger[T, R]{
+			sink:        sink,
+			mapper:      mapper,
+			concurrency: concurrency,
+		}
+		subscribe(&m.outer, f, Subscribe[T]{
+			OnNext:     m.onNext,
+			OnComplete: m.onComplete,
+			OnError: func(err error) {
+				m.fail(err, nil)
+			},
+		})
+		sink.OnSubscribe(OnSubscribe{
+			Request: m.request,
+			Cancel:  m.cancel,
+		})
+	})
+}
+
+// ConcatMap maps the items of f to fluxes and emits their items in
+// order. The flux of an item is subscribed to once the flux of the
+// previous item completes.
+func ConcatMap[T, R any](f Flux[T], mapper func(T) Flux[R]) Flux[R] {
+	return FlatMap(f, mapper, 1)
+}
+
+// Merge emits the items of sources as they arrive.
+func Merge[T any](sources ...Flux[T]) Flux[T] {
+	return FlatMap(FromSlice(sources), identity[Flux[T]], len(sources))
+}
+
+// Concat emits the items of sources in order. Each source is
+// subscribed to once the previous one completes.
+func Concat[T any](sources ...Flux[T]) Flux[T] {
+	return ConcatMap(FromSlice(sources), identity[Flux[T]])
+}
+
+func identity[T any](value T) T {
+	return value
+}
+
+// merger subscribes to the fluxes of FlatMap and serializes their items.
+type merger[T, R any] struct {
+	mu          mutex
+	sink        Sink[R]
+	mapper      func(T) Flux[R]
+	concurrency int
+	outer       upstream
+
+	started   bool
+	requested int
+	queue     []mergedItem[R]
+	inners    []*mergeInner
+	outerDone bool
+	done      bool
+	draining  bool
+}
+
+type mergedItem[R any] struct {
+	value R
+	from  *mergeInner
+}
+
+// mergeInner is the subscription to one of the fluxes of FlatMap.
+type mergeInner struct {
+	up       upstream
+	consumed int
+	done     bool
+}
+
+func (m *merger[T, R]) request(n int) {
+	m.mu.Lock()
+	if m.done {
+		m.mu.Unlock()
+		return
+	}
+	first := !m.started
+	m.started = true
+	m.requested = addRequest(m.requested, n)
+	m.mu.Unlock()
+	if first {
+		if m.concurrency > 0 {
+			m.outer.Request(m.concurrency)
+		} else {
+			m.outer.Request(rx.RequestMax)
+		}
+	}
+	m.drain()
+}
+
+func (m *merger[T, R]) cancel() {
+	m.mu.Lock()
+	if m.done {
+		m.mu.Unlock()
+		return
+	}
+	m.done = true
+	m.queue = nil
+	outerDone, inners := m.outerDone, m.inners
+	m.inners = nil
+	m.mu.Unlock()
+	if !outerDone {
+		m.outer.Cancel()
+	}
+	for _, in := range inners {
+		in.up.Cancel()
+	}
+}
+
+func (m *merger[T, R]) onNext(value T) {
+	m.mu.Lock()
+	if m.done {
+		m.mu.Unlock()
+		return
+	}
+	in := new(mergeInner)
+	m.inners = append(m.inners, in)
+	m.mu.Unlock()
+	subscribe(&in.up, m.mapper(value), Subscribe[R]{
+		OnNext: func(value R) {
+			m.mu.Lock()
+			if m.done {
+				m.mu.Unlock()
+				return
+			}
+			m.queue = append(m.queue, mergedItem[R]{value, in})
+			m.mu.Unlock()
+			m.drain()
+		},
+		OnComplete: func() {
+			m.mu.Lock()
+			in.done = true
+			m.remove(in)
+			more := !m.outerDone && !m.done && m.concurrency > 0
+			m.mu.Unlock()
+			if more {
+				m.outer.Request(1)
+			}
+			m.drain()
+		},
+		OnError: func(err error) {
+			m.fail(err, in)
+		},
+	})
+	in.up.Request(prefetch)
+}
+
+func (m *merger[T, R]) onComplete() {
+	m.mu.Lock()
+	m.outerDone = true
+	m.mu.Unlock()
+	m.drain()
+}
+
+// fail cancels the fluxes that are still active and emits err.
+// failed is the flux that emitted err, or nil for the flux of FlatMap.
+func (m *merger[T, R]) fail(err error, failed *mergeInner) {
+	m.mu.Lock()
+	if m.done {
+		m.mu.Unlock()
+		return
+	}
+	m.done = true
+	m.queue = nil
+	if failed != nil {
+		failed.done = true
+	}
+	cancelOuter := failed != nil && !m.outerDone
+	inners := m.inners
+	m.outerDone = true
+	m.inners = nil
+	m.mu.Unlock()
+	if cancelOuter {
+		m.outer.Cancel()
+	}
+	for _, in := range inners {
+		if !in.done {
+			in.up.Cancel()
+		}
+	}
+	m.sink.Error(err)
+}
+
+// remove removes a completed flux. It is called with mu held.
+func (m *merger[T, R]) remove(in *mergeInner) {
+	for i, inner := range m.inners {
+		if inner == in {
+			m.inners = append(m.inners[:i], m.inners[i+1:]...)
+			return
+		}
+	}
+}
+
+// drain emits queued items while they are requested and completes once
+// all fluxes have completed. Only one caller emits at a time. Items
+// queued by other callers meanwhile are emitted by it.
+func (m *merger[T, R]) drain() {
+	m.mu.Lock()
+	if m.draining {
+		m.mu.Unlock()
+		return
+	}
+	m.draining = true
+	for !m.done && m.requested > 0 && len(m.queue) > 0 {
+		item := m.queue[0]
+		m.queue[0] = mergedItem[R]{}
+		m.queue = m.queue[1:]
+		if m.requested != rx.RequestMax {
+			m.requested--
+		}
+		// Items are requested again once half of them were emitted.
+		replenish := 0
+		item.from.consumed++
+		if !item.from.done && item.from.consumed >= prefetch/2 {
+			replenish = item.from.consumed
+			item.from.consumed = 0
+		}
+		m.mu.Unlock()
+		m.sink.Next(item.value)
+		if replenish > 0 {
+			item.from.up.Request(replenish)
+		}
+		m.mu.Lock()
+	}
+	complete := !m.done && m.outerDone && len(m.inners) == 0 && len(m.queue) == 0
+	if complete {
+		m.done = true
+	}
+	m.draining = false
+	m.mu.Unlock()
+	if complete {
+		m.sink.Complete()
+	}
+}
@@ -0,0 +1,166 @@
+package flux
+
+import (
+	"github.com/nanobus/iota/go/rx"
+)
+
+// NewMulticastProcessor returns a hot processor that passes the signals
+// it receives to all of its subscribers. Items that arrive before a
+// subscriber subscribes are not passed to it. Items that a subscriber
+// has not requested are handled by overflow, on their own for each
+// subscriber. Subscribers that subscribe after the processor completed
+// receive its terminal signal.
+func NewMulticastProcessor[T any](overflow Overflow) Processor[T] {
+	return newMulticast[T](overflow, 0)
+}
+
+// NewReplayProcessor returns a processor that passes the signals it
+// receives to all of its subscribers and replays the last n items to
+// each new subscriber. All items are replayed if n is less than zero.
+// Items are buffered until each subscriber requests them.
+func NewReplayProcessor[T any](n int) Processor[T] {
+	return newMulticast[T](OverflowBuffer(0), n)
+}
+
+// multicast implements the multicast and replay processors.
+type multicast[T any] struct {
+	Flux[T]
+	overflow Overflow
+	// history is the number of items to replay: none if it is zero and
+	// all if it is less than zero.
+	history int
+
+	mu     mutex
+	inners []*inner[T]
+	replay []T
+	done   bool
+	err    error
+}
+
+func newMulticast[T any](overflow Overflow, history int) *multicast[T] {
+	m := &multicast[T]{
+		overflow: overflow,
+		history:  history,
+	}
+	m.Flux = Create(m.subscribe)
+	return m
+}
+
+func (m *multicast[T]) subscribe(sink Sink[T]) {
+	in := &inner[T]{
+		buffer: buffer[T]{
+			sink:  sink,
+			queue: overflowQueue[T]{overflow: OverflowBuffer(0)},
+		},
+		m: m,
+	}
+	m.mu.Lock()
+	// Replayed items are buffered in full. The strategy applies to the
+	// items that arrive from now on.
+	in.queue.items = append(in.queue.items, m.replay...)
+	in.queue.overflow = m.overflow
+	if m.done {
+		in.terminated = true
+		in.err = m.err
+	} else {
+		m.inners = append(m.inners, in)
+	}
+	m.mu.Unlock()
+	sink.OnSubscribe(OnSubscribe{
+		Request: in.request,
+		Cancel:  in.cancel,
+	})
+	in.drain()
+}
+
+func (m *multicast[T]) Next(value T) {
+	m.mu.Lock()
+	if m.done {
+		m.mu.Unlock()
+		return
+	}
+	if m.history != 0 {
+		m.replay = append(m.replay, value)
+		if m.history > 0 && len(m.replay) > m.history {
+			var zero T
+			m.replay[0] = zero
+			m.replay = m.replay[1:]
+		}
+	}
+	inners := m.inners
+	m.mu.Unlock()
+	for _, in := range inners {
+		in.next(value)
+	}
+}
+
+func (m *multicast[T]) Complete() {
+	m.terminate(nil)
+}
+
+func (m *multicast[T]) Error(err error) {
+	m.terminate(err)
+}
+
+func (m *multicast[T]) terminate(err error) {
+	m.mu.Lock()
+	if m.done {
+		m.mu.Unlock()
+		return
+	}
+	m.done = true
+	m.err = err
+	inners := m.inners
+	m.inners = nil
+	m.mu.Unlock()
+	for _, in := range inners {
+		in.terminate(err)
+	}
+}
+
+// OnSubscribe requests all items from the source of the processor. The
+// processor does not slow down its source for slow subscribers.
+func (m *multicast[T]) OnSubscribe(sub OnSubscribe) {
+	if sub.Request != nil {
+		sub.Request(rx.RequestMax)
+	}
+}
+
+// isDone returns true if the processor received a terminal signal.
+func (m *multicast[T]) isDone() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.done
+}
+
+// remove removes in from the subscribers.
+func (m *multicast[T]) remove(in *inner[T]) {
+	m.mu.Lock()
+	for i, other := range m.inners {
+		if other == in {
+			inners := make([]*inner[T], 0, len(m.inners)-1)
+			inners = append(inners, m.inners[:i]...)
+			m.inners = append(inners, m.inners[i+1:]...)
+			break
+		}
+	}
+	m.mu.Unlock()
+}
+
+// inner is a subscriber of a multicast processor.
+type inner[T any] struct {
+	buffer[T]
+	m *multicast[T]
+}
+
+func (in *inner[T]) next(value T) {
+	if !in.buffer.next(value) {
+		in.m.remove(in)
+	}
+}
+
+func (in *inner[T]) cancel() {
+	if in.buffer.cancel() {
+		in.m.remove(in)
+	}
+}
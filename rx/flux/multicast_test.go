@@ -0,0 +1,127 @@
+package flux_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/flux"
+)
+
+func TestMulticastProcessor(t *testing.T) {
+	p := flux.NewMulticastProcessor[int](flux.OverflowBuffer(0))
+	// Items without subscribers are dropped.
+	p.Next(0)
+
+	a := record[int](p, rx.RequestMax)
+	b := record[int](p, 0)
+	bsub := p.Subscription()
+	p.Next(1)
+	p.Next(2)
+	a.eventually(t, []int{1, 2}, false, nil)
+	bsub.Request(1)
+	b.eventually(t, []int{1}, false, nil)
+
+	p.Complete()
+	a.eventually(t, []int{1, 2}, true, nil)
+	// Buffered items are passed before the terminal signal.
+	b.eventually(t, []int{1}, false, nil)
+	bsub.Request(1)
+	b.eventually(t, []int{1, 2}, true, nil)
+
+	late := record[int](p, 1)
+	late.eventually(t, nil, true, nil)
+}
+
+func TestMulticastOverflow(t *testing.T) {
+	p := flux.NewMulticastProcessor[int](flux.OverflowDropLatest(1))
+	r := record[int](p, 0)
+	sub := p.Subscription()
+	p.Next(1)
+	p.Next(2)
+	p.Next(3)
+	sub.Request(5)
+	r.eventually(t, []int{1}, false, nil)
+
+	p = flux.NewMulticastProcessor[int](flux.OverflowBuffer(2))
+	slow := record[int](p, 0)
+	fast := record[int](p, rx.RequestMax)
+	p.Next(1)
+	fast.eventually(t, []int{1}, false, nil)
+	p.Next(2)
+	p.Next(3)
+	// The slow subscriber fails without affecting the others.
+	slow.eventually(t, nil, false, flux.ErrOverflow)
+	p.Next(4)
+	fast.eventually(t, []int{1, 2, 3, 4}, false, nil)
+}
+
+func TestReplayProcessor(t *testing.T) {
+	p := flux.NewReplayProcessor[int](2)
+	p.Next(1)
+	p.Next(2)
+	p.Next(3)
+	r := record[int](p, rx.RequestMax)
+	r.eventually(t, []int{2, 3}, false, nil)
+	p.Next(4)
+	r.eventually(t, []int{2, 3, 4}, false, nil)
+	p.Complete()
+	r.eventually(t, []int{2, 3, 4}, true, nil)
+
+	late := record[int](p, 1)
+	sub := p.Subscription()
+	late.eventually(t, []int{3}, false, nil)
+	sub.Request(1)
+	late.eventually(t, []int{3, 4}, true, nil)
+
+	all := flux.NewReplayProcessor[int](-1)
+	all.Next(1)
+	all.Next(2)
+	all.Next(3)
+	record[int](all, rx.RequestMax).eventually(t, []int{1, 2, 3}, false, nil)
+}
+
+func TestPublish(t *testing.T) {
+	var src source
+	c := flux.Publish(src.flux(3))
+	a := record[int](c, rx.RequestMax)
+	b := record[int](c, 2)
+	requested, _ := src.state()
+	assert.Zero(t, requested)
+
+	c.Connect()
+	a.eventually(t, []int{0, 1, 2}, true, nil)
+	b.eventually(t, []int{0, 1}, false, nil)
+
+	// Connecting again does not subscribe again.
+	c.Connect()
+	requested, _ = src.state()
+	assert.Equal(t, rx.RequestMax, requested)
+}
+
+func TestShare(t *testing.T) {
+	var h hot
+	s := flux.Share(h.flux())
+	a := record(s, rx.RequestMax)
+	asub := s.Subscription()
+	b := record(s, rx.RequestMax)
+	bsub := s.Subscription()
+	h.waitRequested(t)
+	h.push(1)
+	a.eventually(t, []int{1}, false, nil)
+	b.eventually(t, []int{1}, false, nil)
+
+	// The source is cancelled once all subscribers cancelled.
+	asub.Cancel()
+	assert.False(t, h.isCancelled())
+	h.push(2)
+	b.eventually(t, []int{1, 2}, false, nil)
+	bsub.Cancel()
+	assert.True(t, h.isCancelled())
+
+	// Subscribers after completion subscribe to the source again.
+	s = flux.Share(ints(1, 2))
+	record(s, rx.RequestMax).eventually(t, []int{1, 2}, true, nil)
+	record(s, rx.RequestMax).eventually(t, []int{1, 2}, true, nil)
+}
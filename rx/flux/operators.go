@@ -0,0 +1,292 @@
+package flux
+
+import (
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/mono"
+)
+
+// Filter emits the items of f that predicate returns true for.
+// An item is requested from f in place of each item that is dropped.
+func Filter[T any](f Flux[T], predicate func(T) bool) Flux[T] {
+	return Create(func(sink Sink[T]) {
+		up := new(upstream)
+		subscribe(up, f, Subscribe[T]{
+			OnNext: func(value T) {
+				if predicate(value) {
+					sink.Next(value)
+				} else {
+					up.replenish()
+				}
+			},
+			OnComplete: sink.Complete,
+			OnError:    sink.Error,
+		})
+		relay(sink, up)
+	})
+}
+
+// Take emits the first n items of f, then cancels f and completes.
+// No more than n items are requested from f.
+func Take[T any](f Flux[T], n int) Flux[T] {
+	return Create(func(sink Sink[T]) {
+		if n <= 0 {
+			sink.Complete()
+			return
+		}
+		up := new(upstream)
+		var mu mutex
+		remaining := n
+		taken := 0
+		subscribe(up, f, Subscribe[T]{
+			OnNext: func(value T) {
+				if taken == n {
+					return
+				}
+				taken++
+				sink.Next(value)
+				if taken == n {
+					up.Cancel()
+					sink.Complete()
+				}
+			},
+			OnComplete: sink.Complete,
+			OnError:    sink.Error,
+		})
+		sink.OnSubscribe(OnSubscribe{
+			Request: func(k int) {
+				mu.Lock()
+				if k > remaining {
+					k = remaining
+				}
+				remaining -= k
+				mu.Unlock()
+				up.Request(k)
+			},
+			Cancel: up.Cancel,
+		})
+	})
+}
+
+// Skip drops the first n items of f and emits the rest. The first
+// request to f includes the n items that are dropped.
+func Skip[T any](f Flux[T], n int) Flux[T] {
+	return Create(func(sink Sink[T]) {
+		up := new(upstream)
+		skipped := 0
+		subscribe(up, f, Subscribe[T]{
+			OnNext: func(value T) {
+				if skipped < n {
+					skipped++
+					return
+				}
+				sink.Next(value)
+			},
+			OnComplete: sink.Complete,
+			OnError:    sink.Error,
+		})
+		first := true
+		sink.OnSubscribe(OnSubscribe{
+			Request: func(k int) {
+				if first && n > 0 {
+					first = false
+					k = addRequest(k, n)
+				}
+				up.Request(k)
+			},
+			Cancel: up.Cancel,
+		})
+	})
+}
+
+// TakeWhile emits the items of f until predicate returns false,
+// then cancels f and completes.
+func TakeWhile[T any](f Flux[T], predicate func(T) bool) Flux[T] {
+	return Create(func(sink Sink[T]) {
+		up := new(upstream)
+		done := false
+		subscribe(up, f, Subscribe[T]{
+			OnNext: func(value T) {
+				if done {
+					return
+				}
+				if !predicate(value) {
+					done = true
+					up.Cancel()
+					sink.Complete()
+					return
+				}
+				sink.Next(value)
+			},
+			OnComplete: sink.Complete,
+			OnError:    sink.Error,
+		})
+		relay(sink, up)
+	})
+}
+
+// Reduce requests all items of f and combines them with accumulator,
+// starting with initial. The mono succeeds with the result once f
+// completes.
+func Reduce[T, A any](f Flux[T], initial A, accumulator func(A, T) A) mono.Mono[A] {
+	return mono.Create(func(sink mono.Sink[A]) {
+		up := new(upstream)
+		acc := initial
+		subscribe(up, f, Subscribe[T]{
+			OnNext: func(value T) {
+				acc = accumulator(acc, value)
+			},
+			OnComplete: func() {
+				sink.Success(acc)
+			},
+			OnError: sink.Error,
+		})
+		up.Request(rx.RequestMax)
+	})
+}
+
+// Scan combines the items of f with accumulator, starting with initial,
+// and emits the result after each item.
+func Scan[T, A any](f Flux[T], initial A, accumulator func(A, T) A) Flux[A] {
+	return Create(func(sink Sink[A]) {
+		up := new(upstream)
+		acc := initial
+		subscribe(up, f, Subscribe[T]{
+			OnNext: func(value T) {
+				acc = accumulator(acc, value)
+				sink.Next(acc)
+			},
+			OnComplete: sink.Complete,
+			OnError:    sink.Error,
+		})
+		relay(sink, up)
+	})
+}
+
+// Distinct drops the items of f that were emitted before.
+func Distinct[T comparable](f Flux[T]) Flux[T] {
+	return DistinctBy(f, func(value T) T { return value })
+}
+
+// DistinctBy drops the items of f with a key that was emitted before.
+func DistinctBy[T any, K comparable](f Flux[T], key func(T) K) Flux[T] {
+	return Create(func(sink Sink[T]) {
+		up := new(upstream)
+		seen := make(map[K]struct{})
+		subscribe(up, Filter(f, func(value T) bool {
+			k := key(value)
+			if _, ok := seen[k]; ok {
+				return false
+			}
+			seen[k] = struct{}{}
+			return true
+		}), Subscribe[T]{
+			OnNext:     sink.Next,
+			OnComplete: sink.Complete,
+			OnError:    sink.Error,
+		})
+		relay(sink, up)
+	})
+}
+
+// Buffer collects the items of f into slices of size items. The last
+// slice holds the remaining items when f completes. A request for n
+// slices requests n*size items from f.
+func Buffer[T any](f Flux[T], size int) Flux[[]T] {
+	if size <= 0 {
+		panic("flux: buffer size must be positive")
+	}
+	return Create(func(sink Sink[[]T]) {
+		up := new(upstream)
+		var buf []T
+		subscribe(up, f, Subscribe[T]{
+			OnNext: func(value T) {
+				buf = append(buf, value)
+				if len(buf) == size {
+					b := buf
+					buf = nil
+					sink.Next(b)
+				}
+			},
+			OnComplete: func() {
+				if len(buf) > 0 {
+					b := buf
+					buf = nil
+					sink.Next(b)
+				}
+				sink.Complete()
+			},
+			OnError: func(err error) {
+				buf = nil
+				sink.Error(err)
+			},
+		})
+		sink.OnSubscribe(OnSubscribe{
+			Request: func(n int) {
+				up.Request(mulRequest(n, size))
+			},
+			Cancel: up.Cancel,
+		})
+	})
+}
+
+// Window splits the items of f into fluxes of size items. Each window
+// is emitted once its items have arrived, so windows are requested
+// like the slices of Buffer.
+func Window[T any](f Flux[T], size int) Flux[Flux[T]] {
+	return Map(Buffer(f, size), func(values []T) (Flux[T], error) {
+		return FromSlice(values), nil
+	})
+}
+
+// StartWith emits values before the items of f.
+func StartWith[T any](f Flux[T], values ...T) Flux[T] {
+	return Concat(FromSlice(values), f)
+}
+
+// DefaultIfEmpty emits value if f completes without items.
+func DefaultIfEmpty[T any](f Flux[T], value T) Flux[T] {
+	return Create(func(sink Sink[T]) {
+		up := new(upstream)
+		var mu mutex
+		requested := false
+		empty := true
+		pending := false
+		subscribe(up, f, Subscribe[T]{
+			OnNext: func(v T) {
+				empty = false
+				sink.Next(v)
+			},
+			OnComplete: func() {
+				if empty {
+					// The default value is emitted once it is requested.
+					mu.Lock()
+					emit := requested
+					pending = !requested
+					mu.Unlock()
+					if !emit {
+						return
+					}
+					sink.Next(value)
+				}
+				sink.Complete()
+			},
+			OnError: sink.Error,
+		})
+		sink.OnSubscribe(OnSubscribe{
+			Request: func(n int) {
+				mu.Lock()
+				requested = true
+				emit := pending
+				pending = false
+				mu.Unlock()
+				if emit {
+					sink.Next(value)
+					sink.Complete()
+					return
+				}
+				up.Request(n)
+			},
+			Cancel: up.Cancel,
+		})
+	})
+}
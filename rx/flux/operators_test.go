@@ -0,0 +1,290 @@
+package flux_test
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/flux"
+)
+
+// source emits 0, 1, 2, ... up to count items as they are requested.
+type source struct {
+	mu        sync.Mutex
+	requested int
+	cancelled bool
+}
+
+func (s *source) flux(count int) flux.Flux[int] {
+	next := 0
+	return flux.Create(func(sink flux.Sink[int]) {
+		sink.OnSubscribe(flux.OnSubscribe{
+			Request: func(n int) {
+				s.mu.Lock()
+				s.requested += n
+				s.mu.Unlock()
+				for ; n > 0 && next < count; n-- {
+					sink.Next(next)
+					next++
+				}
+				if next == count {
+					sink.Complete()
+				}
+			},
+			Cancel: func() {
+				s.mu.Lock()
+				s.cancelled = true
+				s.mu.Unlock()
+			},
+		})
+	})
+}
+
+func (s *source) state() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requested, s.cancelled
+}
+
+func collect[T any](t *testing.T, f flux.Flux[T]) []T {
+	t.Helper()
+	var mu sync.Mutex
+	var items []T
+	err := f.Block(flux.Subscribe[T]{
+		OnNext: func(value T) {
+			mu.Lock()
+			items = append(items, value)
+			mu.Unlock()
+		},
+	})
+	require.NoError(t, err)
+	return items
+}
+
+// request subscribes to f without requesting items, requests n items
+// and returns the items that were emitted once no more arrive.
+func request[T any](t *testing.T, f flux.Flux[T], n int) []T {
+	t.Helper()
+	ch := make(chan T, 100)
+	f.Subscribe(flux.Subscribe[T]{
+		OnNext: func(value T) {
+			ch <- value
+		},
+		NoRequest: true,
+	})
+	f.Subscription().Request(n)
+	var items []T
+	for {
+		select {
+		case value := <-ch:
+			items = append(items, value)
+		case <-time.After(50 * time.Millisecond):
+			return items
+		}
+	}
+}
+
+func ints(values ...int) flux.Flux[int] {
+	return flux.FromSlice(values)
+}
+
+func even(value int) bool {
+	return value%2 == 0
+}
+
+func TestFilter(t *testing.T) {
+	assert.Equal(t, []int{0, 2, 4}, collect(t, flux.Filter(ints(0, 1, 2, 3, 4, 5), even)))
+
+	// Dropped items are replaced.
+	var src source
+	assert.Equal(t, []int{0, 2, 4}, request(t, flux.Filter(src.flux(100), even), 3))
+	requested, _ := src.state()
+	assert.Equal(t, 5, requested)
+}
+
+func TestTake(t *testing.T) {
+	var src source
+	assert.Equal(t, []int{0, 1, 2}, collect(t, flux.Take(src.flux(100), 3)))
+	requested, cancelled := src.state()
+	assert.Equal(t, 3, requested)
+	assert.True(t, cancelled)
+
+	assert.Equal(t, []int{0, 1}, collect(t, flux.Take(ints(0, 1), 3)))
+	assert.Empty(t, collect(t, flux.Take(ints(0, 1), 0)))
+}
+
+func TestSkip(t *testing.T) {
+	assert.Equal(t, []int{3, 4}, collect(t, flux.Skip(ints(0, 1, 2, 3, 4), 3)))
+
+	var src source
+	assert.Equal(t, []int{2, 3}, request(t, flux.Skip(src.flux(100), 2), 2))
+	requested, _ := src.state()
+	assert.Equal(t, 4, requested)
+}
+
+func TestTakeWhile(t *testing.T) {
+	var src source
+	f := flux.TakeWhile(src.flux(100), func(value int) bool { return value < 3 })
+	assert.Equal(t, []int{0, 1, 2}, collect(t, f))
+	_, cancelled := src.state()
+	assert.True(t, cancelled)
+}
+
+func TestReduce(t *testing.T) {
+	sum, err := flux.Reduce(ints(1, 2, 3, 4), 0, func(acc, value int) int {
+		return acc + value
+	}).Block()
+	require.NoError(t, err)
+	assert.Equal(t, 10, sum)
+
+	boom := errors.New("boom")
+	_, err = flux.Reduce(flux.Error[int](boom), 0, func(acc, value int) int {
+		return acc + value
+	}).Block()
+	assert.Equal(t, boom, err)
+}
+
+func TestScan(t *testing.T) {
+	f := flux.Scan(ints(1, 2, 3, 4), "", func(acc string, value int) string {
+		return acc + strconv.Itoa(value)
+	})
+	assert.Equal(t, []string{"1", "12", "123", "1234"}, collect(t, f))
+}
+
+func TestDistinct(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, collect(t, flux.Distinct(ints(1, 2, 1, 3, 2, 3))))
+
+	f := flux.DistinctBy(flux.FromSlice([]string{"a", "bb", "c", "dd", "eee"}), func(value string) int {
+		return len(value)
+	})
+	assert.Equal(t, []string{"a", "bb", "eee"}, collect(t, f))
+}
+
+func TestBuffer(t *testing.T) {
+	assert.Equal(t, [][]int{{0, 1}, {2, 3}, {4}}, collect(t, flux.Buffer(ints(0, 1, 2, 3, 4), 2)))
+
+	var src source
+	assert.Equal(t, [][]int{{0, 1, 2}, {3, 4, 5}}, request(t, flux.Buffer(src.flux(100), 3), 2))
+	requested, _ := src.state()
+	assert.Equal(t, 6, requested)
+
+	assert.Panics(t, func() { flux.Buffer(ints(), 0) })
+}
+
+func TestWindow(t *testing.T) {
+	var windows [][]int
+	for _, w := range collect(t, flux.Window(ints(0, 1, 2, 3, 4), 2)) {
+		windows = append(windows, collect(t, w))
+	}
+	assert.Equal(t, [][]int{{0, 1}, {2, 3}, {4}}, windows)
+}
+
+func repeat(value, count int) flux.Flux[int] {
+	values := make([]int, count)
+	for i := range values {
+		values[i] = value
+	}
+	return flux.FromSlice(values)
+}
+
+func TestFlatMap(t *testing.T) {
+	f := flux.FlatMap(ints(1, 2, 3), func(value int) flux.Flux[int] {
+		return repeat(value, value)
+	}, 2)
+	items := collect(t, f)
+	sort.Ints(items)
+	assert.Equal(t, []int{1, 2, 2, 3, 3, 3}, items)
+
+	// Items are emitted as they are requested.
+	f = flux.FlatMap(ints(1, 2, 3), func(value int) flux.Flux[int] {
+		return repeat(value, 100)
+	}, 0)
+	assert.Len(t, request(t, f, 5), 5)
+}
+
+func TestFlatMapError(t *testing.T) {
+	boom := errors.New("boom")
+	var src source
+	f := flux.FlatMap(ints(1, 2), func(value int) flux.Flux[int] {
+		if value == 2 {
+			return flux.Error[int](boom)
+		}
+		return src.flux(1000)
+	}, 2)
+	err := f.Block(flux.Subscribe[int]{})
+	assert.Equal(t, boom, err)
+	assert.Eventually(t, func() bool {
+		_, cancelled := src.state()
+		return cancelled
+	}, time.Second, time.Millisecond)
+}
+
+func TestConcatMap(t *testing.T) {
+	f := flux.ConcatMap(ints(1, 2, 3), func(value int) flux.Flux[int] {
+		return repeat(value, value)
+	})
+	assert.Equal(t, []int{1, 2, 2, 3, 3, 3}, collect(t, f))
+}
+
+func TestMerge(t *testing.T) {
+	items := collect(t, flux.Merge(ints(1, 3, 5), ints(2, 4)))
+	sort.Ints(items)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, items)
+	assert.Empty(t, collect(t, flux.Merge[int]()))
+}
+
+func TestConcat(t *testing.T) {
+	assert.Equal(t, []int{1, 3, 5, 2, 4}, collect(t, flux.Concat(ints(1, 3, 5), ints(2, 4))))
+}
+
+func TestZip(t *testing.T) {
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}}, collect(t, flux.Zip(ints(1, 3, 5), ints(2, 4))))
+
+	var src source
+	f := flux.ZipWith(src.flux(100), flux.FromSlice([]string{"a", "b"}), func(i int, s string) string {
+		return s + strconv.Itoa(i)
+	})
+	assert.Equal(t, []string{"a0", "b1"}, collect(t, f))
+	_, cancelled := src.state()
+	assert.True(t, cancelled)
+}
+
+func TestZipRequest(t *testing.T) {
+	var src source
+	f := flux.ZipWith(src.flux(100), flux.FromSlice([]string{"a", "b"}), func(i int, s string) string {
+		return s + strconv.Itoa(i)
+	})
+	assert.Equal(t, []string{"a0", "b1"}, request(t, f, 5))
+	requested, cancelled := src.state()
+	assert.Equal(t, 5, requested)
+	assert.True(t, cancelled)
+
+	// Unbounded requests are made prefetch items at a time.
+	var a, b source
+	items := request(t, flux.Zip(a.flux(100), b.flux(100)), rx.RequestMax)
+	assert.Len(t, items, 100)
+	requested, _ = a.state()
+	assert.LessOrEqual(t, requested, 100+32)
+}
+
+func TestStartWith(t *testing.T) {
+	assert.Equal(t, []int{0, 1, 2, 3}, collect(t, flux.StartWith(ints(2, 3), 0, 1)))
+}
+
+func TestDefaultIfEmpty(t *testing.T) {
+	assert.Equal(t, []int{1, 2}, collect(t, flux.DefaultIfEmpty(ints(1, 2), 0)))
+	assert.Equal(t, []int{0}, collect(t, flux.DefaultIfEmpty(ints(), 0)))
+
+	// The default value waits for a request.
+	empty := flux.Create(func(sink flux.Sink[int]) {
+		sink.Complete()
+	})
+	assert.Equal(t, []int{0}, request(t, flux.DefaultIfEmpty(empty, 0), 1))
+}
@@ -0,0 +1,332 @@
+package flux
+
+import (
+	"errors"
+
+	"github.com/nanobus/iota/go/rx"
+)
+
+// ErrOverflow is the error of a subscriber that did not request items
+// fast enough for the Overflow strategy of its source.
+var ErrOverflow = errors.New("flux: overflow")
+
+// Overflow is a strategy for items that arrive while a subscriber has
+// not requested them.
+type Overflow struct {
+	kind overflowKind
+	size int
+}
+
+type overflowKind uint8
+
+const (
+	overflowBuffer overflowKind = iota
+	overflowDropLatest
+	overflowDropOldest
+	overflowError
+)
+
+var (
+	// OverflowLatest keeps only the latest item that was not requested.
+	OverflowLatest = OverflowDropOldest(1)
+	// OverflowError fails with ErrOverflow as soon as an item arrives
+	// that was not requested.
+	OverflowError = Overflow{kind: overflowError}
+)
+
+// OverflowBuffer buffers up to size items that were not requested and
+// fails with ErrOverflow once more arrive. A size of zero or less
+// buffers any number of items.
+func OverflowBuffer(size int) Overflow {
+	return Overflow{kind: overflowBuffer, size: size}
+}
+
+// OverflowDropLatest buffers up to size items that were not requested
+// and drops the items that arrive while the buffer is full.
+func OverflowDropLatest(size int) Overflow {
+	return Overflow{kind: overflowDropLatest, size: size}
+}
+
+// OverflowDropOldest buffers up to size items that were not requested
+// and drops the oldest of them to make room for the items that arrive
+// while the buffer is full.
+func OverflowDropOldest(size int) Overflow {
+	return Overflow{kind: overflowDropOldest, size: size}
+}
+
+// bounded returns true if the strategy limits the items it buffers.
+func (o Overflow) bounded() bool {
+	return o.kind != overflowBuffer || o.size > 0
+}
+
+// overflowQueue holds the items of a subscriber until they are
+// requested.
+type overflowQueue[T any] struct {
+	overflow  Overflow
+	items     []T
+	requested int
+}
+
+// offer queues value and returns ErrOverflow if the strategy fails.
+func (q *overflowQueue[T]) offer(value T) error {
+	if q.overflow.bounded() && q.requested != rx.RequestMax &&
+		len(q.items)-q.requested >= q.overflow.size {
+		switch q.overflow.kind {
+		case overflowBuffer, overflowError:
+			return ErrOverflow
+		case overflowDropLatest:
+			return nil
+		case overflowDropOldest:
+			if len(q.items) == 0 {
+				return nil
+			}
+			var zero T
+			q.items[0] = zero
+			q.items = q.items[1:]
+		}
+	}
+	q.items = append(q.items, value)
+	return nil
+}
+
+// request adds n to the requested items.
+func (q *overflowQueue[T]) request(n int) {
+	q.requested = addRequest(q.requested, n)
+}
+
+// poll removes the next requested item.
+func (q *overflowQueue[T]) poll() (value T, ok bool) {
+	if len(q.items) == 0 || q.requested == 0 {
+		return value, false
+	}
+	var zero T
+	value = q.items[0]
+	q.items[0] = zero
+	q.items = q.items[1:]
+	if q.requested != rx.RequestMax {
+		q.requested--
+	}
+	return value, true
+}
+
+// clear drops the queued items.
+func (q *overflowQueue[T]) clear() {
+	q.items = nil
+}
+
+// buffer passes the items of a source to sink as they are requested and
+// holds the others according to an Overflow strategy. Terminal signals
+// are passed once the held items were passed.
+type buffer[T any] struct {
+	sink Sink[T]
+
+	mu         mutex
+	queue      overflowQueue[T]
+	terminated bool
+	err        error
+	finished   bool
+	draining   bool
+}
+
+// next passes or holds value. It returns false if the strategy failed,
+// in which case the held items are dropped and sink fails with
+// ErrOverflow.
+func (b *buffer[T]) next(value T) bool {
+	b.mu.Lock()
+	if b.terminated {
+		b.mu.Unlock()
+		return true
+	}
+	if err := b.queue.offer(value); err != nil {
+		b.terminated = true
+		b.err = err
+		b.queue.clear()
+		b.mu.Unlock()
+		b.drain()
+		return false
+	}
+	b.mu.Unlock()
+	b.drain()
+	return true
+}
+
+func (b *buffer[T]) terminate(err error) {
+	b.mu.Lock()
+	if b.terminated {
+		b.mu.Unlock()
+		return
+	}
+	b.terminated = true
+	b.err = err
+	b.mu.Unlock()
+	b.drain()
+}
+
+func (b *buffer[T]) request(n int) {
+	if n <= 0 {
+		return
+	}
+	b.mu.Lock()
+	b.queue.request(n)
+	b.mu.Unlock()
+	b.drain()
+}
+
+// cancel drops the held items and returns false if sink received a
+// terminal signal or was cancelled before.
+func (b *buffer[T]) cancel() bool {
+	b.mu.Lock()
+	finished := b.finished
+	b.finished = true
+	b.terminated = true
+	b.queue.clear()
+	b.mu.Unlock()
+	return !finished
+}
+
+// drain passes the requested items to sink, followed by the terminal
+// signal once no items are held. Only one caller passes signals at a
+// time.
+func (b *buffer[T]) drain() {
+	b.mu.Lock()
+	if b.draining || b.finished {
+		b.mu.Unlock()
+		return
+	}
+	b.draining = true
+	for !b.finished {
+		value, ok := b.queue.poll()
+		if !ok {
+			break
+		}
+		b.mu.Unlock()
+		b.sink.Next(value)
+		b.mu.Lock()
+	}
+	complete := !b.finished && b.terminated && len(b.queue.items) == 0
+	if complete {
+		b.finished = true
+	}
+	err := b.err
+	b.draining = false
+	b.mu.Unlock()
+	if complete {
+		if err != nil {
+			b.sink.Error(err)
+		} else {
+			b.sink.Complete()
+		}
+	}
+}
+
+// overflowSink applies an Overflow strategy to the items of a source
+// for Create and OnBackpressureBuffer. Requests are passed on to the
+// source, which fails with ErrOverflow if it emits too many items that
+// were not requested.
+type overflowSink[T any] struct {
+	buffer[T]
+	// sub is the subscription to the source, once the source calls
+	// OnSubscribe. Requests made before are passed on then.
+	sub       OnSubscribe
+	pending   int
+	cancelled bool
+}
+
+func newOverflowSink[T any](sink Sink[T], overflow Overflow) *overflowSink[T] {
+	return &overflowSink[T]{
+		buffer: buffer[T]{
+			sink:  sink,
+			queue: overflowQueue[T]{overflow: overflow},
+		},
+	}
+}
+
+// subscription returns the subscription for the subscriber of sink.
+func (s *overflowSink[T]) subscription() OnSubscribe {
+	return OnSubscribe{
+		Request: s.requestSource,
+		Cancel:  s.cancelSource,
+	}
+}
+
+func (s *overflowSink[T]) Next(value T) {
+	if !s.next(value) {
+		s.cancelSource()
+	}
+}
+
+func (s *overflowSink[T]) Complete() {
+	s.terminate(nil)
+}
+
+func (s *overflowSink[T]) Error(err error) {
+	s.terminate(err)
+}
+
+func (s *overflowSink[T]) OnSubscribe(sub OnSubscribe) {
+	s.mu.Lock()
+	s.sub = sub
+	pending := s.pending
+	s.pending = 0
+	cancelled := s.cancelled
+	s.mu.Unlock()
+	if cancelled {
+		if sub.Cancel != nil {
+			sub.Cancel()
+		}
+	} else if pending > 0 && sub.Request != nil {
+		sub.Request(pending)
+	}
+}
+
+func (s *overflowSink[T]) requestSource(n int) {
+	if n <= 0 {
+		return
+	}
+	s.request(n)
+	s.mu.Lock()
+	request := s.sub.Request
+	if request == nil {
+		s.pending = addRequest(s.pending, n)
+	}
+	s.mu.Unlock()
+	if request != nil {
+		request(n)
+	}
+}
+
+// cancelSource cancels the subscription to the source, or does so once
+// the source calls OnSubscribe.
+func (s *overflowSink[T]) cancelSource() {
+	s.cancel()
+	s.mu.Lock()
+	if s.cancelled {
+		s.mu.Unlock()
+		return
+	}
+	s.cancelled = true
+	cancel := s.sub.Cancel
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// OnBackpressureBuffer passes the requests of its subscriber to f and
+// holds the items that f emits beyond them according to overflow.
+func OnBackpressureBuffer[T any](f Flux[T], overflow Overflow) Flux[T] {
+	return Create(func(sink Sink[T]) {
+		s := newOverflowSink(sink, overflow)
+		var up upstream
+		subscribe(&up, f, Subscribe[T]{
+			OnNext:     s.Next,
+			OnComplete: s.Complete,
+			OnError:    s.Error,
+		})
+		s.OnSubscribe(OnSubscribe{
+			Request: up.Request,
+			Cancel:  up.Cancel,
+		})
+		sink.OnSubscribe(s.subscription())
+	})
+}
@@ -0,0 +1,65 @@
+package flux_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nanobus/iota/go/rx/flux"
+)
+
+func TestCreateOverflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		overflow flux.Overflow
+		items    []int
+		err      error
+	}{
+		{"buffer", flux.OverflowBuffer(0), []int{1, 2, 3, 4}, nil},
+		{"bounded buffer", flux.OverflowBuffer(2), nil, flux.ErrOverflow},
+		{"drop latest", flux.OverflowDropLatest(2), []int{1, 2}, nil},
+		{"drop oldest", flux.OverflowDropOldest(2), []int{3, 4}, nil},
+		{"latest", flux.OverflowLatest, []int{4}, nil},
+		{"error", flux.OverflowError, nil, flux.ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sink flux.Sink[int]
+			cancelled := false
+			// The source ignores requests.
+			f := flux.Create(func(s flux.Sink[int]) {
+				sink = s
+				s.OnSubscribe(flux.OnSubscribe{
+					Request: func(int) {},
+					Cancel:  func() { cancelled = true },
+				})
+			}, tt.overflow)
+			r := record(f, 0)
+			for i := 1; i <= 4; i++ {
+				sink.Next(i)
+			}
+			sink.Complete()
+
+			f.Subscription().Request(5)
+			r.eventually(t, tt.items, tt.err == nil, tt.err)
+			assert.Equal(t, tt.err != nil, cancelled)
+		})
+	}
+}
+
+func TestOnBackpressureBuffer(t *testing.T) {
+	var h hot
+	f := flux.OnBackpressureBuffer(h.flux(), flux.OverflowBuffer(0))
+	r := record(f, 1)
+	h.waitRequested(t)
+	h.push(1, 2, 3)
+	r.eventually(t, []int{1}, false, nil)
+	f.Subscription().Request(2)
+	r.eventually(t, []int{1, 2, 3}, false, nil)
+
+	// Requests are passed on rather than requesting all items.
+	var src source
+	assert.Equal(t, []int{0, 1, 2}, request(t, flux.OnBackpressureBuffer(src.flux(100), flux.OverflowError), 3))
+	requested, _ := src.state()
+	assert.Equal(t, 3, requested)
+}
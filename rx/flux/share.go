@@ -0,0 +1,119 @@
+package flux
+
+import (
+	"github.com/nanobus/iota/go/rx"
+)
+
+// Connectable is a flux that subscribes to its source once Connect is
+// called.
+type Connectable[T any] interface {
+	Flux[T]
+	// Connect subscribes to the source, unless it did before, and
+	// returns a function that cancels the subscription.
+	Connect() rx.FnCancel
+}
+
+// Publish returns a flux that passes the items of f to all of its
+// subscribers once it is connected. Items that arrive before a
+// subscriber subscribes are not passed to it. Items are buffered until
+// each subscriber requests them.
+func Publish[T any](f Flux[T]) Connectable[T] {
+	return newPublisher(f)
+}
+
+type publisher[T any] struct {
+	*multicast[T]
+	source Flux[T]
+
+	mu        mutex
+	connected bool
+	up        upstream
+	// subscribers counts the subscribers of Share.
+	subscribers int
+}
+
+func newPublisher[T any](f Flux[T]) *publisher[T] {
+	return &publisher[T]{
+		multicast: newMulticast[T](OverflowBuffer(0), 0),
+		source:    f,
+	}
+}
+
+func (p *publisher[T]) Connect() rx.FnCancel {
+	p.mu.Lock()
+	connected := p.connected
+	p.connected = true
+	p.mu.Unlock()
+	if !connected {
+		subscribe(&p.up, p.source, Subscribe[T]{
+			OnNext:     p.multicast.Next,
+			OnComplete: p.multicast.Complete,
+			OnError:    p.multicast.Error,
+		})
+		p.up.Request(rx.RequestMax)
+	}
+	return p.up.Cancel
+}
+
+// Share returns a flux that subscribes to f when its first subscriber
+// subscribes and passes the items of f to all of its subscribers. The
+// subscription to f is cancelled once all subscribers cancelled.
+// Subscribers that subscribe after that, or after f completed, cause a
+// new subscription to f.
+func Share[T any](f Flux[T]) Flux[T] {
+	s := share[T]{source: f}
+	return Create(s.subscribe)
+}
+
+type share[T any] struct {
+	mu     mutex
+	source Flux[T]
+	conn   *publisher[T]
+}
+
+func (s *share[T]) subscribe(sink Sink[T]) {
+	s.mu.Lock()
+	conn := s.conn
+	if conn == nil || conn.isDone() {
+		conn = newPublisher(s.source)
+		s.conn = conn
+	}
+	conn.subscribers++
+	s.mu.Unlock()
+	conn.subscribe(sharedSink[T]{
+		Sink:    sink,
+		release: func() { s.release(conn) },
+	})
+	conn.Connect()
+}
+
+// release cancels the subscription of conn once it has no subscribers.
+func (s *share[T]) release(conn *publisher[T]) {
+	s.mu.Lock()
+	conn.subscribers--
+	disconnect := conn.subscribers == 0 && s.conn == conn
+	if disconnect {
+		s.conn = nil
+	}
+	s.mu.Unlock()
+	if disconnect {
+		conn.up.Cancel()
+	}
+}
+
+// sharedSink releases the connection of Share when its subscriber
+// cancels.
+type sharedSink[T any] struct {
+	Sink[T]
+	release func()
+}
+
+func (s sharedSink[T]) OnSubscribe(sub OnSubscribe) {
+	s.Sink.OnSubscribe(OnSubscribe{
+		Request: sub.Request,
+		Cancel: func() {
+			sub.Cancel()
+			s.release()
+		},
+	})
+}
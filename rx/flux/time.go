@@ -0,0 +1,554 @@
+package flux
+
+import (
+	"time"
+
+	"github.com/nanobus/iota/go/rx"
+)
+
+// Timeout fails with rx.ErrTimeout and cancels f if no item arrives
+// within timeout of the subscription or of the previous item.
+func Timeout[T any](f Flux[T], timeout time.Duration, s rx.Scheduler) Flux[T] {
+	return Create(func(sink Sink[T]) {
+		up := new(upstream)
+		var mu mutex
+		var cancel rx.FnCancel
+		done := false
+		gen := 0
+		// arm restarts the timer. It is called with mu held.
+		arm := func() {
+			if cancel != nil {
+				cancel()
+			}
+			gen++
+			g := gen
+			cancel = s.Schedule(timeout, func() {
+				mu.Lock()
+				if done || g != gen {
+					mu.Unlock()
+					return
+				}
+				done = true
+				mu.Unlock()
+				up.Cancel()
+				sink.Error(rx.ErrTimeout)
+			})
+		}
+		finish := func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			if done {
+				return false
+			}
+			done = true
+			if cancel != nil {
+				cancel()
+			}
+			return true
+		}
+		subscribe(up, f, Subscribe[T]{
+			OnNext: func(value T) {
+				mu.Lock()
+				if done {
+					mu.Unlock()
+					return
+				}
+				arm()
+				mu.Unlock()
+				sink.Next(value)
+			},
+			OnComplete: func() {
+				if finish() {
+					sink.Complete()
+				}
+			},
+			OnError: func(err error) {
+				if finish() {
+					sink.Error(err)
+				}
+			},
+		})
+		mu.Lock()
+		if !done {
+			arm()
+		}
+		mu.Unlock()
+		sink.OnSubscribe(OnSubscribe{
+			Request: up.Request,
+			Cancel: func() {
+				finish()
+				up.Cancel()
+			},
+		})
+	})
+}
+
+// Delay emits each item of f once delay has passed after it arrived,
+// and completes once delay has passed after f completes. Errors are not
+// delayed.
+func Delay[T any](f Flux[T], delay time.Duration, s rx.Scheduler) Flux[T] {
+	return Create(func(sink Sink[T]) {
+		d := delayer[T]{sink: sink}
+		subscribe(&d.up, f, Subscribe[T]{
+			OnNext: func(value T) {
+				d.mu.Lock()
+				d.queue = append(d.queue, value)
+				d.mu.Unlock()
+				s.Schedule(delay, func() {
+					d.mu.Lock()
+					d.due++
+					d.mu.Unlock()
+					d.drain()
+				})
+			},
+			OnComplete: func() {
+				s.Schedule(delay, func() {
+					d.mu.Lock()
+					d.completed = true
+					d.mu.Unlock()
+					d.drain()
+				})
+			},
+			OnError: func(err error) {
+				d.mu.Lock()
+				d.err = err
+				d.mu.Unlock()
+				d.drain()
+			},
+		})
+		sink.OnSubscribe(OnSubscribe{
+			Request: d.up.Request,
+			Cancel: func() {
+				d.mu.Lock()
+				d.done = true
+				d.queue = nil
+				d.mu.Unlock()
+				d.up.Cancel()
+			},
+		})
+	})
+}
+
+// delayer queues the items of Delay until they are due.
+type delayer[T any] struct {
+	mu        mutex
+	sink      Sink[T]
+	up        upstream
+	queue     []T
+	due       int
+	completed bool
+	err       error
+	done      bool
+	draining  bool
+}
+
+// drain emits the items that are due, in order. Timers that fire out
+// of order still emit the oldest item first. Only one caller emits at
+// a time.
+func (d *delayer[T]) drain() {
+	d.mu.Lock()
+	if d.draining {
+		d.mu.Unlock()
+		return
+	}
+	d.draining = true
+	for !d.done {
+		if d.err != nil {
+			err := d.err
+			d.done = true
+			d.queue = nil
+			d.mu.Unlock()
+			d.sink.Error(err)
+			d.mu.Lock()
+			break
+		}
+		if d.due > 0 && len(d.queue) > 0 {
+			var zero T
+			value := d.queue[0]
+			d.queue[0] = zero
+			d.queue = d.queue[1:]
+			d.due--
+			d.mu.Unlock()
+			d.sink.Next(value)
+			d.mu.Lock()
+			continue
+		}
+		if d.completed && len(d.queue) == 0 {
+			d.done = true
+			d.mu.Unlock()
+			d.sink.Complete()
+			d.mu.Lock()
+		}
+		break
+	}
+	d.draining = false
+	d.mu.Unlock()
+}
+
+// Interval emits 0, 1, 2, ... with period between items. A tick that
+// is not requested waits for a request instead of being dropped, and
+// the next tick is scheduled once it is emitted.
+func Interval(period time.Duration, s rx.Scheduler) Flux[int] {
+	return Create(func(sink Sink[int]) {
+		var mu mutex
+		var cancel rx.FnCancel
+		requested := 0
+		next := 0
+		waiting := false
+		done := false
+		var tick func()
+		tick = func() {
+			mu.Lock()
+			if done {
+				mu.Unlock()
+				return
+			}
+			if requested == 0 {
+				waiting = true
+				mu.Unlock()
+				return
+			}
+			if requested != rx.RequestMax {
+				requested--
+			}
+			n := next
+			next++
+			mu.Unlock()
+			sink.Next(n)
+			mu.Lock()
+			if !done {
+				cancel = s.Schedule(period, tick)
+			}
+			mu.Unlock()
+		}
+		mu.Lock()
+		cancel = s.Schedule(period, tick)
+		mu.Unlock()
+		sink.OnSubscribe(OnSubscribe{
+			Request: func(n int) {
+				mu.Lock()
+				requested = addRequest(requested, n)
+				fire := waiting
+				waiting = false
+				mu.Unlock()
+				if fire {
+					tick()
+				}
+			},
+			Cancel: func() {
+				mu.Lock()
+				done = true
+				c := cancel
+				mu.Unlock()
+				c()
+			},
+		})
+	})
+}
+
+// Debounce emits an item of f once quiet has passed without another
+// item. The pending item is emitted when f completes. All items of f
+// are requested, and items that are not requested when they are due
+// are dropped.
+func Debounce[T any](f Flux[T], quiet time.Duration, s rx.Scheduler) Flux[T] {
+	return Create(func(sink Sink[T]) {
+		l := latest[T]{sink: sink}
+		var cancel rx.FnCancel
+		gen := 0
+		subscribe(&l.up, f, Subscribe[T]{
+			OnNext: func(value T) {
+				l.mu.Lock()
+				if l.done {
+					l.mu.Unlock()
+					return
+				}
+				l.value, l.has = value, true
+				if cancel != nil {
+					cancel()
+				}
+				gen++
+				g := gen
+				cancel = s.Schedule(quiet, func() {
+					l.mu.Lock()
+					current := g == gen
+					l.mu.Unlock()
+					if current {
+						l.emit()
+					}
+				})
+				l.mu.Unlock()
+			},
+			OnComplete: func() {
+				l.mu.Lock()
+				if cancel != nil {
+					cancel()
+				}
+				l.mu.Unlock()
+				l.complete()
+			},
+			OnError: l.fail,
+		})
+		l.relay(nil)
+	})
+}
+
+// Sample emits the latest item of f every period if it arrived since
+// the previous sample. The latest item is emitted when f completes.
+// All items of f are requested, and samples that are not requested are
+// dropped.
+func Sample[T any](f Flux[T], period time.Duration, s rx.Scheduler) Flux[T] {
+	return Create(func(sink Sink[T]) {
+		l := latest[T]{sink: sink}
+		var cancel rx.FnCancel
+		var tick func()
+		tick = func() {
+			l.emit()
+			l.mu.Lock()
+			if !l.done {
+				cancel = s.Schedule(period, tick)
+			}
+			l.mu.Unlock()
+		}
+		subscribe(&l.up, f, Subscribe[T]{
+			OnNext: func(value T) {
+				l.mu.Lock()
+				if !l.done {
+					l.value, l.has = value, true
+				}
+				l.mu.Unlock()
+			},
+			OnComplete: l.complete,
+			OnError:    l.fail,
+		})
+		l.mu.Lock()
+		if !l.done {
+			cancel = s.Schedule(period, tick)
+		}
+		l.mu.Unlock()
+		l.relay(func() {
+			l.mu.Lock()
+			c := cancel
+			l.mu.Unlock()
+			if c != nil {
+				c()
+			}
+		})
+	})
+}
+
+// latest holds the most recent item of Debounce and Sample until it is
+// emitted. Signals are emitted under emitting so that timers and f do
+// not emit at the same time.
+type latest[T any] struct {
+	mu        mutex
+	emitting  mutex
+	sink      Sink[T]
+	up        upstream
+	value     T
+	has       bool
+	requested int
+	started   bool
+	done      bool
+}
+
+// emit emits the latest item if it is requested and drops it otherwise.
+func (l *latest[T]) emit() {
+	l.emitting.Lock()
+	defer l.emitting.Unlock()
+	l.mu.Lock()
+	if l.done || !l.has {
+		l.mu.Unlock()
+		return
+	}
+	value := l.value
+	var zero T
+	l.value, l.has = zero, false
+	if l.requested == 0 {
+		l.mu.Unlock()
+		return
+	}
+	if l.requested != rx.RequestMax {
+		l.requested--
+	}
+	l.mu.Unlock()
+	l.sink.Next(value)
+}
+
+func (l *latest[T]) complete() {
+	l.emit()
+	l.emitting.Lock()
+	defer l.emitting.Unlock()
+	l.mu.Lock()
+	if l.done {
+		l.mu.Unlock()
+		return
+	}
+	l.done = true
+	l.mu.Unlock()
+	l.sink.Complete()
+}
+
+func (l *latest[T]) fail(err error) {
+	l.emitting.Lock()
+	defer l.emitting.Unlock()
+	l.mu.Lock()
+	if l.done {
+		l.mu.Unlock()
+		return
+	}
+	l.done = true
+	l.mu.Unlock()
+	l.sink.Error(err)
+}
+
+// relay requests all items of f on the first request and counts the
+// requested items. stop is called on cancellation, if not nil.
+func (l *latest[T]) relay(stop func()) {
+	l.sink.OnSubscribe(OnSubscribe{
+		Request: func(n int) {
+			l.mu.Lock()
+			l.requested = addRequest(l.requested, n)
+			first := !l.started
+			l.started = true
+			l.mu.Unlock()
+			if first {
+				l.up.Request(rx.RequestMax)
+			}
+		},
+		Cancel: func() {
+			l.mu.Lock()
+			l.done = true
+			l.mu.Unlock()
+			if stop != nil {
+				stop()
+			}
+			l.up.Cancel()
+		},
+	})
+}
+
+// Retry subscribes to f again when it fails, up to retries times, and
+// requests the items that were requested but not emitted. It fails
+// with the last error.
+func Retry[T any](f Flux[T], retries int) Flux[T] {
+	return retry(f, retries, nil)
+}
+
+// RetryWithBackoff subscribes to f again when it fails, up to retries
+// times, after a delay that starts at first and doubles with each
+// attempt up to max. It fails with the last error.
+func RetryWithBackoff[T any](f Flux[T], retries int, first, max time.Duration, s rx.Scheduler) Flux[T] {
+	return retry(f, retries, func(attempt int, resubscribe func()) {
+		s.Schedule(rx.Backoff(attempt, first, max), resubscribe)
+	})
+}
+
+func retry[T any](f Flux[T], retries int, wait func(attempt int, resubscribe func())) Flux[T] {
+	return Create(func(sink Sink[T]) {
+		r := retrier[T]{
+			f:       f,
+			sink:    sink,
+			retries: retries,
+			wait:    wait,
+		}
+		r.resubscribe()
+		sink.OnSubscribe(OnSubscribe{
+			Request: r.request,
+			Cancel:  r.cancel,
+		})
+	})
+}
+
+// retrier subscribes to the flux of Retry until it does not fail.
+type retrier[T any] struct {
+	mu      mutex
+	f       Flux[T]
+	sink    Sink[T]
+	retries int
+	wait    func(attempt int, resubscribe func())
+
+	attempt int
+	up      *upstream
+	// outstanding is the number of items requested but not emitted.
+	outstanding int
+	done        bool
+}
+
+func (r *retrier[T]) resubscribe() {
+	r.mu.Lock()
+	attempt := r.attempt
+	r.mu.Unlock()
+	up := new(upstream)
+	subscribe(up, r.f, Subscribe[T]{
+		OnNext:     r.onNext,
+		OnComplete: r.sink.Complete,
+		OnError:    r.onError,
+	})
+	r.mu.Lock()
+	if r.attempt != attempt {
+		// f failed while subscribing and was retried already.
+		r.mu.Unlock()
+		return
+	}
+	if r.done {
+		r.mu.Unlock()
+		up.Cancel()
+		return
+	}
+	r.up = up
+	n := r.outstanding
+	r.mu.Unlock()
+	up.Request(n)
+}
+
+func (r *retrier[T]) request(n int) {
+	r.mu.Lock()
+	r.outstanding = addRequest(r.outstanding, n)
+	up := r.up
+	r.mu.Unlock()
+	if up != nil {
+		up.Request(n)
+	}
+}
+
+func (r *retrier[T]) cancel() {
+	r.mu.Lock()
+	r.done = true
+	up := r.up
+	r.mu.Unlock()
+	if up != nil {
+		up.Cancel()
+	}
+}
+
+func (r *retrier[T]) onNext(value T) {
+	r.mu.Lock()
+	if r.outstanding != rx.RequestMax {
+		r.outstanding--
+	}
+	r.mu.Unlock()
+	r.sink.Next(value)
+}
+
+func (r *retrier[T]) onError(err error) {
+	r.mu.Lock()
+	if r.done {
+		r.mu.Unlock()
+		return
+	}
+	if r.attempt >= r.retries {
+		r.done = true
+		r.mu.Unlock()
+		r.sink.Error(err)
+		return
+	}
+	r.attempt++
+	attempt := r.attempt
+	r.up = nil
+	r.mu.Unlock()
+	if r.wait == nil {
+		r.resubscribe()
+	} else {
+		r.wait(attempt, r.resubscribe)
+	}
+}
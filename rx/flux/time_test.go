@@ -0,0 +1,264 @@
+package flux_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/rxtest"
+)
+
+// recorder records the signals of a flux.
+type recorder[T any] struct {
+	mu        sync.Mutex
+	items     []T
+	err       error
+	completed bool
+	f         flux.Flux[T]
+}
+
+// record subscribes to f and requests n items.
+func record[T any](f flux.Flux[T], n int) *recorder[T] {
+	r := &recorder[T]{f: f}
+	f.Subscribe(flux.Subscribe[T]{
+		OnNext: func(value T) {
+			r.mu.Lock()
+			r.items = append(r.items, value)
+			r.mu.Unlock()
+		},
+		OnComplete: func() {
+			r.mu.Lock()
+			r.completed = true
+			r.mu.Unlock()
+		},
+		OnError: func(err error) {
+			r.mu.Lock()
+			r.err = err
+			r.mu.Unlock()
+		},
+		NoRequest: true,
+	})
+	f.Subscription().Request(n)
+	return r
+}
+
+func (r *recorder[T]) state() ([]T, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]T(nil), r.items...), r.completed, r.err
+}
+
+// eventually asserts that the recorder reaches the given state.
+func (r *recorder[T]) eventually(t *testing.T, items []T, completed bool, err error) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		got, c, e := r.state()
+		return assert.ObjectsAreEqual(items, got) && c == completed && e == err
+	}, time.Second, time.Millisecond)
+}
+
+// hot is a flux that emits the items that are pushed to it.
+type hot struct {
+	mu        sync.Mutex
+	sink      flux.Sink[int]
+	requested bool
+	cancelled bool
+}
+
+func (h *hot) flux() flux.Flux[int] {
+	return flux.Create(func(sink flux.Sink[int]) {
+		h.mu.Lock()
+		h.sink = sink
+		h.mu.Unlock()
+		sink.OnSubscribe(flux.OnSubscribe{
+			Request: func(int) {
+				h.mu.Lock()
+				h.requested = true
+				h.mu.Unlock()
+			},
+			Cancel: func() {
+				h.mu.Lock()
+				h.cancelled = true
+				h.mu.Unlock()
+			},
+		})
+	})
+}
+
+// waitRequested waits until items were requested.
+func (h *hot) waitRequested(t *testing.T) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return h.requested
+	}, time.Second, time.Millisecond)
+}
+
+func (h *hot) push(values ...int) {
+	for _, value := range values {
+		h.sink.Next(value)
+	}
+}
+
+func (h *hot) isCancelled() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.cancelled
+}
+
+func TestTimeoutFlux(t *testing.T) {
+	s := rxtest.NewVirtualScheduler()
+	var h hot
+	r := record(flux.Timeout(h.flux(), time.Second, s), rx.RequestMax)
+	h.waitRequested(t)
+	s.Advance(900 * time.Millisecond)
+	h.push(1)
+	// The timer restarts with each item.
+	s.Advance(900 * time.Millisecond)
+	h.push(2)
+	r.eventually(t, []int{1, 2}, false, nil)
+	s.Advance(time.Second)
+	r.eventually(t, []int{1, 2}, false, rx.ErrTimeout)
+	assert.True(t, h.isCancelled())
+
+	r = record(flux.Timeout(ints(1, 2), time.Second, s), rx.RequestMax)
+	r.eventually(t, []int{1, 2}, true, nil)
+	assert.Zero(t, s.Pending())
+}
+
+func TestDelayFlux(t *testing.T) {
+	s := rxtest.NewVirtualScheduler()
+	var h hot
+	r := record(flux.Delay(h.flux(), time.Second, s), rx.RequestMax)
+	h.waitRequested(t)
+	h.push(1)
+	s.Advance(500 * time.Millisecond)
+	h.push(2)
+	h.sink.Complete()
+	s.Advance(500 * time.Millisecond)
+	r.eventually(t, []int{1}, false, nil)
+	s.Advance(500 * time.Millisecond)
+	r.eventually(t, []int{1, 2}, true, nil)
+
+	// Errors are not delayed.
+	boom := errors.New("boom")
+	r = record(flux.Delay(flux.Error[int](boom), time.Second, s), rx.RequestMax)
+	r.eventually(t, nil, false, boom)
+}
+
+func TestInterval(t *testing.T) {
+	s := rxtest.NewVirtualScheduler()
+	r := record(flux.Interval(time.Second, s), 2)
+	s.Advance(time.Second)
+	r.eventually(t, []int{0}, false, nil)
+	s.Advance(time.Second)
+	r.eventually(t, []int{0, 1}, false, nil)
+
+	// The next tick waits for a request.
+	s.Advance(time.Second)
+	r.eventually(t, []int{0, 1}, false, nil)
+	r.f.Subscription().Request(1)
+	r.eventually(t, []int{0, 1, 2}, false, nil)
+
+	r.f.Subscription().Cancel()
+	assert.Eventually(t, func() bool { return s.Pending() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestDebounce(t *testing.T) {
+	s := rxtest.NewVirtualScheduler()
+	var h hot
+	r := record(flux.Debounce(h.flux(), time.Second, s), rx.RequestMax)
+	h.waitRequested(t)
+	h.push(1, 2)
+	s.Advance(500 * time.Millisecond)
+	h.push(3)
+	s.Advance(500 * time.Millisecond)
+	r.eventually(t, nil, false, nil)
+	s.Advance(500 * time.Millisecond)
+	r.eventually(t, []int{3}, false, nil)
+	h.push(4)
+	h.sink.Complete()
+	r.eventually(t, []int{3, 4}, true, nil)
+
+	assert.Equal(t, []int{3}, collect(t, flux.Debounce(ints(1, 2, 3), time.Second, s)))
+}
+
+func TestSample(t *testing.T) {
+	s := rxtest.NewVirtualScheduler()
+	var h hot
+	r := record(flux.Sample(h.flux(), time.Second, s), rx.RequestMax)
+	h.waitRequested(t)
+	h.push(1, 2)
+	s.Advance(time.Second)
+	r.eventually(t, []int{2}, false, nil)
+	// Nothing arrived since the previous sample.
+	s.Advance(time.Second)
+	r.eventually(t, []int{2}, false, nil)
+	h.push(3)
+	h.sink.Complete()
+	r.eventually(t, []int{2, 3}, true, nil)
+}
+
+var errFlaky = errors.New("flaky")
+
+// flakyFlux emits 0, 1, 2 and fails on the first fails subscriptions
+// after emitting the items.
+func flakyFlux(fails int, subscriptions *int) flux.Flux[int] {
+	return flux.Create(func(sink flux.Sink[int]) {
+		*subscriptions++
+		attempt := *subscriptions
+		next := 0
+		sink.OnSubscribe(flux.OnSubscribe{
+			Request: func(n int) {
+				for ; n > 0 && next < 3; n-- {
+					sink.Next(next)
+					next++
+				}
+				if next == 3 {
+					if attempt <= fails {
+						sink.Error(errFlaky)
+					} else {
+						sink.Complete()
+					}
+				}
+			},
+			Cancel: func() {},
+		})
+	})
+}
+
+func TestRetryFlux(t *testing.T) {
+	var subscriptions int
+	assert.Equal(t, []int{0, 1, 2, 0, 1, 2, 0, 1, 2}, collect(t, flux.Retry(flakyFlux(2, &subscriptions), 2)))
+
+	subscriptions = 0
+	r := record(flux.Retry(flakyFlux(5, &subscriptions), 1), rx.RequestMax)
+	r.eventually(t, []int{0, 1, 2, 0, 1, 2}, false, errFlaky)
+}
+
+func TestRetryFluxRequests(t *testing.T) {
+	// Items that were requested but not emitted are requested again.
+	var subscriptions int
+	assert.Equal(t, []int{0, 1, 2, 0}, request(t, flux.Retry(flakyFlux(1, &subscriptions), 1), 4))
+}
+
+func TestRetryWithBackoffFlux(t *testing.T) {
+	s := rxtest.NewVirtualScheduler()
+	var subscriptions int
+	r := record(flux.RetryWithBackoff(flakyFlux(2, &subscriptions), 3, 100*time.Millisecond, time.Second, s), rx.RequestMax)
+	r.eventually(t, []int{0, 1, 2}, false, nil)
+	assert.Eventually(t, func() bool { return s.Pending() == 1 }, time.Second, time.Millisecond)
+	s.Advance(100 * time.Millisecond)
+	r.eventually(t, []int{0, 1, 2, 0, 1, 2}, false, nil)
+	assert.Eventually(t, func() bool { return s.Pending() == 1 }, time.Second, time.Millisecond)
+	s.Advance(199 * time.Millisecond)
+	r.eventually(t, []int{0, 1, 2, 0, 1, 2}, false, nil)
+	s.Advance(time.Millisecond)
+	r.eventually(t, []int{0, 1, 2, 0, 1, 2, 0, 1, 2}, true, nil)
+}
@@ -0,0 +1,102 @@
+package flux
+
+import (
+	"github.com/nanobus/iota/go/rx"
+)
+
+// upstream is the subscription of an operator to its source.
+// Requests made while another request is being made, such as from
+// OnNext when a source emits items synchronously, are made once it
+// returns instead of recursing.
+type upstream struct {
+	mu        mutex
+	sub       rx.Subscription
+	pending   int
+	active    bool
+	unbounded bool
+}
+
+// subscribe subscribes to f without requesting items. Requests and
+// cancellation are passed to f through up.
+func subscribe[T any](up *upstream, f Flux[T], sub Subscribe[T]) {
+	sub.NoRequest = true
+	f.Subscribe(sub)
+	up.mu.Lock()
+	up.sub = f.Subscription()
+	up.mu.Unlock()
+}
+
+func (u *upstream) Request(n int) {
+	if n <= 0 {
+		return
+	}
+	u.mu.Lock()
+	if u.sub == nil {
+		u.mu.Unlock()
+		return
+	}
+	u.pending = addRequest(u.pending, n)
+	if u.pending == rx.RequestMax {
+		u.unbounded = true
+	}
+	if u.active {
+		u.mu.Unlock()
+		return
+	}
+	u.active = true
+	for u.pending > 0 {
+		n := u.pending
+		u.pending = 0
+		u.mu.Unlock()
+		u.sub.Request(n)
+		u.mu.Lock()
+	}
+	u.active = false
+	u.mu.Unlock()
+}
+
+// replenish requests an item in place of one that was dropped,
+// unless an unbounded number of items was requested.
+func (u *upstream) replenish() {
+	u.mu.Lock()
+	unbounded := u.unbounded
+	u.mu.Unlock()
+	if !unbounded {
+		u.Request(1)
+	}
+}
+
+func (u *upstream) Cancel() {
+	u.mu.Lock()
+	sub := u.sub
+	u.mu.Unlock()
+	if sub != nil {
+		sub.Cancel()
+	}
+}
+
+// relay passes the requests and cancellation of the subscriber of
+// sink to up.
+func relay[T any](sink Sink[T], up *upstream) {
+	sink.OnSubscribe(OnSubscribe{
+		Request: up.Request,
+		Cancel:  up.Cancel,
+	})
+}
+
+// addRequest adds two requests. RequestMax stands for
+// an unbounded number of items.
+func addRequest(a, b int) int {
+	if b > rx.RequestMax-a {
+		return rx.RequestMax
+	}
+	return a + b
+}
+
+// mulRequest multiplies a request for n groups of size items.
+func mulRequest(n, size int) int {
+	if n > rx.RequestMax/size {
+		return rx.RequestMax
+	}
+	return n * size
+}
@@ -0,0 +1,223 @@
+package flux
+
+import (
+	"github.com/nanobus/iota/go/rx"
+)
+
+// Zip emits slices with the next item of each source. It completes once
+// a source completes and its items were emitted, and cancels the other
+// sources. A request for n slices requests up to n items from each
+// source, at most prefetch at a time.
+func Zip[T any](sources ...Flux[T]) Flux[[]T] {
+	if len(sources) == 0 {
+		return FromSlice[[]T](nil)
+	}
+	return Create(func(sink Sink[[]T]) {
+		z := zipper[T]{
+			sink:        sink,
+			ups:         make([]upstream, len(sources)),
+			queues:      make([][]T, len(sources)),
+			outstanding: make([]int, len(sources)),
+			done:        make([]bool, len(sources)),
+		}
+		for i, source := range sources {
+			i := i
+			subscribe(&z.ups[i], source, Subscribe[T]{
+				OnNext: func(value T) {
+					z.mu.Lock()
+					if !z.finished {
+						z.queues[i] = append(z.queues[i], value)
+						if z.outstanding[i] > 0 {
+							z.outstanding[i]--
+						}
+					}
+					z.mu.Unlock()
+					z.drain()
+				},
+				OnComplete: func() {
+					z.mu.Lock()
+					z.done[i] = true
+					z.mu.Unlock()
+					z.drain()
+				},
+				OnError: func(err error) {
+					z.fail(i, err)
+				},
+			})
+		}
+		sink.OnSubscribe(OnSubscribe{
+			Request: z.request,
+			Cancel:  z.cancel,
+		})
+	})
+}
+
+// ZipWith combines the next items of a and b with combiner.
+func ZipWith[A, B, R any](a Flux[A], b Flux[B], combiner func(A, B) R) Flux[R] {
+	return Map(Zip(toAny(a), toAny(b)), func(values []any) (R, error) {
+		return combiner(values[0].(A), values[1].(B)), nil
+	})
+}
+
+func toAny[T any](f Flux[T]) Flux[any] {
+	return Map(f, func(value T) (any, error) {
+		return value, nil
+	})
+}
+
+// zipper subscribes to the sources of Zip and combines their items.
+type zipper[T any] struct {
+	mu     mutex
+	sink   Sink[[]T]
+	ups    []upstream
+	queues [][]T
+	// outstanding counts the items requested from each source that
+	// have not arrived.
+	outstanding []int
+	done        []bool
+	requested   int
+	finished    bool
+	draining    bool
+}
+
+func (z *zipper[T]) request(n int) {
+	z.mu.Lock()
+	if z.finished {
+		z.mu.Unlock()
+		return
+	}
+	z.requested = addRequest(z.requested, n)
+	z.mu.Unlock()
+	z.replenish()
+	z.drain()
+}
+
+// replenish requests items from each source until it has as many
+// requested or queued as slices are requested, up to prefetch.
+func (z *zipper[T]) replenish() {
+	z.mu.Lock()
+	if z.finished {
+		z.mu.Unlock()
+		return
+	}
+	limit := z.requested
+	if limit > prefetch {
+		limit = prefetch
+	}
+	needs := make([]int, len(z.ups))
+	for i := range z.ups {
+		if z.done[i] {
+			continue
+		}
+		needs[i] = limit - z.outstanding[i] - len(z.queues[i])
+		if needs[i] > 0 {
+			z.outstanding[i] += needs[i]
+		}
+	}
+	z.mu.Unlock()
+	for i, n := range needs {
+		if n > 0 {
+			z.ups[i].Request(n)
+		}
+	}
+}
+
+func (z *zipper[T]) cancel() {
+	z.mu.Lock()
+	if z.finished {
+		z.mu.Unlock()
+		return
+	}
+	z.finished = true
+	z.mu.Unlock()
+	z.cancelActive()
+}
+
+// cancelActive cancels the sources that have not completed.
+func (z *zipper[T]) cancelActive() {
+	for i := range z.ups {
+		z.mu.Lock()
+		done := z.done[i]
+		z.done[i] = true
+		z.mu.Unlock()
+		if !done {
+			z.ups[i].Cancel()
+		}
+	}
+}
+
+func (z *zipper[T]) fail(i int, err error) {
+	z.mu.Lock()
+	if z.finished {
+		z.mu.Unlock()
+		return
+	}
+	z.finished = true
+	z.done[i] = true
+	z.queues = nil
+	z.mu.Unlock()
+	z.cancelActive()
+	z.sink.Error(err)
+}
+
+// ready returns true if each source has a queued item.
+// It is called with mu held.
+func (z *zipper[T]) ready() bool {
+	for _, q := range z.queues {
+		if len(q) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// exhausted returns true if a source completed without queued items.
+// It is called with mu held.
+func (z *zipper[T]) exhausted() bool {
+	for i, q := range z.queues {
+		if z.done[i] && len(q) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// drain emits slices while they are requested and completes once a
+// source is exhausted. Only one caller emits at a time.
+func (z *zipper[T]) drain() {
+	z.mu.Lock()
+	if z.draining {
+		z.mu.Unlock()
+		return
+	}
+	z.draining = true
+	emitted := false
+	for !z.finished && z.requested > 0 && z.ready() {
+		emitted = true
+		values := make([]T, len(z.queues))
+		for i, q := range z.queues {
+			var zero T
+			values[i] = q[0]
+			q[0] = zero
+			z.queues[i] = q[1:]
+		}
+		if z.requested != rx.RequestMax {
+			z.requested--
+		}
+		z.mu.Unlock()
+		z.sink.Next(values)
+		z.mu.Lock()
+	}
+	complete := !z.finished && z.exhausted()
+	if complete {
+		z.finished = true
+	}
+	z.draining = false
+	z.mu.Unlock()
+	if complete {
+		z.cancelActive()
+		z.sink.Complete()
+	} else if emitted {
+		z.replenish()
+	}
+}
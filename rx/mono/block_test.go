@@ -0,0 +1,80 @@
+package mono_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/mono"
+)
+
+func TestBlockContext(t *testing.T) {
+	v, err := later(1, time.Millisecond).BlockContext(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	boom := errors.New("boom")
+	_, err = mono.Map(failLater[int](boom, 0), func(v int) (int, error) {
+		return v, nil
+	}).BlockContext(context.Background())
+	assert.Equal(t, boom, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = pending[int]().BlockContext(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	_, err = mono.Just(1).BlockContext(ctx)
+	assert.NoError(t, err)
+}
+
+// slowSubscribe subscribes to a mono once release is closed.
+type slowSubscribe[T any] struct {
+	mono.Mono[T]
+	release chan struct{}
+}
+
+func (s slowSubscribe[T]) Subscribe(sub mono.Subscribe[T]) mono.Mono[T] {
+	<-s.release
+	return s.Mono.Subscribe(sub)
+}
+
+func TestBlockContextCancelsLateSubscription(t *testing.T) {
+	var cancelled atomic.Bool
+	m := slowSubscribe[int]{Mono: never[int](&cancelled), release: make(chan struct{})}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := mono.BlockContext[int](ctx, m)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// The subscription is cancelled once it is made.
+	close(m.release)
+	assert.Eventually(t, cancelled.Load, time.Second, time.Millisecond)
+}
+
+func TestSubscribeCancel(t *testing.T) {
+	var cancel rx.FnCancel
+	var signal rx.SignalType = -1
+	succeeded := false
+	var sink mono.Sink[int]
+	mono.Create(func(s mono.Sink[int]) {
+		sink = s
+	}).Subscribe(mono.Subscribe[int]{
+		OnSuccess: func(int) { succeeded = true },
+		OnRequest: func(c rx.FnCancel) { cancel = c },
+		Finally:   func(s rx.SignalType) { signal = s },
+	})
+	require.NotNil(t, cancel)
+	cancel()
+	assert.Equal(t, rx.SignalCancel, signal)
+
+	// The result is dropped once the subscription is cancelled.
+	sink.Success(1)
+	assert.False(t, succeeded)
+}
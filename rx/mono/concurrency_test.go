@@ -0,0 +1,74 @@
+package mono_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/mono"
+)
+
+func TestConcurrentSignals(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		var sink mono.Sink[int]
+		var cancel rx.FnCancel
+		var signals, finals atomic.Int32
+		mono.Create(func(s mono.Sink[int]) {
+			sink = s
+		}).Subscribe(mono.Subscribe[int]{
+			OnSuccess: func(int) { signals.Add(1) },
+			OnError:   func(error) { signals.Add(1) },
+			OnRequest: func(c rx.FnCancel) { cancel = c },
+			Finally:   func(rx.SignalType) { finals.Add(1) },
+		})
+
+		var wg sync.WaitGroup
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			sink.Success(1)
+		}()
+		go func() {
+			defer wg.Done()
+			sink.Error(errors.New("boom"))
+		}()
+		go func() {
+			defer wg.Done()
+			cancel()
+		}()
+		wg.Wait()
+
+		require.Equal(t, int32(1), finals.Load())
+		require.LessOrEqual(t, signals.Load(), int32(1))
+	}
+}
+
+func TestConcurrentProcessor(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		p := mono.NewProcessor[int]()
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			p.Success(i)
+		}()
+		var value atomic.Int64
+		go func() {
+			defer wg.Done()
+			p.Subscribe(mono.Subscribe[int]{
+				OnSuccess: func(v int) { value.Store(int64(v)) },
+			})
+		}()
+		wg.Wait()
+
+		v, err := p.Block()
+		require.NoError(t, err)
+		assert.Equal(t, i, v)
+		assert.Equal(t, int64(i), value.Load())
+	}
+}
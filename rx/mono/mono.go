@@ -1,6 +1,8 @@
 package mono
 
 import (
+	"context"
+
 	"github.com/nanobus/iota/go/rx"
 )
 
@@ -21,6 +23,8 @@ type Source[T any] func(Sink[T])
 type Subscribe[T any] struct {
 	OnSuccess rx.FnOnNext[T]
 	OnError   rx.FnOnError
+	// OnRequest receives a function that cancels the subscription
+	// before the mono completes.
 	OnRequest rx.FnOnCancel
 	Finally   rx.FnFinally
 }
@@ -50,6 +54,11 @@ func (e errorSingle[T]) Block() (T, error) {
 	return dummy, e.err
 }
 
+func (e errorSingle[T]) BlockContext(context.Context) (T, error) {
+	var dummy T
+	return dummy, e.err
+}
+
 func (e errorSingle[T]) Get() (T, error) {
 	var dummy T
 	return dummy, e.err
@@ -81,6 +90,10 @@ func (j justSingle[T]) Block() (T, error) {
 	return j.value, nil
 }
 
+func (j justSingle[T]) BlockContext(context.Context) (T, error) {
+	return j.value, nil
+}
+
 func (j justSingle[T]) Get() (T, error) {
 	return j.value, nil
 }
@@ -99,67 +112,106 @@ func Create[T any](source Source[T]) Mono[T] {
 }
 
 type mono[T any] struct {
+	mu         mutex
 	source     Source[T]
 	subscriber *subscriber[T]
 	callbacks  []rx.FnFinally
 }
 
 func (m *mono[T]) Subscribe(sub Subscribe[T]) Mono[T] {
-	wrapper := subscriber[T]{
+	wrapper := &subscriber[T]{
 		m:             m,
 		doOnSuccess:   sub.OnSuccess,
 		doOnError:     sub.OnError,
 		doOnSubscribe: sub.OnRequest,
 		doFinally:     sub.Finally,
 	}
-	m.subscriber = &wrapper
-	m.source(&wrapper)
+	m.mu.Lock()
+	m.subscriber = wrapper
+	m.mu.Unlock()
+	if wrapper.doOnSubscribe != nil {
+		wrapper.doOnSubscribe(wrapper.Cancel)
+	}
+	m.source(wrapper)
 	return m
 }
 
 func (m *mono[T]) Async() {
 }
 
+// Cancel cancels the last subscription of m, such as when its result is
+// no longer awaited.
+func (m *mono[T]) Cancel() {
+	m.mu.Lock()
+	s := m.subscriber
+	m.mu.Unlock()
+	if s != nil {
+		s.Cancel()
+	}
+}
+
 func (m *mono[T]) Notify(fn rx.FnFinally) {
 	// if m.subscriber == nil {
 	// 	wrapper := subscriber[T]{}
 	// 	m.subscriber = &wrapper
 	// 	m.source(&wrapper)
 	// }
-	if m.subscriber != nil && m.subscriber.closed {
-		fn(m.subscriber.signal)
-		return
+	m.mu.Lock()
+	if m.subscriber != nil {
+		if closed, signal, _, _ := m.subscriber.result(); closed {
+			m.mu.Unlock()
+			fn(signal)
+			return
+		}
 	}
-
 	m.callbacks = append(m.callbacks, fn)
+	m.mu.Unlock()
 }
 
 func (m *mono[T]) Get() (ret T, err error) {
-	if !m.subscriber.closed {
+	closed, _, value, err := m.result()
+	if !closed {
 		panic("mono: Get called before completion")
 	}
-	return m.subscriber.value, m.subscriber.err
+	return value, err
+}
+
+// result returns the result of the last subscription, if it is closed.
+func (m *mono[T]) result() (closed bool, signal rx.SignalType, value T, err error) {
+	m.mu.Lock()
+	s := m.subscriber
+	m.mu.Unlock()
+	if s == nil {
+		return false, signal, value, nil
+	}
+	return s.result()
 }
 
+// subscriber passes the result of a source to the callbacks of a
+// subscription. Sources may signal from any goroutine. Only the first
+// of Success, Error and Cancel is delivered.
 type subscriber[T any] struct {
+	m *mono[T]
+
+	mu     mutex
 	closed bool
 	value  T
 	err    error
 	signal rx.SignalType
-	m      *mono[T]
 
 	doOnSuccess   rx.FnOnNext[T]
 	doOnError     rx.FnOnError
 	doOnSubscribe rx.FnOnCancel
 	doFinally     rx.FnFinally
+
+	// upstream cancels what the source subscribed to, if set.
+	upstream rx.FnCancel
 }
 
 func (s *subscriber[T]) Success(value T) {
-	if s.closed {
+	if !s.close(rx.SignalComplete, value, nil) {
 		return
 	}
-	s.value = value
-	s.closed = true
 	if s.doOnSuccess != nil {
 		s.doOnSuccess(value)
 	}
@@ -167,35 +219,76 @@ func (s *subscriber[T]) Success(value T) {
 }
 
 func (s *subscriber[T]) Error(err error) {
-	if s.closed {
+	var zero T
+	if !s.close(rx.SignalError, zero, err) {
 		return
 	}
-	s.err = err
-	s.closed = true
 	if s.doOnError != nil {
 		s.doOnError(err)
 	}
 	s.finally(rx.SignalError)
 }
 
-func (s *subscriber[T]) finally(signal rx.SignalType) {
+// close records the result unless the subscriber is closed already.
+func (s *subscriber[T]) close(signal rx.SignalType, value T, err error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.closed = true
 	s.signal = signal
+	s.value = value
+	s.err = err
+	return true
+}
+
+func (s *subscriber[T]) result() (closed bool, signal rx.SignalType, value T, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed, s.signal, s.value, s.err
+}
+
+func (s *subscriber[T]) finally(signal rx.SignalType) {
 	if s.doFinally != nil {
 		s.doFinally(signal)
 	}
-	for _, fn := range s.m.callbacks {
+	s.m.mu.Lock()
+	callbacks := s.m.callbacks
+	s.m.mu.Unlock()
+	for _, fn := range callbacks {
 		fn(signal)
 	}
 }
 
 func (s *subscriber[T]) Cancel() {
+	s.mu.Lock()
 	if s.closed {
+		s.mu.Unlock()
 		return
 	}
 	s.closed = true
+	s.signal = rx.SignalCancel
+	upstream := s.upstream
+	s.mu.Unlock()
+	if upstream != nil {
+		upstream()
+	}
 	s.finally(rx.SignalCancel)
 }
 
+// setUpstream makes Cancel call cancel, or calls it now if the
+// subscription is cancelled already.
+func (s *subscriber[T]) setUpstream(cancel rx.FnCancel) {
+	s.mu.Lock()
+	s.upstream = cancel
+	cancelled := s.closed && s.signal == rx.SignalCancel
+	s.mu.Unlock()
+	if cancelled {
+		cancel()
+	}
+}
+
 func Map[S any | ~[]any, D any](mono Mono[S], tx rx.Transform[S, D]) Mono[D] {
 	return &mapper[S, D]{
 		mono: mono,
@@ -3,13 +3,27 @@
 
 package mono
 
+import (
+	"context"
+	"sync"
+
+	"github.com/nanobus/iota/go/rx"
+)
+
 type Blockable[T any] interface {
 	Block() (T, error)
+	// BlockContext is like Block but cancels the subscription and
+	// returns the error of ctx once ctx is done.
+	BlockContext(ctx context.Context) (T, error)
+}
+
+type mutex struct {
+	sync.Mutex
 }
 
 func (s *mono[T]) Block() (ret T, err error) {
-	if s.subscriber != nil && s.subscriber.closed {
-		return s.subscriber.value, s.subscriber.err
+	if closed, _, value, err := s.result(); closed {
+		return value, err
 	}
 
 	done := make(chan struct{})
@@ -30,6 +44,13 @@ func (s *mono[T]) Block() (ret T, err error) {
 	return ret, err
 }
 
+func (s *mono[T]) BlockContext(ctx context.Context) (T, error) {
+	if closed, _, value, err := s.result(); closed {
+		return value, err
+	}
+	return BlockContext[T](ctx, s)
+}
+
 func (b *mapper[S, D]) Block() (ret D, err error) {
 	done := make(chan struct{})
 	go func() {
@@ -48,3 +69,58 @@ func (b *mapper[S, D]) Block() (ret D, err error) {
 	<-done
 	return ret, err
 }
+
+func (b *mapper[S, D]) BlockContext(ctx context.Context) (D, error) {
+	return BlockContext[D](ctx, b)
+}
+
+// BlockContext subscribes to m and waits for its result. Once ctx is
+// done, it cancels the subscription and returns the error of ctx.
+func BlockContext[T any](ctx context.Context, m Mono[T]) (ret T, err error) {
+	if err := ctx.Err(); err != nil {
+		return ret, err
+	}
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	cancels := make(chan rx.FnCancel, 1)
+	go func() {
+		m.Subscribe(Subscribe[T]{
+			OnSuccess: func(value T) {
+				select {
+				case done <- result{value: value}:
+				default:
+				}
+			},
+			OnError: func(err error) {
+				select {
+				case done <- result{err: err}:
+				default:
+				}
+			},
+			OnRequest: func(cancel rx.FnCancel) {
+				select {
+				case cancels <- cancel:
+				default:
+				}
+			},
+		})
+		close(cancels)
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		// Subscribe may not have returned yet, so the subscription is
+		// cancelled once it has.
+		go func() {
+			if cancel, ok := <-cancels; ok {
+				cancel()
+			}
+		}()
+		return ret, ctx.Err()
+	}
+}
@@ -5,3 +5,8 @@ package mono
 
 type Blockable[T any] interface {
 }
+
+type mutex struct{}
+
+func (mutex) Lock()   {}
+func (mutex) Unlock() {}
@@ -0,0 +1,222 @@
+package mono
+
+import (
+	"github.com/nanobus/iota/go/rx"
+)
+
+// FlatMap maps the value of m to a mono and succeeds with its value.
+func FlatMap[T, R any](m Mono[T], mapper func(T) Mono[R]) Mono[R] {
+	return Create(func(sink Sink[R]) {
+		up := relay(sink)
+		m.Subscribe(Subscribe[T]{
+			OnSuccess: func(value T) {
+				forward(mapper(value), sink, up)
+			},
+			OnError:   sink.Error,
+			OnRequest: up.OnRequest,
+		})
+	})
+}
+
+// Then subscribes to next once m succeeds and succeeds with its value.
+// The value of m is discarded.
+func Then[T, R any](m Mono[T], next Mono[R]) Mono[R] {
+	return Create(func(sink Sink[R]) {
+		up := relay(sink)
+		m.Subscribe(Subscribe[T]{
+			OnSuccess: func(T) {
+				forward(next, sink, up)
+			},
+			OnError:   sink.Error,
+			OnRequest: up.OnRequest,
+		})
+	})
+}
+
+// OnErrorResume subscribes to the mono that fallback returns for the
+// error of m.
+func OnErrorResume[T any](m Mono[T], fallback func(error) Mono[T]) Mono[T] {
+	return Create(func(sink Sink[T]) {
+		up := relay(sink)
+		m.Subscribe(Subscribe[T]{
+			OnSuccess: sink.Success,
+			OnError: func(err error) {
+				forward(fallback(err), sink, up)
+			},
+			OnRequest: up.OnRequest,
+		})
+	})
+}
+
+// OnErrorReturn succeeds with value if m fails.
+func OnErrorReturn[T any](m Mono[T], value T) Mono[T] {
+	return Create(func(sink Sink[T]) {
+		up := relay(sink)
+		m.Subscribe(Subscribe[T]{
+			OnSuccess: sink.Success,
+			OnError: func(error) {
+				sink.Success(value)
+			},
+			OnRequest: up.OnRequest,
+		})
+	})
+}
+
+// DoOnSuccess calls fn with the value of m before passing it on.
+func DoOnSuccess[T any](m Mono[T], fn func(T)) Mono[T] {
+	return Create(func(sink Sink[T]) {
+		up := relay(sink)
+		m.Subscribe(Subscribe[T]{
+			OnSuccess: func(value T) {
+				fn(value)
+				sink.Success(value)
+			},
+			OnError:   sink.Error,
+			OnRequest: up.OnRequest,
+		})
+	})
+}
+
+// DoOnError calls fn with the error of m before passing it on.
+func DoOnError[T any](m Mono[T], fn func(error)) Mono[T] {
+	return Create(func(sink Sink[T]) {
+		up := relay(sink)
+		m.Subscribe(Subscribe[T]{
+			OnSuccess: sink.Success,
+			OnError: func(err error) {
+				fn(err)
+				sink.Error(err)
+			},
+			OnRequest: up.OnRequest,
+		})
+	})
+}
+
+// Defer calls supplier for each subscription and subscribes to the
+// mono it returns.
+func Defer[T any](supplier func() Mono[T]) Mono[T] {
+	return Create(func(sink Sink[T]) {
+		forward(supplier(), sink, relay(sink))
+	})
+}
+
+// Cache subscribes to m once, on the first subscription, and passes its
+// result to every subscriber, including those that subscribe after m
+// completed.
+func Cache[T any](m Mono[T]) Mono[T] {
+	c := cache[T]{source: m}
+	return Create(c.subscribe)
+}
+
+type cache[T any] struct {
+	mu         mutex
+	source     Mono[T]
+	subscribed bool
+	done       bool
+	value      T
+	err        error
+	sinks      []Sink[T]
+}
+
+func (c *cache[T]) subscribe(sink Sink[T]) {
+	c.mu.Lock()
+	if c.done {
+		c.mu.Unlock()
+		c.emit(sink)
+		return
+	}
+	c.sinks = append(c.sinks, sink)
+	first := !c.subscribed
+	c.subscribed = true
+	c.mu.Unlock()
+	if first {
+		c.source.Subscribe(Subscribe[T]{
+			OnSuccess: func(value T) {
+				c.complete(value, nil)
+			},
+			OnError: func(err error) {
+				var zero T
+				c.complete(zero, err)
+			},
+		})
+	}
+}
+
+func (c *cache[T]) complete(value T, err error) {
+	c.mu.Lock()
+	if c.done {
+		c.mu.Unlock()
+		return
+	}
+	c.done = true
+	c.value, c.err = value, err
+	sinks := c.sinks
+	c.sinks = nil
+	c.mu.Unlock()
+	for _, sink := range sinks {
+		c.emit(sink)
+	}
+}
+
+func (c *cache[T]) emit(sink Sink[T]) {
+	if c.err != nil {
+		sink.Error(c.err)
+	} else {
+		sink.Success(c.value)
+	}
+}
+
+// forward passes the result of m to sink and its cancellation to up.
+func forward[T any](m Mono[T], sink Sink[T], up *upstream) {
+	m.Subscribe(Subscribe[T]{
+		OnSuccess: sink.Success,
+		OnError:   sink.Error,
+		OnRequest: up.OnRequest,
+	})
+}
+
+// upstream cancels the monos that an operator subscribed to when the
+// subscription to the operator is cancelled.
+type upstream struct {
+	mu        mutex
+	cancels   []rx.FnCancel
+	cancelled bool
+}
+
+// relay returns the upstream of the operator that passes its result to
+// sink. Cancelling the subscription of sink cancels the upstream.
+func relay[T any](sink Sink[T]) *upstream {
+	up := &upstream{}
+	if s, ok := sink.(*subscriber[T]); ok {
+		s.setUpstream(up.Cancel)
+	}
+	return up
+}
+
+// OnRequest records the cancel function of a subscription, or calls it
+// if the upstream is cancelled already.
+func (u *upstream) OnRequest(cancel rx.FnCancel) {
+	u.mu.Lock()
+	if u.cancelled {
+		u.mu.Unlock()
+		cancel()
+		return
+	}
+	u.cancels = append(u.cancels, cancel)
+	u.mu.Unlock()
+}
+
+func (u *upstream) Cancel() {
+	u.mu.Lock()
+	if u.cancelled {
+		u.mu.Unlock()
+		return
+	}
+	u.cancelled = true
+	cancels := u.cancels
+	u.cancels = nil
+	u.mu.Unlock()
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
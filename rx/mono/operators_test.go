@@ -0,0 +1,197 @@
+package mono_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/rx/rxtest"
+)
+
+func TestFlatMap(t *testing.T) {
+	m := mono.FlatMap(later(2, 0), func(value int) mono.Mono[string] {
+		return later(string(rune('a'+value)), 0)
+	})
+	v, err := m.Block()
+	require.NoError(t, err)
+	assert.Equal(t, "c", v)
+
+	boom := errors.New("boom")
+	called := false
+	_, err = mono.FlatMap(failLater[int](boom, 0), func(int) mono.Mono[string] {
+		called = true
+		return mono.Just("a")
+	}).Block()
+	assert.Equal(t, boom, err)
+	assert.False(t, called)
+}
+
+func TestThen(t *testing.T) {
+	var first atomic.Bool
+	subscribed := false
+	next := mono.Create(func(sink mono.Sink[string]) {
+		subscribed = first.Load()
+		sink.Success("next")
+	})
+	v, err := mono.Then(mono.DoOnSuccess(later(1, 10*time.Millisecond), func(int) {
+		first.Store(true)
+	}), next).Block()
+	require.NoError(t, err)
+	assert.Equal(t, "next", v)
+	assert.True(t, subscribed)
+
+	boom := errors.New("boom")
+	_, err = mono.Then(mono.Error[int](boom), next).Block()
+	assert.Equal(t, boom, err)
+}
+
+func TestOnErrorResume(t *testing.T) {
+	boom := errors.New("boom")
+	v, err := mono.OnErrorResume(failLater[int](boom, 0), func(err error) mono.Mono[int] {
+		assert.Equal(t, boom, err)
+		return later(2, 0)
+	}).Block()
+	require.NoError(t, err)
+	assert.Equal(t, 2, v)
+
+	v, err = mono.OnErrorResume(mono.Just(1), func(error) mono.Mono[int] {
+		return mono.Just(2)
+	}).Block()
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+}
+
+func TestOnErrorReturn(t *testing.T) {
+	v, err := mono.OnErrorReturn(failLater[int](errors.New("boom"), 0), 2).Block()
+	require.NoError(t, err)
+	assert.Equal(t, 2, v)
+}
+
+func TestDoOnSuccess(t *testing.T) {
+	var got int
+	v, err := mono.DoOnSuccess(mono.Just(1), func(value int) {
+		got = value
+	}).Block()
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, 1, got)
+}
+
+func TestDoOnError(t *testing.T) {
+	boom := errors.New("boom")
+	var got error
+	_, err := mono.DoOnError(mono.Error[int](boom), func(err error) {
+		got = err
+	}).Block()
+	assert.Equal(t, boom, err)
+	assert.Equal(t, boom, got)
+}
+
+func TestDefer(t *testing.T) {
+	calls := 0
+	m := mono.Defer(func() mono.Mono[int] {
+		calls++
+		return mono.Just(calls)
+	})
+	assert.Equal(t, 0, calls)
+	var values []int
+	m.Subscribe(mono.Subscribe[int]{OnSuccess: func(v int) { values = append(values, v) }})
+	m.Subscribe(mono.Subscribe[int]{OnSuccess: func(v int) { values = append(values, v) }})
+	assert.Equal(t, []int{1, 2}, values)
+}
+
+func TestCache(t *testing.T) {
+	var subscriptions atomic.Int32
+	m := mono.Cache(mono.Defer(func() mono.Mono[int] {
+		subscriptions.Add(1)
+		return later(42, 20*time.Millisecond)
+	}))
+
+	var wg sync.WaitGroup
+	values := make([]int, 3)
+	for i := range values {
+		i := i
+		wg.Add(1)
+		mono.Cache(m).Subscribe(mono.Subscribe[int]{
+			OnSuccess: func(v int) {
+				values[i] = v
+				wg.Done()
+			},
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, []int{42, 42, 42}, values)
+
+	// Subscribers after completion get the result without subscribing again.
+	var got int
+	m.Subscribe(mono.Subscribe[int]{OnSuccess: func(v int) { got = v }})
+	assert.Equal(t, 42, got)
+	assert.Equal(t, int32(1), subscriptions.Load())
+
+	boom := errors.New("boom")
+	failed := mono.Cache(mono.Error[int](boom))
+	_, err := failed.Block()
+	assert.Equal(t, boom, err)
+	var gotErr error
+	failed.Subscribe(mono.Subscribe[int]{OnError: func(err error) { gotErr = err }})
+	assert.Equal(t, boom, gotErr)
+}
+
+// never is a mono that does not complete. cancelled is set once its
+// subscription is cancelled.
+func never[T any](cancelled *atomic.Bool) mono.Mono[T] {
+	m := mono.Create(func(sink mono.Sink[T]) {})
+	m.Notify(func(signal rx.SignalType) {
+		if signal == rx.SignalCancel {
+			cancelled.Store(true)
+		}
+	})
+	return m
+}
+
+func TestOperatorsCancel(t *testing.T) {
+	boom := errors.New("boom")
+	tests := map[string]func(*atomic.Bool) mono.Mono[int]{
+		"FlatMap source": func(cancelled *atomic.Bool) mono.Mono[int] {
+			return mono.FlatMap(never[int](cancelled), func(v int) mono.Mono[int] { return mono.Just(v) })
+		},
+		"FlatMap inner": func(cancelled *atomic.Bool) mono.Mono[int] {
+			return mono.FlatMap(mono.Just(1), func(int) mono.Mono[int] { return never[int](cancelled) })
+		},
+		"Then source": func(cancelled *atomic.Bool) mono.Mono[int] {
+			return mono.Then(never[string](cancelled), mono.Just(1))
+		},
+		"Then next": func(cancelled *atomic.Bool) mono.Mono[int] {
+			return mono.Then(mono.Just("a"), never[int](cancelled))
+		},
+		"OnErrorResume source": func(cancelled *atomic.Bool) mono.Mono[int] {
+			return mono.OnErrorResume(never[int](cancelled), func(error) mono.Mono[int] { return mono.Just(1) })
+		},
+		"OnErrorResume fallback": func(cancelled *atomic.Bool) mono.Mono[int] {
+			return mono.OnErrorResume(mono.Error[int](boom), func(error) mono.Mono[int] { return never[int](cancelled) })
+		},
+		"DoOnSuccess": func(cancelled *atomic.Bool) mono.Mono[int] {
+			return mono.DoOnSuccess(never[int](cancelled), func(int) {})
+		},
+		"Defer": func(cancelled *atomic.Bool) mono.Mono[int] {
+			return mono.Defer(func() mono.Mono[int] { return never[int](cancelled) })
+		},
+	}
+	for name, operator := range tests {
+		t.Run(name, func(t *testing.T) {
+			var cancelled atomic.Bool
+			rxtest.Mono(operator(&cancelled)).
+				ExpectNoEvent(10 * time.Millisecond).
+				ThenCancel().
+				Verify(t)
+			assert.True(t, cancelled.Load())
+		})
+	}
+}
@@ -5,8 +5,11 @@ type Processor[T any] interface {
 	Sink[T]
 }
 
+// ProcessorImpl passes its result to its subscriber. A result that
+// arrives before a subscriber is kept until one subscribes.
 type ProcessorImpl[T any] struct {
 	Mono[T]
+	mu      mutex
 	sink    Sink[T]
 	success *T
 	err     error
@@ -15,11 +18,14 @@ type ProcessorImpl[T any] struct {
 func NewProcessor[T any]() *ProcessorImpl[T] {
 	p := &ProcessorImpl[T]{}
 	p.Mono = Create(func(sink Sink[T]) {
+		p.mu.Lock()
 		p.sink = sink
-		if p.success != nil {
-			sink.Success(*p.success)
-		} else if p.err != nil {
-			sink.Error(p.err)
+		success, err := p.success, p.err
+		p.mu.Unlock()
+		if success != nil {
+			sink.Success(*success)
+		} else if err != nil {
+			sink.Error(err)
 		}
 	})
 
@@ -27,17 +33,25 @@ func NewProcessor[T any]() *ProcessorImpl[T] {
 }
 
 func (p *ProcessorImpl[T]) Success(value T) {
-	if p.sink != nil {
-		p.sink.Success(value)
-	} else {
+	p.mu.Lock()
+	sink := p.sink
+	if sink == nil {
 		p.success = &value
 	}
+	p.mu.Unlock()
+	if sink != nil {
+		sink.Success(value)
+	}
 }
 
 func (p *ProcessorImpl[T]) Error(err error) {
-	if p.sink != nil {
-		p.sink.Error(err)
-	} else {
+	p.mu.Lock()
+	sink := p.sink
+	if sink == nil {
 		p.err = err
 	}
+	p.mu.Unlock()
+	if sink != nil {
+		sink.Error(err)
+	}
 }
@@ -0,0 +1,116 @@
+package mono
+
+import (
+	"time"
+
+	"github.com/nanobus/iota/go/rx"
+)
+
+// Timeout fails with rx.ErrTimeout and cancels m if m does not complete
+// within timeout.
+func Timeout[T any](m Mono[T], timeout time.Duration, s rx.Scheduler) Mono[T] {
+	return Create(func(sink Sink[T]) {
+		up := relay(sink)
+		var o once
+		cancel := s.Schedule(timeout, func() {
+			if o.do() {
+				up.Cancel()
+				sink.Error(rx.ErrTimeout)
+			}
+		})
+		up.OnRequest(cancel)
+		m.Subscribe(Subscribe[T]{
+			OnSuccess: func(value T) {
+				if o.do() {
+					cancel()
+					sink.Success(value)
+				}
+			},
+			OnError: func(err error) {
+				if o.do() {
+					cancel()
+					sink.Error(err)
+				}
+			},
+			OnRequest: up.OnRequest,
+		})
+	})
+}
+
+// Delay succeeds with the value of m once delay has passed after m
+// succeeds. Errors are not delayed.
+func Delay[T any](m Mono[T], delay time.Duration, s rx.Scheduler) Mono[T] {
+	return Create(func(sink Sink[T]) {
+		up := relay(sink)
+		m.Subscribe(Subscribe[T]{
+			OnSuccess: func(value T) {
+				up.OnRequest(s.Schedule(delay, func() {
+					sink.Success(value)
+				}))
+			},
+			OnError:   sink.Error,
+			OnRequest: up.OnRequest,
+		})
+	})
+}
+
+// Retry subscribes to m again when it fails, up to retries times.
+// It fails with the last error. Each subscription to m must start the
+// work again, as monos from Create and Defer do. A mono that keeps its
+// result, such as one from Cache or NewProcessor, fails again at once.
+func Retry[T any](m Mono[T], retries int) Mono[T] {
+	return retry(m, retries, nil)
+}
+
+// RetryWithBackoff subscribes to m again when it fails, up to retries
+// times, after a delay that starts at first and doubles with each
+// attempt up to max. It fails with the last error. Like with Retry,
+// each subscription to m must start the work again.
+func RetryWithBackoff[T any](m Mono[T], retries int, first, max time.Duration, s rx.Scheduler) Mono[T] {
+	return retry(m, retries, func(up *upstream, attempt int, resubscribe func()) {
+		up.OnRequest(s.Schedule(rx.Backoff(attempt, first, max), resubscribe))
+	})
+}
+
+func retry[T any](m Mono[T], retries int, wait func(up *upstream, attempt int, resubscribe func())) Mono[T] {
+	return Create(func(sink Sink[T]) {
+		up := relay(sink)
+		attempt := 0
+		var resubscribe func()
+		resubscribe = func() {
+			m.Subscribe(Subscribe[T]{
+				OnSuccess: sink.Success,
+				OnError: func(err error) {
+					if attempt >= retries {
+						sink.Error(err)
+						return
+					}
+					attempt++
+					if wait == nil {
+						resubscribe()
+					} else {
+						wait(up, attempt, resubscribe)
+					}
+				},
+				OnRequest: up.OnRequest,
+			})
+		}
+		resubscribe()
+	})
+}
+
+// once lets the first of racing signals through.
+type once struct {
+	mu   mutex
+	done bool
+}
+
+func (o *once) do() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.done {
+		return false
+	}
+	o.done = true
+	return true
+}
@@ -0,0 +1,120 @@
+package mono_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/rx/rxtest"
+)
+
+// result records the result of a mono that completes on a scheduler.
+type result[T any] struct {
+	value T
+	err   error
+	done  bool
+}
+
+func subscribe[T any](m mono.Mono[T]) *result[T] {
+	r := new(result[T])
+	m.Subscribe(mono.Subscribe[T]{
+		OnSuccess: func(value T) {
+			r.value, r.done = value, true
+		},
+		OnError: func(err error) {
+			r.err, r.done = err, true
+		},
+	})
+	return r
+}
+
+// pending never completes.
+func pending[T any]() mono.Mono[T] {
+	return mono.Create(func(mono.Sink[T]) {})
+}
+
+func TestTimeout(t *testing.T) {
+	s := rxtest.NewVirtualScheduler()
+	r := subscribe(mono.Timeout(pending[int](), time.Second, s))
+	s.Advance(999 * time.Millisecond)
+	assert.False(t, r.done)
+	s.Advance(time.Millisecond)
+	assert.True(t, r.done)
+	assert.Equal(t, rx.ErrTimeout, r.err)
+
+	r = subscribe(mono.Timeout(mono.Just(1), time.Second, s))
+	assert.Equal(t, 1, r.value)
+	assert.Zero(t, s.Pending())
+}
+
+func TestTimeoutCancel(t *testing.T) {
+	s := rxtest.NewVirtualScheduler()
+	var cancelled atomic.Bool
+	r := subscribe(mono.Timeout(never[int](&cancelled), time.Second, s))
+	s.Advance(time.Second)
+	assert.Equal(t, rx.ErrTimeout, r.err)
+	assert.True(t, cancelled.Load())
+
+	// Cancelling the timeout cancels m and the timer.
+	cancelled.Store(false)
+	rxtest.Mono(mono.Timeout(never[int](&cancelled), time.Second, s)).
+		ThenCancel().
+		Verify(t)
+	assert.True(t, cancelled.Load())
+	assert.Zero(t, s.Pending())
+}
+
+func TestDelay(t *testing.T) {
+	s := rxtest.NewVirtualScheduler()
+	r := subscribe(mono.Delay(mono.Just(1), time.Second, s))
+	assert.False(t, r.done)
+	s.Advance(time.Second)
+	assert.True(t, r.done)
+	assert.Equal(t, 1, r.value)
+
+	boom := errors.New("boom")
+	r = subscribe(mono.Delay(mono.Error[int](boom), time.Second, s))
+	assert.Equal(t, boom, r.err)
+}
+
+// flaky fails until it was subscribed to n times.
+func flaky(n int, subscriptions *int) mono.Mono[int] {
+	return mono.Defer(func() mono.Mono[int] {
+		*subscriptions++
+		if *subscriptions < n {
+			return mono.Error[int](errors.New("attempt " + string(rune('0'+*subscriptions))))
+		}
+		return mono.Just(*subscriptions)
+	})
+}
+
+func TestRetry(t *testing.T) {
+	var subscriptions int
+	v, err := mono.Retry(flaky(3, &subscriptions), 2).Block()
+	require.NoError(t, err)
+	assert.Equal(t, 3, v)
+
+	subscriptions = 0
+	_, err = mono.Retry(flaky(4, &subscriptions), 2).Block()
+	assert.EqualError(t, err, "attempt 3")
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	s := rxtest.NewVirtualScheduler()
+	var subscriptions int
+	r := subscribe(mono.RetryWithBackoff(flaky(3, &subscriptions), 5, 100*time.Millisecond, time.Second, s))
+	assert.Equal(t, 1, subscriptions)
+	s.Advance(100 * time.Millisecond)
+	assert.Equal(t, 2, subscriptions)
+	s.Advance(199 * time.Millisecond)
+	assert.Equal(t, 2, subscriptions)
+	s.Advance(time.Millisecond)
+	assert.True(t, r.done)
+	assert.Equal(t, 3, r.value)
+}
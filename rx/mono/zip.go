@@ -0,0 +1,189 @@
+package mono
+
+type (
+	// Tuple2 holds the values of Zip2.
+	Tuple2[A, B any] struct {
+		A A
+		B B
+	}
+
+	// Tuple3 holds the values of Zip3.
+	Tuple3[A, B, C any] struct {
+		A A
+		B B
+		C C
+	}
+
+	// Tuple4 holds the values of Zip4.
+	Tuple4[A, B, C, D any] struct {
+		A A
+		B B
+		C C
+		D D
+	}
+
+	// Tuple5 holds the values of Zip5.
+	Tuple5[A, B, C, D, E any] struct {
+		A A
+		B B
+		C C
+		D D
+		E E
+	}
+)
+
+// Zip2 subscribes to a and b and succeeds with both values once both
+// succeed. It fails with the first error.
+func Zip2[A, B any](a Mono[A], b Mono[B]) Mono[Tuple2[A, B]] {
+	return Create(func(sink Sink[Tuple2[A, B]]) {
+		var t Tuple2[A, B]
+		up := relay(sink)
+		j := newJoiner(2, func() { sink.Success(t) }, sink.Error, up)
+		a.Subscribe(joined(j, &t.A))
+		b.Subscribe(joined(j, &t.B))
+	})
+}
+
+// Zip3 subscribes to a, b and c and succeeds with their values once all
+// of them succeed. It fails with the first error.
+func Zip3[A, B, C any](a Mono[A], b Mono[B], c Mono[C]) Mono[Tuple3[A, B, C]] {
+	return Create(func(sink Sink[Tuple3[A, B, C]]) {
+		var t Tuple3[A, B, C]
+		up := relay(sink)
+		j := newJoiner(3, func() { sink.Success(t) }, sink.Error, up)
+		a.Subscribe(joined(j, &t.A))
+		b.Subscribe(joined(j, &t.B))
+		c.Subscribe(joined(j, &t.C))
+	})
+}
+
+// Zip4 subscribes to a, b, c and d and succeeds with their values once
+// all of them succeed. It fails with the first error.
+func Zip4[A, B, C, D any](a Mono[A], b Mono[B], c Mono[C], d Mono[D]) Mono[Tuple4[A, B, C, D]] {
+	return Create(func(sink Sink[Tuple4[A, B, C, D]]) {
+		var t Tuple4[A, B, C, D]
+		up := relay(sink)
+		j := newJoiner(4, func() { sink.Success(t) }, sink.Error, up)
+		a.Subscribe(joined(j, &t.A))
+		b.Subscribe(joined(j, &t.B))
+		c.Subscribe(joined(j, &t.C))
+		d.Subscribe(joined(j, &t.D))
+	})
+}
+
+// Zip5 subscribes to a, b, c, d and e and succeeds with their values
+// once all of them succeed. It fails with the first error.
+func Zip5[A, B, C, D, E any](a Mono[A], b Mono[B], c Mono[C], d Mono[D], e Mono[E]) Mono[Tuple5[A, B, C, D, E]] {
+	return Create(func(sink Sink[Tuple5[A, B, C, D, E]]) {
+		var t Tuple5[A, B, C, D, E]
+		up := relay(sink)
+		j := newJoiner(5, func() { sink.Success(t) }, sink.Error, up)
+		a.Subscribe(joined(j, &t.A))
+		b.Subscribe(joined(j, &t.B))
+		c.Subscribe(joined(j, &t.C))
+		d.Subscribe(joined(j, &t.D))
+		e.Subscribe(joined(j, &t.E))
+	})
+}
+
+// Zip subscribes to monos and succeeds with their values, in the order
+// of monos, once all of them succeed. It fails with the first error.
+func Zip[T any](monos ...Mono[T]) Mono[[]T] {
+	return Create(func(sink Sink[[]T]) {
+		if len(monos) == 0 {
+			sink.Success(nil)
+			return
+		}
+		values := make([]T, len(monos))
+		up := relay(sink)
+		j := newJoiner(len(monos), func() { sink.Success(values) }, sink.Error, up)
+		for i, m := range monos {
+			m.Subscribe(joined(j, &values[i]))
+		}
+	})
+}
+
+// When subscribes to monos and succeeds once all of them succeed,
+// discarding their values. It fails with the first error. Monos of
+// different types can be awaited by passing each through Then with
+// VoidVal.
+func When[T any](monos ...Mono[T]) Void {
+	return Create(func(sink Sink[struct{}]) {
+		if len(monos) == 0 {
+			sink.Success(struct{}{})
+			return
+		}
+		up := relay(sink)
+		j := newJoiner(len(monos), func() { sink.Success(struct{}{}) }, sink.Error, up)
+		for _, m := range monos {
+			m.Subscribe(Subscribe[T]{
+				OnSuccess: func(T) { j.succeed() },
+				OnError:   j.fail,
+				OnRequest: up.OnRequest,
+			})
+		}
+	})
+}
+
+// joiner counts the monos of Zip and When that have yet to succeed.
+// The first error cancels the monos in up.
+type joiner struct {
+	mu        mutex
+	remaining int
+	done      bool
+	success   func()
+	failure   func(error)
+	up        *upstream
+}
+
+func newJoiner(n int, success func(), failure func(error), up *upstream) *joiner {
+	return &joiner{
+		remaining: n,
+		success:   success,
+		failure:   failure,
+		up:        up,
+	}
+}
+
+// joined stores the value of a mono in dst before counting it.
+func joined[T any](j *joiner, dst *T) Subscribe[T] {
+	return Subscribe[T]{
+		OnSuccess: func(value T) {
+			j.mu.Lock()
+			if !j.done {
+				*dst = value
+			}
+			j.mu.Unlock()
+			j.succeed()
+		},
+		OnError:   j.fail,
+		OnRequest: j.up.OnRequest,
+	}
+}
+
+func (j *joiner) succeed() {
+	j.mu.Lock()
+	if j.done {
+		j.mu.Unlock()
+		return
+	}
+	j.remaining--
+	j.done = j.remaining == 0
+	done := j.done
+	j.mu.Unlock()
+	if done {
+		j.success()
+	}
+}
+
+func (j *joiner) fail(err error) {
+	j.mu.Lock()
+	if j.done {
+		j.mu.Unlock()
+		return
+	}
+	j.done = true
+	j.mu.Unlock()
+	j.up.Cancel()
+	j.failure(err)
+}
@@ -0,0 +1,135 @@
+package mono_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/rx/rxtest"
+)
+
+// later succeeds with value after delay.
+func later[T any](value T, delay time.Duration) mono.Mono[T] {
+	return mono.Create(func(sink mono.Sink[T]) {
+		go func() {
+			time.Sleep(delay)
+			sink.Success(value)
+		}()
+	})
+}
+
+// failLater fails with err after delay.
+func failLater[T any](err error, delay time.Duration) mono.Mono[T] {
+	return mono.Create(func(sink mono.Sink[T]) {
+		go func() {
+			time.Sleep(delay)
+			sink.Error(err)
+		}()
+	})
+}
+
+func TestZip2(t *testing.T) {
+	v, err := mono.Zip2(later(1, 10*time.Millisecond), mono.Just("a")).Block()
+	require.NoError(t, err)
+	assert.Equal(t, mono.Tuple2[int, string]{A: 1, B: "a"}, v)
+}
+
+func TestZip5(t *testing.T) {
+	v, err := mono.Zip5(
+		later(1, 5*time.Millisecond),
+		mono.Just("b"),
+		later(true, time.Millisecond),
+		mono.Just(4.0),
+		later('e', 0),
+	).Block()
+	require.NoError(t, err)
+	assert.Equal(t, mono.Tuple5[int, string, bool, float64, rune]{A: 1, B: "b", C: true, D: 4.0, E: 'e'}, v)
+}
+
+func TestZip(t *testing.T) {
+	values, err := mono.Zip(
+		later(1, 20*time.Millisecond),
+		later(2, 0),
+		mono.Just(3),
+	).Block()
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, values)
+
+	values, err = mono.Zip[int]().Block()
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func TestZipError(t *testing.T) {
+	boom := errors.New("boom")
+	_, err := mono.Zip3(
+		later(1, time.Second),
+		failLater[string](boom, 0),
+		mono.Just(3),
+	).Block()
+	assert.Equal(t, boom, err)
+}
+
+func TestWhen(t *testing.T) {
+	_, err := mono.When(later(1, 10*time.Millisecond), later(2, 0)).Block()
+	require.NoError(t, err)
+
+	// Monos of different types are awaited through Then.
+	_, err = mono.When(
+		mono.Then(later(1, 0), mono.VoidVal),
+		mono.Then(mono.Just("a"), mono.VoidVal),
+	).Block()
+	require.NoError(t, err)
+
+	boom := errors.New("boom")
+	_, err = mono.When(later(1, time.Second), failLater[int](boom, 0)).Block()
+	assert.Equal(t, boom, err)
+
+	_, err = mono.When[int]().Block()
+	require.NoError(t, err)
+}
+
+func TestZipCancel(t *testing.T) {
+	var a, b atomic.Bool
+	rxtest.Mono(mono.Zip2(never[int](&a), never[string](&b))).
+		ExpectNoEvent(10 * time.Millisecond).
+		ThenCancel().
+		Verify(t)
+	assert.True(t, a.Load())
+	assert.True(t, b.Load())
+
+	a.Store(false)
+	b.Store(false)
+	rxtest.Mono(mono.When(never[int](&a), never[int](&b))).
+		ExpectNoEvent(10 * time.Millisecond).
+		ThenCancel().
+		Verify(t)
+	assert.True(t, a.Load())
+	assert.True(t, b.Load())
+}
+
+func TestZipErrorCancelsSources(t *testing.T) {
+	boom := errors.New("boom")
+	var a, c atomic.Bool
+	_, err := mono.Zip3(never[int](&a), failLater[string](boom, 0), never[int](&c)).Block()
+	assert.Equal(t, boom, err)
+	assert.True(t, a.Load())
+	assert.True(t, c.Load())
+
+	a.Store(false)
+	c.Store(false)
+	_, err = mono.Zip(never[int](&a), mono.Error[int](boom), never[int](&c)).Block()
+	assert.Equal(t, boom, err)
+	assert.True(t, a.Load())
+	assert.True(t, c.Load())
+
+	a.Store(false)
+	_, err = mono.When(never[int](&a), failLater[int](boom, 0)).Block()
+	assert.Equal(t, boom, err)
+	assert.True(t, a.Load())
+}
@@ -0,0 +1,115 @@
+// Package rxtest helps test code that uses fluxes and monos.
+package rxtest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nanobus/iota/go/rx"
+)
+
+// VirtualScheduler is a scheduler with a clock that only moves when
+// Advance is called. Tasks run on the goroutine that calls Advance, in
+// the order of their due times, so tests of time-based operators are
+// deterministic.
+type VirtualScheduler struct {
+	mu    sync.Mutex
+	now   time.Time
+	seq   uint64
+	tasks []*virtualTask
+}
+
+type virtualTask struct {
+	due  time.Time
+	seq  uint64
+	task func()
+}
+
+var _ = (rx.Scheduler)((*VirtualScheduler)(nil))
+
+// NewVirtualScheduler returns a scheduler with its clock at the Unix
+// epoch.
+func NewVirtualScheduler() *VirtualScheduler {
+	return &VirtualScheduler{
+		now: time.Unix(0, 0).UTC(),
+	}
+}
+
+func (s *VirtualScheduler) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+func (s *VirtualScheduler) Schedule(delay time.Duration, task func()) rx.FnCancel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if delay < 0 {
+		delay = 0
+	}
+	s.seq++
+	t := &virtualTask{
+		due:  s.now.Add(delay),
+		seq:  s.seq,
+		task: task,
+	}
+	s.tasks = append(s.tasks, t)
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, other := range s.tasks {
+			if other == t {
+				s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Advance moves the clock forward by d and runs the tasks that are due
+// by then, including tasks that those tasks schedule.
+func (s *VirtualScheduler) Advance(d time.Duration) {
+	s.mu.Lock()
+	end := s.now.Add(d)
+	for {
+		t := s.next(end)
+		if t == nil {
+			break
+		}
+		s.now = t.due
+		s.mu.Unlock()
+		t.task()
+		s.mu.Lock()
+	}
+	s.now = end
+	s.mu.Unlock()
+}
+
+// Pending returns the number of tasks that have yet to run.
+func (s *VirtualScheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tasks)
+}
+
+// next removes and returns the first task that is due by end.
+// It is called with mu held.
+func (s *VirtualScheduler) next(end time.Time) *virtualTask {
+	if len(s.tasks) == 0 {
+		return nil
+	}
+	sort.Slice(s.tasks, func(i, j int) bool {
+		a, b := s.tasks[i], s.tasks[j]
+		if !a.due.Equal(b.due) {
+			return a.due.Before(b.due)
+		}
+		return a.seq < b.seq
+	})
+	t := s.tasks[0]
+	if t.due.After(end) {
+		return nil
+	}
+	s.tasks = s.tasks[1:]
+	return t
+}
@@ -0,0 +1,34 @@
+package rxtest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nanobus/iota/go/rx/rxtest"
+)
+
+func TestVirtualScheduler(t *testing.T) {
+	s := rxtest.NewVirtualScheduler()
+	start := s.Now()
+	var ran []string
+	s.Schedule(2*time.Second, func() { ran = append(ran, "b") })
+	s.Schedule(time.Second, func() {
+		ran = append(ran, "a")
+		assert.Equal(t, start.Add(time.Second), s.Now())
+		s.Schedule(time.Second, func() { ran = append(ran, "c") })
+	})
+	cancel := s.Schedule(time.Second, func() { ran = append(ran, "cancelled") })
+	cancel()
+
+	s.Advance(500 * time.Millisecond)
+	assert.Empty(t, ran)
+	assert.Equal(t, 2, s.Pending())
+
+	s.Advance(2 * time.Second)
+	// Tasks that are due at the same time run in the order they were scheduled.
+	assert.Equal(t, []string{"a", "b", "c"}, ran)
+	assert.Equal(t, start.Add(2500*time.Millisecond), s.Now())
+	assert.Zero(t, s.Pending())
+}
@@ -0,0 +1,411 @@
+package rxtest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/mono"
+)
+
+// DefaultTimeout is how long a verifier waits for each expected signal
+// unless Timeout is called.
+var DefaultTimeout = 5 * time.Second
+
+// Verifier checks the signals of a flux or mono against a script of
+// expectations. The script is built by chaining calls and run by Verify,
+// which subscribes and checks each step in order:
+//
+//	rxtest.Flux(f).
+//		ThenRequest(2).
+//		ExpectNext(1, 2).
+//		ThenCancel().
+//		Verify(t)
+type Verifier[T any] struct {
+	subscribe func(*recorder[T]) rx.Subscription
+	steps     []step[T]
+	timeout   time.Duration
+	scheduler *VirtualScheduler
+}
+
+// step is one expectation or action of a script.
+type step[T any] struct {
+	name string
+	run  func(v *verification[T]) error
+}
+
+// Flux returns a verifier for f. Items are only requested by
+// ThenRequest.
+func Flux[T any](f flux.Flux[T]) *Verifier[T] {
+	return &Verifier[T]{
+		subscribe: func(r *recorder[T]) rx.Subscription {
+			var sub rx.Subscription
+			f.Subscribe(flux.Subscribe[T]{
+				OnNext:     r.next,
+				OnComplete: r.complete,
+				OnError:    r.error,
+				OnRequest: func(s rx.Subscription) {
+					r.mu.Lock()
+					sub = s
+					r.mu.Unlock()
+				},
+			})
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if sub == nil {
+				sub = f.Subscription()
+			}
+			return sub
+		},
+		timeout: DefaultTimeout,
+	}
+}
+
+// Mono returns a verifier for m. A value is verified with ExpectNext
+// followed by ExpectComplete. ThenRequest has no effect on monos.
+func Mono[T any](m mono.Mono[T]) *Verifier[T] {
+	return &Verifier[T]{
+		subscribe: func(r *recorder[T]) rx.Subscription {
+			var cancel rx.FnCancel
+			m.Subscribe(mono.Subscribe[T]{
+				OnSuccess: func(value T) {
+					r.next(value)
+					r.complete()
+				},
+				OnError: r.error,
+				OnRequest: func(c rx.FnCancel) {
+					r.mu.Lock()
+					cancel = c
+					r.mu.Unlock()
+				},
+			})
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			return monoSubscription(cancel)
+		},
+		timeout: DefaultTimeout,
+	}
+}
+
+// monoSubscription cancels a mono.
+type monoSubscription rx.FnCancel
+
+func (s monoSubscription) Request(int) {}
+
+func (s monoSubscription) Cancel() {
+	if s != nil {
+		s()
+	}
+}
+
+// Timeout sets how long Verify waits for each expected signal.
+func (v *Verifier[T]) Timeout(d time.Duration) *Verifier[T] {
+	v.timeout = d
+	return v
+}
+
+// WithVirtualTime sets the scheduler that ThenAdvance advances.
+func (v *Verifier[T]) WithVirtualTime(s *VirtualScheduler) *Verifier[T] {
+	v.scheduler = s
+	return v
+}
+
+// ExpectNext expects the next signals to be the given items.
+func (v *Verifier[T]) ExpectNext(values ...T) *Verifier[T] {
+	for _, value := range values {
+		value := value
+		v.add(fmt.Sprintf("ExpectNext(%v)", value), func(vn *verification[T]) error {
+			got, err := vn.nextItem()
+			if err != nil {
+				return err
+			}
+			if !assert.ObjectsAreEqual(value, got) {
+				return fmt.Errorf("expected item %v, got %v", value, got)
+			}
+			return nil
+		})
+	}
+	return v
+}
+
+// ExpectNextMatches expects the next signal to be an item for which
+// matches returns true.
+func (v *Verifier[T]) ExpectNextMatches(matches func(T) bool) *Verifier[T] {
+	return v.add("ExpectNextMatches", func(vn *verification[T]) error {
+		got, err := vn.nextItem()
+		if err != nil {
+			return err
+		}
+		if !matches(got) {
+			return fmt.Errorf("item %v does not match", got)
+		}
+		return nil
+	})
+}
+
+// ExpectNextCount expects the next n signals to be items.
+func (v *Verifier[T]) ExpectNextCount(n int) *Verifier[T] {
+	return v.add(fmt.Sprintf("ExpectNextCount(%d)", n), func(vn *verification[T]) error {
+		for i := 0; i < n; i++ {
+			if _, err := vn.nextItem(); err != nil {
+				return fmt.Errorf("item %d of %d: %w", i+1, n, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ExpectComplete expects the next signal to be completion.
+func (v *Verifier[T]) ExpectComplete() *Verifier[T] {
+	return v.add("ExpectComplete", func(vn *verification[T]) error {
+		e, err := vn.nextEvent()
+		if err != nil {
+			return err
+		}
+		if e.kind != eventComplete {
+			return fmt.Errorf("expected completion, got %s", e)
+		}
+		return nil
+	})
+}
+
+// ExpectError expects the next signal to be an error that matches err
+// according to errors.Is.
+func (v *Verifier[T]) ExpectError(err error) *Verifier[T] {
+	return v.add(fmt.Sprintf("ExpectError(%v)", err), func(vn *verification[T]) error {
+		got, e := vn.nextError()
+		if e != nil {
+			return e
+		}
+		if !errors.Is(got, err) {
+			return fmt.Errorf("expected error %v, got %v", err, got)
+		}
+		return nil
+	})
+}
+
+// ExpectErrorMatches expects the next signal to be an error for which
+// matches returns true.
+func (v *Verifier[T]) ExpectErrorMatches(matches func(error) bool) *Verifier[T] {
+	return v.add("ExpectErrorMatches", func(vn *verification[T]) error {
+		got, err := vn.nextError()
+		if err != nil {
+			return err
+		}
+		if !matches(got) {
+			return fmt.Errorf("error %v does not match", got)
+		}
+		return nil
+	})
+}
+
+// ExpectNoEvent expects no signal to arrive within d.
+func (v *Verifier[T]) ExpectNoEvent(d time.Duration) *Verifier[T] {
+	return v.add(fmt.Sprintf("ExpectNoEvent(%s)", d), func(vn *verification[T]) error {
+		if e, ok := vn.r.wait(d); ok {
+			return fmt.Errorf("expected no signal, got %s", e)
+		}
+		return nil
+	})
+}
+
+// ThenRequest requests n more items.
+func (v *Verifier[T]) ThenRequest(n int) *Verifier[T] {
+	return v.add(fmt.Sprintf("ThenRequest(%d)", n), func(vn *verification[T]) error {
+		vn.sub.Request(n)
+		return nil
+	})
+}
+
+// ThenCancel cancels the subscription. Signals that arrived before are
+// still expected to be verified.
+func (v *Verifier[T]) ThenCancel() *Verifier[T] {
+	return v.add("ThenCancel", func(vn *verification[T]) error {
+		vn.sub.Cancel()
+		vn.cancelled = true
+		return nil
+	})
+}
+
+// Then calls fn, such as to emit items from a processor.
+func (v *Verifier[T]) Then(fn func()) *Verifier[T] {
+	return v.add("Then", func(*verification[T]) error {
+		fn()
+		return nil
+	})
+}
+
+// ThenAdvance advances the scheduler of WithVirtualTime by d.
+func (v *Verifier[T]) ThenAdvance(d time.Duration) *Verifier[T] {
+	return v.add(fmt.Sprintf("ThenAdvance(%s)", d), func(*verification[T]) error {
+		if v.scheduler == nil {
+			return errors.New("ThenAdvance requires WithVirtualTime")
+		}
+		v.scheduler.Advance(d)
+		return nil
+	})
+}
+
+func (v *Verifier[T]) add(name string, run func(*verification[T]) error) *Verifier[T] {
+	v.steps = append(v.steps, step[T]{name: name, run: run})
+	return v
+}
+
+// Verify subscribes and runs the script. It fails t at the first step
+// that is not met, or if signals arrived that the script did not
+// expect.
+func (v *Verifier[T]) Verify(t testing.TB) {
+	t.Helper()
+	r := newRecorder[T]()
+	vn := verification[T]{
+		r:       r,
+		timeout: v.timeout,
+	}
+	vn.sub = v.subscribe(r)
+	if vn.sub == nil {
+		vn.sub = monoSubscription(nil)
+	}
+	for i, s := range v.steps {
+		if err := s.run(&vn); err != nil {
+			t.Fatalf("step %d, %s: %v", i+1, s.name, err)
+		}
+	}
+	if e, ok := r.poll(); ok && !vn.cancelled {
+		t.Fatalf("unexpected signal after the last step: %s", e)
+	}
+}
+
+// verification is the state of a running script.
+type verification[T any] struct {
+	r         *recorder[T]
+	sub       rx.Subscription
+	timeout   time.Duration
+	cancelled bool
+}
+
+func (vn *verification[T]) nextEvent() (event[T], error) {
+	e, ok := vn.r.wait(vn.timeout)
+	if !ok {
+		return e, fmt.Errorf("no signal within %s", vn.timeout)
+	}
+	return e, nil
+}
+
+func (vn *verification[T]) nextItem() (value T, err error) {
+	e, err := vn.nextEvent()
+	if err != nil {
+		return value, err
+	}
+	if e.kind != eventNext {
+		return value, fmt.Errorf("expected an item, got %s", e)
+	}
+	return e.value, nil
+}
+
+func (vn *verification[T]) nextError() (error, error) {
+	e, err := vn.nextEvent()
+	if err != nil {
+		return nil, err
+	}
+	if e.kind != eventError {
+		return nil, fmt.Errorf("expected an error, got %s", e)
+	}
+	return e.err, nil
+}
+
+type eventKind uint8
+
+const (
+	eventNext eventKind = iota
+	eventComplete
+	eventError
+)
+
+// event is a recorded signal.
+type event[T any] struct {
+	kind  eventKind
+	value T
+	err   error
+}
+
+func (e event[T]) String() string {
+	switch e.kind {
+	case eventNext:
+		return fmt.Sprintf("item %v", e.value)
+	case eventComplete:
+		return "completion"
+	default:
+		return fmt.Sprintf("error %v", e.err)
+	}
+}
+
+// recorder queues the signals of a subscription until a step takes
+// them.
+type recorder[T any] struct {
+	mu      sync.Mutex
+	events  []event[T]
+	arrived chan struct{}
+}
+
+func newRecorder[T any]() *recorder[T] {
+	return &recorder[T]{
+		arrived: make(chan struct{}, 1),
+	}
+}
+
+func (r *recorder[T]) next(value T) {
+	r.add(event[T]{kind: eventNext, value: value})
+}
+
+func (r *recorder[T]) complete() {
+	r.add(event[T]{kind: eventComplete})
+}
+
+func (r *recorder[T]) error(err error) {
+	r.add(event[T]{kind: eventError, err: err})
+}
+
+func (r *recorder[T]) add(e event[T]) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+	select {
+	case r.arrived <- struct{}{}:
+	default:
+	}
+}
+
+// poll removes the first signal, if one arrived.
+func (r *recorder[T]) poll() (e event[T], ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.events) == 0 {
+		return e, false
+	}
+	e = r.events[0]
+	r.events = r.events[1:]
+	return e, true
+}
+
+// wait removes the first signal, waiting up to timeout for one to
+// arrive.
+func (r *recorder[T]) wait(timeout time.Duration) (event[T], bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		if e, ok := r.poll(); ok {
+			return e, true
+		}
+		select {
+		case <-r.arrived:
+		case <-timer.C:
+			return r.poll()
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package rxtest_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/rx/rxtest"
+)
+
+// fakeT records the failure of a verifier.
+type fakeT struct {
+	testing.TB
+	failure string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Fatalf(format string, args ...any) {
+	t.failure = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// failure returns the failure of verify, if any.
+func failure(verify func(testing.TB)) string {
+	t := &fakeT{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		verify(t)
+	}()
+	<-done
+	return t.failure
+}
+
+func TestVerifyFlux(t *testing.T) {
+	rxtest.Flux(flux.FromSlice([]int{1, 2, 3, 4})).
+		ThenRequest(2).
+		ExpectNext(1, 2).
+		ExpectNoEvent(10 * time.Millisecond).
+		ThenRequest(rx.RequestMax).
+		ExpectNextMatches(func(v int) bool { return v == 3 }).
+		ExpectNextCount(1).
+		ExpectComplete().
+		Verify(t)
+
+	rxtest.Flux(flux.FromSlice([]int{1, 2, 3})).
+		ThenRequest(1).
+		ExpectNext(1).
+		ThenCancel().
+		Verify(t)
+
+	boom := errors.New("boom")
+	rxtest.Flux(flux.Error[int](fmt.Errorf("wrapped: %w", boom))).
+		ExpectError(boom).
+		Verify(t)
+
+	p := flux.NewProcessor[string]()
+	rxtest.Flux[string](p).
+		ThenRequest(rx.RequestMax).
+		Then(func() {
+			p.Next("a")
+			p.Error(boom)
+		}).
+		ExpectNext("a").
+		ExpectErrorMatches(func(err error) bool { return err == boom }).
+		Verify(t)
+}
+
+func TestVerifyMono(t *testing.T) {
+	rxtest.Mono(mono.Just(1)).
+		ExpectNext(1).
+		ExpectComplete().
+		Verify(t)
+
+	boom := errors.New("boom")
+	rxtest.Mono(mono.Error[int](boom)).
+		ExpectError(boom).
+		Verify(t)
+
+	s := rxtest.NewVirtualScheduler()
+	rxtest.Mono(mono.Delay(mono.Just("a"), time.Second, s)).
+		WithVirtualTime(s).
+		ThenAdvance(500 * time.Millisecond).
+		ExpectNoEvent(10 * time.Millisecond).
+		ThenAdvance(500 * time.Millisecond).
+		ExpectNext("a").
+		ExpectComplete().
+		Verify(t)
+}
+
+func TestVerifyFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		verify  func(testing.TB)
+		failure string
+	}{
+		{
+			"wrong item",
+			rxtest.Flux(flux.FromSlice([]int{1})).ThenRequest(1).ExpectNext(2).Verify,
+			"step 2, ExpectNext(2): expected item 2, got 1",
+		},
+		{
+			"error instead of completion",
+			rxtest.Flux(flux.Error[int](errors.New("boom"))).ExpectComplete().Verify,
+			"step 1, ExpectComplete: expected completion, got error boom",
+		},
+		{
+			"timeout",
+			rxtest.Flux(flux.FromSlice([]int{1})).Timeout(10 * time.Millisecond).ExpectNext(1).Verify,
+			"step 1, ExpectNext(1): no signal within 10ms",
+		},
+		{
+			"unexpected signal",
+			rxtest.Mono(mono.Just(1)).ExpectNext(1).Verify,
+			"unexpected signal after the last step: completion",
+		},
+		{
+			"no scheduler",
+			rxtest.Mono(mono.Just(1)).ThenAdvance(time.Second).Verify,
+			"step 1, ThenAdvance(1s): ThenAdvance requires WithVirtualTime",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := failure(tt.verify)
+			assert.True(t, strings.HasPrefix(got, tt.failure), got)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package rx
+
+import (
+	"time"
+)
+
+// Scheduler runs tasks after a delay. Time-based operators take a
+// Scheduler so that they run on the host, in guests and in tests
+// with virtual time.
+type Scheduler interface {
+	// Now returns the current time of the scheduler.
+	Now() time.Time
+	// Schedule runs task once delay has passed. The returned function
+	// cancels task if it has not run yet.
+	Schedule(delay time.Duration, task func()) FnCancel
+}
+
+// Backoff returns the delay before retry attempt, starting at 1. The
+// delay starts at first and doubles with each attempt up to max.
+func Backoff(attempt int, first, max time.Duration) time.Duration {
+	delay := first
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,25 @@
+//go:build !purego && !appengine && !wasm && !tinygo.wasm && !wasi
+// +build !purego,!appengine,!wasm,!tinygo.wasm,!wasi
+
+package rx
+
+import (
+	"time"
+)
+
+// HostScheduler runs each task on its own goroutine once its timer
+// fires.
+var HostScheduler Scheduler = hostScheduler{}
+
+type hostScheduler struct{}
+
+func (hostScheduler) Now() time.Time {
+	return time.Now()
+}
+
+func (hostScheduler) Schedule(delay time.Duration, task func()) FnCancel {
+	t := time.AfterFunc(delay, task)
+	return func() {
+		t.Stop()
+	}
+}
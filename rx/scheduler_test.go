@@ -0,0 +1,40 @@
+package rx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nanobus/iota/go/rx"
+)
+
+func TestBackoff(t *testing.T) {
+	var delays []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		delays = append(delays, rx.Backoff(attempt, 100*time.Millisecond, time.Second))
+	}
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+	}, delays)
+}
+
+func TestHostScheduler(t *testing.T) {
+	ran := make(chan struct{})
+	rx.HostScheduler.Schedule(time.Millisecond, func() { close(ran) })
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("task did not run")
+	}
+
+	cancel := rx.HostScheduler.Schedule(10*time.Millisecond, func() {
+		t.Error("cancelled task ran")
+	})
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+}
@@ -0,0 +1,125 @@
+package tap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/nanobus/iota/go/internal/frames"
+)
+
+// A capture starts with the magic "RSCAP" and a version byte. Each frame
+// is a record of its direction (1 byte), the time it was observed in
+// nanoseconds since the Unix epoch (8 bytes), its length (3 bytes) and
+// the encoded frame. Integers are big endian.
+const (
+	captureMagic   = "RSCAP"
+	captureVersion = 1
+
+	// recordHeaderLen is the length of the direction,
+	// timestamp and frame length of a record.
+	recordHeaderLen = 1 + 8 + 3
+)
+
+// ErrInvalidCapture is returned when reading data that is not a capture.
+var ErrInvalidCapture = errors.New("invalid capture")
+
+// Reader reads the frames of a capture written by a Recorder.
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader checks the capture header and returns a reader of its frames.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	var header [len(captureMagic) + 1]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCapture, err)
+	}
+	if string(header[:len(captureMagic)]) != captureMagic {
+		return nil, ErrInvalidCapture
+	}
+	if version := header[len(captureMagic)]; version != captureVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidCapture, version)
+	}
+	return &Reader{r: br}, nil
+}
+
+// Read returns the next frame of the capture or io.EOF
+// after the last one.
+func (r *Reader) Read() (Event, error) {
+	var header [recordHeaderLen]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = fmt.Errorf("%w: truncated record", ErrInvalidCapture)
+		}
+		return Event{}, err
+	}
+	direction := Direction(header[0])
+	if direction != Inbound && direction != Outbound {
+		return Event{}, fmt.Errorf("%w: unknown direction %d", ErrInvalidCapture, direction)
+	}
+	nanos := int64(binary.BigEndian.Uint64(header[1:]))
+	size := int(header[9])<<16 | int(header[10])<<8 | int(header[11])
+
+	raw := make([]byte, size)
+	if _, err := io.ReadFull(r.r, raw); err != nil {
+		return Event{}, fmt.Errorf("%w: truncated frame", ErrInvalidCapture)
+	}
+	f, err := frames.Decode(raw)
+	if err != nil {
+		return Event{}, fmt.Errorf("%w: %v", ErrInvalidCapture, err)
+	}
+	return Event{
+		Time:      time.Unix(0, nanos),
+		Direction: direction,
+		Frame:     f,
+	}, nil
+}
+
+// ReadAll reads all frames of a capture from r.
+func ReadAll(r io.Reader) ([]Event, error) {
+	cr, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	for {
+		e, err := cr.Read()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+}
+
+// ReadFile reads all frames of the named capture file.
+func ReadFile(name string) ([]Event, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadAll(f)
+}
+
+// Clone returns a copy of e with a frame that does not share memory with
+// the frame of e, so it can be kept after ObserveFrame returns. It fails
+// if the encoded frame cannot be decoded again.
+func Clone(e Event) (Event, error) {
+	raw := make([]byte, e.Size())
+	e.Frame.Encode(raw)
+	f, err := frames.Decode(raw)
+	if err != nil {
+		return e, fmt.Errorf("clone %s frame of stream %d: %w", e.Type(), e.StreamID(), err)
+	}
+	e.Frame = f
+	return e, nil
+}
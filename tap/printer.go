@@ -0,0 +1,94 @@
+package tap
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/payload"
+)
+
+// TimeFormat is the layout of timestamps written by Printer.
+const TimeFormat = "15:04:05.000000"
+
+// Printer writes one line per frame in a human readable form.
+type Printer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewPrinter creates a printer that writes to w, such as os.Stderr.
+func NewPrinter(w io.Writer) *Printer {
+	return &Printer{w: w}
+}
+
+func (p *Printer) ObserveFrame(e Event) {
+	line := Format(e) + "\n"
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, _ = io.WriteString(p.w, line)
+}
+
+// Format returns a line describing the frame of e with its time,
+// direction, stream ID, type, flags and sizes, such as
+//
+//	12:01:02.000003 OUT stream=1 REQUEST_STREAM flags=M size=42 initialN=10 metadata=8 data=25
+func Format(e Event) string {
+	var b strings.Builder
+	b.WriteString(e.Time.Format(TimeFormat))
+	b.WriteByte(' ')
+	b.WriteString(e.Direction.String())
+	b.WriteString(" stream=")
+	b.WriteString(strconv.FormatUint(uint64(e.StreamID()), 10))
+	b.WriteByte(' ')
+	b.WriteString(e.Type().String())
+	if flags := e.Flags(); flags != 0 {
+		b.WriteString(" flags=")
+		b.WriteString(flags.String())
+	}
+	b.WriteString(" size=")
+	b.WriteString(strconv.FormatUint(uint64(e.Size()), 10))
+
+	switch f := e.Frame.(type) {
+	case *Setup:
+		field(&b, "version", strconv.Itoa(int(f.MajorVersion))+"."+strconv.Itoa(int(f.MinorVersion)))
+		field(&b, "keepalive", f.TimeBetweenKeepalive.String())
+		field(&b, "lifetime", f.MaxLifetime.String())
+		field(&b, "metadataMimeType", strconv.Quote(f.MimeMetadata))
+		field(&b, "dataMimeType", strconv.Quote(f.MimeData))
+		sizes(&b, f.Metadata, f.Data)
+	case *Keepalive:
+		field(&b, "position", strconv.FormatUint(f.LastReceivedPosition, 10))
+		field(&b, "data", strconv.Itoa(len(f.Data)))
+	case *RequestPayload:
+		if f.FrameType == frames.FrameTypeRequestStream || f.FrameType == frames.FrameTypeRequestChannel {
+			field(&b, "initialN", strconv.FormatUint(uint64(f.InitialN), 10))
+		}
+		sizes(&b, f.Metadata, f.Data)
+	case *RequestN:
+		field(&b, "n", strconv.FormatUint(uint64(f.N), 10))
+	case *Payload:
+		sizes(&b, f.Metadata, f.Data)
+	case *Error:
+		field(&b, "code", payload.ErrCode(f.Code).String())
+		field(&b, "data", strconv.Quote(f.Data))
+	case *Ext:
+		field(&b, "extendedType", strconv.FormatUint(uint64(f.ExtendedType), 10))
+		sizes(&b, f.Metadata, f.Data)
+	}
+	return b.String()
+}
+
+func field(b *strings.Builder, name, value string) {
+	b.WriteByte(' ')
+	b.WriteString(name)
+	b.WriteByte('=')
+	b.WriteString(value)
+}
+
+func sizes(b *strings.Builder, metadata, data []byte) {
+	field(b, "metadata", strconv.Itoa(len(metadata)))
+	field(b, "data", strconv.Itoa(len(data)))
+}
@@ -0,0 +1,88 @@
+package tap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/nanobus/iota/go/internal/buffer"
+)
+
+// Recorder writes frames to a capture for later replay.
+type Recorder struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	closer io.Closer
+	err    error
+}
+
+// NewRecorder creates a recorder that writes a capture to w.
+func NewRecorder(w io.Writer) *Recorder {
+	r := Recorder{w: bufio.NewWriter(w)}
+	_, r.err = r.w.WriteString(captureMagic)
+	if r.err == nil {
+		r.err = r.w.WriteByte(captureVersion)
+	}
+	return &r
+}
+
+// Create creates or truncates the named file and records a capture to it.
+func Create(name string) (*Recorder, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	r := NewRecorder(f)
+	r.closer = f
+	return r, nil
+}
+
+// ObserveFrame writes a record of the frame. Records are buffered
+// until Flush or Close is called.
+func (r *Recorder) ObserveFrame(e Event) {
+	size := e.Size()
+	buf := buffer.Get(recordHeaderLen + int(size))
+	defer buf.Release()
+	b := buf.Bytes()
+	b[0] = byte(e.Direction)
+	binary.BigEndian.PutUint64(b[1:], uint64(e.Time.UnixNano()))
+	b[9], b[10], b[11] = byte(size>>16), byte(size>>8), byte(size)
+	e.Frame.Encode(b[recordHeaderLen:])
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		_, r.err = r.w.Write(b)
+	}
+}
+
+// Flush writes buffered records.
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+	return r.err
+}
+
+// Err returns the first error that occurred while writing the capture.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Close flushes buffered records and closes the file of recorders
+// returned by Create.
+func (r *Recorder) Close() error {
+	err := r.Flush()
+	if r.closer != nil {
+		if cerr := r.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,277 @@
+// Package replay feeds the inbound frames of a capture into a handler.Handler
+// or host.Instance and compares the frames it sends with the capture, so
+// captured bugs can be reproduced as regression tests. With WithReverse,
+// the outbound frames of a capture are fed into the other side of the
+// connection instead, such as the guest of a host.Instance.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/tap"
+)
+
+// DefaultTimeout is how long Run waits for the target to send the
+// frames that the capture expects.
+const DefaultTimeout = time.Second
+
+// Target handles frames and lets replay observe the frames it sends,
+// such as a *handler.Handler or *host.Instance.
+type Target interface {
+	HandleFrame(f frames.Frame) error
+	SetFrameObserver(observer tap.FrameObserver)
+}
+
+// Option configures Run.
+type Option func(*options)
+
+type options struct {
+	timeout time.Duration
+	reverse bool
+}
+
+// WithTimeout sets how long Run waits for the target to send the frames
+// the capture expects before feeding it the next inbound frame and
+// before comparing the result.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithReverse feeds the outbound frames of the capture into the target
+// and compares the frames it sends with the inbound frames. This replays
+// what the peer of the captured side received, such as the requests a
+// host sent to its guest with the target returned by
+// host.Instance.Guest.
+func WithReverse() Option {
+	return func(o *options) {
+		o.reverse = true
+	}
+}
+
+// Result is the outcome of a replay.
+type Result struct {
+	// Expected are the outbound frames of the capture, or the inbound
+	// frames with WithReverse.
+	Expected []tap.Event
+	// Actual are the frames the target sent.
+	Actual []tap.Event
+	// Differences are the frames that do not match.
+	Differences []Difference
+}
+
+// Run feeds the inbound frames of capture into target in order and
+// compares the frames it sends with the outbound frames of the capture.
+// Before each inbound frame, Run waits until the target has sent as many
+// frames as were sent before it in the capture. Frames that transports
+// send themselves, KEEPALIVE frames and the SETUP frame of clients, are
+// skipped. Run replaces the frame observer of target.
+func Run(ctx context.Context, target Target, capture []tap.Event, opts ...Option) (*Result, error) {
+	o := options{timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	sent := tap.Outbound
+	if o.reverse {
+		sent = tap.Inbound
+	}
+	r := recorder{direction: sent, changed: make(chan struct{}, 1)}
+	target.SetFrameObserver(&r)
+
+	var expected []tap.Event
+	for _, e := range capture {
+		if skip(e, sent) {
+			continue
+		}
+		if e.Direction == sent {
+			expected = append(expected, e)
+			continue
+		}
+		if err := r.wait(ctx, len(expected), o.timeout); err != nil {
+			return nil, err
+		}
+		// Errors are part of the behavior that is replayed.
+		_ = target.HandleFrame(e.Frame)
+	}
+	if err := r.wait(ctx, len(expected), o.timeout); err != nil {
+		return nil, err
+	}
+
+	actual, err := r.events()
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Expected:    expected,
+		Actual:      actual,
+		Differences: Diff(expected, actual),
+	}, nil
+}
+
+// skip returns true for frames that are sent by transports. The target
+// sends the frames observed in the sent direction.
+func skip(e tap.Event, sent tap.Direction) bool {
+	switch e.Type() {
+	case frames.FrameTypeKeepalive:
+		return true
+	case frames.FrameTypeSetup:
+		return e.Direction == sent
+	}
+	return false
+}
+
+// recorder keeps the frames sent by the target.
+type recorder struct {
+	// direction is the direction of the frames the target sends.
+	direction tap.Direction
+
+	mu      sync.Mutex
+	sent    []tap.Event
+	err     error
+	changed chan struct{}
+}
+
+func (r *recorder) ObserveFrame(e tap.Event) {
+	if e.Direction != r.direction || skip(e, r.direction) {
+		return
+	}
+	e, err := tap.Clone(e)
+	r.mu.Lock()
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+	r.sent = append(r.sent, e)
+	r.mu.Unlock()
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+}
+
+// events returns the frames sent by the target or the first error
+// cloning them.
+func (r *recorder) events() ([]tap.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]tap.Event(nil), r.sent...), r.err
+}
+
+// wait waits until at least n frames were sent or timeout elapses
+// without a frame being sent.
+func (r *recorder) wait(ctx context.Context, n int, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		r.mu.Lock()
+		sent := len(r.sent)
+		r.mu.Unlock()
+		if sent >= n {
+			return nil
+		}
+		select {
+		case <-r.changed:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(timeout)
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Difference is an expected frame that was not sent, a frame that was
+// sent but not expected or a frame that was sent differently.
+type Difference struct {
+	StreamID uint32
+	// Expected is nil for frames that were not expected.
+	Expected *tap.Event
+	// Actual is nil for frames that were not sent.
+	Actual *tap.Event
+}
+
+func (d Difference) String() string {
+	var b strings.Builder
+	if d.Expected != nil {
+		b.WriteString("- ")
+		b.WriteString(tap.Format(*d.Expected))
+	}
+	if d.Actual != nil {
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("+ ")
+		b.WriteString(tap.Format(*d.Actual))
+	}
+	return b.String()
+}
+
+// Diff compares the frames of each stream in order. Frames of different
+// streams may be sent in any order since streams are handled concurrently.
+// Frames are equal if they encode to the same bytes.
+func Diff(expected, actual []tap.Event) []Difference {
+	expectedByStream, streamIDs := byStream(expected, nil)
+	actualByStream, streamIDs := byStream(actual, streamIDs)
+
+	var diffs []Difference
+	for _, streamID := range streamIDs {
+		exp, act := expectedByStream[streamID], actualByStream[streamID]
+		for i := 0; i < len(exp) || i < len(act); i++ {
+			d := Difference{StreamID: streamID}
+			if i < len(exp) {
+				d.Expected = &exp[i]
+			}
+			if i < len(act) {
+				d.Actual = &act[i]
+			}
+			if d.Expected != nil && d.Actual != nil && equal(d.Expected.Frame, d.Actual.Frame) {
+				continue
+			}
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+// byStream groups events by stream ID and appends
+// new stream IDs in the order they first appear.
+func byStream(events []tap.Event, streamIDs []uint32) (map[uint32][]tap.Event, []uint32) {
+	m := make(map[uint32][]tap.Event)
+	for _, e := range events {
+		streamID := e.StreamID()
+		if _, ok := m[streamID]; !ok && !contains(streamIDs, streamID) {
+			streamIDs = append(streamIDs, streamID)
+		}
+		m[streamID] = append(m[streamID], e)
+	}
+	return m, streamIDs
+}
+
+func contains(streamIDs []uint32, streamID uint32) bool {
+	for _, id := range streamIDs {
+		if id == streamID {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(a, b frames.Frame) bool {
+	if a.Size() != b.Size() {
+		return false
+	}
+	ab := make([]byte, a.Size())
+	bb := make([]byte, b.Size())
+	a.Encode(ab)
+	b.Encode(bb)
+	return bytes.Equal(ab, bb)
+}
@@ -0,0 +1,122 @@
+package replay_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/handler"
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/tap"
+	"github.com/nanobus/iota/go/tap/replay"
+	"github.com/nanobus/iota/go/transport/rsocket"
+)
+
+const testNamespace = "replay.test"
+
+func init() {
+	invoke.ExportRequestResponse(testNamespace, "upper", func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+		return mono.Just[payload.Payload](payload.New(bytes.ToUpper(p.Data())))
+	})
+	invoke.ExportRequestStream(testNamespace, "split", func(ctx context.Context, p payload.Payload) flux.Flux[payload.Payload] {
+		words := strings.Fields(string(p.Data()))
+		return flux.Create(func(sink flux.Sink[payload.Payload]) {
+			for _, w := range words {
+				sink.Next(payload.New([]byte(w)))
+			}
+			sink.Complete()
+		})
+	})
+}
+
+func request(index uint32, data string) payload.Payload {
+	var metadata [8]byte
+	binary.BigEndian.PutUint32(metadata[:], index)
+	return payload.New([]byte(data), metadata[:])
+}
+
+// capture records the frames of the client of a pipe
+// while the server makes requests to it.
+func capture(t *testing.T) []tap.Event {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var out bytes.Buffer
+	recorder := tap.NewRecorder(&out)
+	server := handler.New(ctx, handler.ServerMode)
+	client := handler.New(ctx, handler.ClientMode)
+	_, _, err := rsocket.Pipe(ctx, server, client, rsocket.WithFrameObserver(recorder))
+	require.NoError(t, err)
+
+	upper := server.ImportRequestResponse(testNamespace, "upper")
+	result, err := server.RequestResponse(ctx, request(upper, "hello")).Block()
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO", string(result.Data()))
+
+	split := server.ImportRequestStream(testNamespace, "split")
+	var words []string
+	err = server.RequestStream(ctx, request(split, "a b c")).Block(flux.Subscribe[payload.Payload]{
+		OnNext: func(p payload.Payload) { words = append(words, string(p.Data())) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, words)
+
+	require.NoError(t, recorder.Close())
+	events, err := tap.ReadAll(&out)
+	require.NoError(t, err)
+	return events
+}
+
+func TestRun(t *testing.T) {
+	events := capture(t)
+
+	target := handler.New(context.Background(), handler.ClientMode)
+	target.SetFrameSender(func(f frames.Frame) error { return nil })
+	result, err := replay.Run(context.Background(), target, events, replay.WithTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+	assert.Len(t, result.Expected, 5)
+	assert.Len(t, result.Actual, 5)
+	assert.Empty(t, result.Differences)
+}
+
+func TestDiff(t *testing.T) {
+	event := func(f frames.Frame) tap.Event {
+		return tap.NewEvent(tap.Outbound, f)
+	}
+	expected := []tap.Event{
+		event(&frames.Payload{StreamID: 2, Data: []byte("a"), Next: true}),
+		event(&frames.Payload{StreamID: 4, Data: []byte("b"), Next: true, Complete: true}),
+		event(&frames.Payload{StreamID: 2, Complete: true}),
+	}
+	actual := []tap.Event{
+		// Streams are compared independently of each other.
+		event(&frames.Payload{StreamID: 4, Data: []byte("B"), Next: true, Complete: true}),
+		event(&frames.Payload{StreamID: 2, Data: []byte("a"), Next: true}),
+		event(&frames.Error{StreamID: 6, Code: frames.ErrCodeInvalid, Data: "not_found"}),
+	}
+
+	diffs := replay.Diff(expected, actual)
+	require.Len(t, diffs, 3)
+
+	assert.Equal(t, uint32(2), diffs[0].StreamID)
+	assert.Equal(t, expected[2].Frame, diffs[0].Expected.Frame)
+	assert.Nil(t, diffs[0].Actual)
+
+	assert.Equal(t, uint32(4), diffs[1].StreamID)
+	assert.Equal(t, expected[1].Frame, diffs[1].Expected.Frame)
+	assert.Equal(t, actual[0].Frame, diffs[1].Actual.Frame)
+
+	assert.Equal(t, uint32(6), diffs[2].StreamID)
+	assert.Nil(t, diffs[2].Expected)
+	assert.Equal(t, actual[2].Frame, diffs[2].Actual.Frame)
+}
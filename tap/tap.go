@@ -0,0 +1,106 @@
+// Package tap observes the frames sent and received on a connection,
+// such as between a host and a WebAssembly guest or between two RSocket
+// peers, to debug wire traffic.
+package tap
+
+import (
+	"time"
+
+	"github.com/nanobus/iota/go/internal/frames"
+)
+
+// Frame types that observers can inspect.
+type (
+	Frame          = frames.Frame
+	FrameType      = frames.FrameType
+	FrameFlag      = frames.FrameFlag
+	Setup          = frames.Setup
+	Keepalive      = frames.Keepalive
+	RequestPayload = frames.RequestPayload
+	RequestN       = frames.RequestN
+	Cancel         = frames.Cancel
+	Payload        = frames.Payload
+	Error          = frames.Error
+	Ext            = frames.Ext
+)
+
+// Direction is whether a frame was received or sent.
+type Direction uint8
+
+const (
+	// Inbound frames are received from the peer.
+	Inbound Direction = iota
+	// Outbound frames are sent to the peer.
+	Outbound
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Inbound:
+		return "IN"
+	case Outbound:
+		return "OUT"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Event is a frame seen by a FrameObserver.
+type Event struct {
+	Time      time.Time
+	Direction Direction
+	Frame     Frame
+}
+
+// NewEvent creates an event for a frame that is received or sent now.
+func NewEvent(direction Direction, f Frame) Event {
+	return Event{
+		Time:      time.Now(),
+		Direction: direction,
+		Frame:     f,
+	}
+}
+
+// StreamID returns the stream ID of the frame.
+func (e Event) StreamID() uint32 {
+	return e.Frame.GetStreamID()
+}
+
+// Type returns the type of the frame.
+func (e Event) Type() FrameType {
+	return e.Frame.Type()
+}
+
+// Flags returns the flags of the frame.
+func (e Event) Flags() FrameFlag {
+	return e.Frame.Flags()
+}
+
+// Size returns the encoded size of the frame without its length prefix.
+func (e Event) Size() uint32 {
+	return e.Frame.Size()
+}
+
+// FrameObserver sees every frame received and sent on a connection.
+// ObserveFrame is called from the goroutines that receive and send
+// frames, so it must be safe for concurrent use and return quickly.
+// The metadata and data of the frame are only valid until it returns.
+type FrameObserver interface {
+	ObserveFrame(e Event)
+}
+
+// FrameObserverFunc is a function that observes frames.
+type FrameObserverFunc func(e Event)
+
+func (fn FrameObserverFunc) ObserveFrame(e Event) {
+	fn(e)
+}
+
+// Multi returns an observer that passes each frame to all observers.
+func Multi(observers ...FrameObserver) FrameObserver {
+	return FrameObserverFunc(func(e Event) {
+		for _, o := range observers {
+			o.ObserveFrame(e)
+		}
+	})
+}
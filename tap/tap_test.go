@@ -0,0 +1,175 @@
+package tap_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/tap"
+)
+
+var observedAt = time.Date(2023, 1, 2, 12, 1, 2, 3000, time.UTC)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		direction tap.Direction
+		frame     tap.Frame
+		expected  string
+	}{
+		{
+			name:      "request stream",
+			direction: tap.Outbound,
+			frame: &frames.RequestPayload{
+				FrameType: frames.FrameTypeRequestStream,
+				StreamID:  1,
+				Metadata:  []byte("metadata"),
+				Data:      []byte("data"),
+				InitialN:  10,
+			},
+			expected: "12:01:02.000003 OUT stream=1 REQUEST_STREAM flags=M size=25 initialN=10 metadata=8 data=4",
+		},
+		{
+			name:      "payload",
+			direction: tap.Inbound,
+			frame: &frames.Payload{
+				StreamID: 1,
+				Data:     []byte("data"),
+				Next:     true,
+				Complete: true,
+			},
+			expected: "12:01:02.000003 IN stream=1 PAYLOAD flags=N|CL size=10 metadata=0 data=4",
+		},
+		{
+			name:      "request n",
+			direction: tap.Inbound,
+			frame:     &frames.RequestN{StreamID: 3, N: 5},
+			expected:  "12:01:02.000003 IN stream=3 REQUEST_N size=10 n=5",
+		},
+		{
+			name:      "error",
+			direction: tap.Outbound,
+			frame: &frames.Error{
+				StreamID: 3,
+				Code:     frames.ErrCodeInvalid,
+				Data:     "not_found",
+			},
+			expected: `12:01:02.000003 OUT stream=3 ERROR size=19 code=INVALID data="not_found"`,
+		},
+		{
+			name:      "ext",
+			direction: tap.Outbound,
+			frame: &frames.Ext{
+				ExtendedType: 7,
+				Data:         []byte("data"),
+				Ignore:       true,
+			},
+			expected: "12:01:02.000003 OUT stream=0 EXT flags=I size=14 extendedType=7 metadata=0 data=4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tap.Event{Time: observedAt, Direction: tt.direction, Frame: tt.frame}
+			assert.Equal(t, tt.expected, tap.Format(e))
+		})
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	var out bytes.Buffer
+	p := tap.NewPrinter(&out)
+	p.ObserveFrame(tap.Event{Time: observedAt, Direction: tap.Inbound, Frame: &frames.Cancel{StreamID: 1}})
+	assert.Equal(t, "12:01:02.000003 IN stream=1 CANCEL size=6\n", out.String())
+}
+
+func TestRecorder(t *testing.T) {
+	var out bytes.Buffer
+	r := tap.NewRecorder(&out)
+	f := &frames.Payload{StreamID: 1, Metadata: []byte{}, Data: []byte("data"), Next: true}
+	r.ObserveFrame(tap.Event{Time: observedAt, Direction: tap.Outbound, Frame: f})
+	require.NoError(t, r.Close())
+
+	b := out.Bytes()
+	require.Len(t, b, 6+12+10)
+	assert.Equal(t, "RSCAP\x01", string(b[:6]))
+	assert.Equal(t, byte(tap.Outbound), b[6])
+	assert.Equal(t, uint64(observedAt.UnixNano()), binary.BigEndian.Uint64(b[7:]))
+	assert.Equal(t, []byte{0, 0, 10}, b[15:18])
+	decoded, err := frames.Decode(b[18:])
+	require.NoError(t, err)
+	assert.Equal(t, f, decoded)
+}
+
+func TestMulti(t *testing.T) {
+	var calls int
+	count := tap.FrameObserverFunc(func(tap.Event) { calls++ })
+	tap.Multi(count, count).ObserveFrame(tap.NewEvent(tap.Inbound, &frames.Cancel{StreamID: 1}))
+	assert.Equal(t, 2, calls)
+}
+
+func TestReadAll(t *testing.T) {
+	var out bytes.Buffer
+	r := tap.NewRecorder(&out)
+	recorded := []tap.Event{
+		{Time: observedAt, Direction: tap.Inbound, Frame: &frames.RequestN{StreamID: 1, N: 5}},
+		{Time: observedAt.Add(time.Millisecond), Direction: tap.Outbound, Frame: &frames.Cancel{StreamID: 1}},
+	}
+	for _, e := range recorded {
+		r.ObserveFrame(e)
+	}
+	require.NoError(t, r.Close())
+
+	events, err := tap.ReadAll(&out)
+	require.NoError(t, err)
+	require.Len(t, events, len(recorded))
+	for i, e := range events {
+		assert.True(t, recorded[i].Time.Equal(e.Time))
+		assert.Equal(t, recorded[i].Direction, e.Direction)
+		assert.Equal(t, recorded[i].Frame, e.Frame)
+	}
+}
+
+func TestReadAllInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"empty":     "",
+		"magic":     "PCAP\x00\x01",
+		"version":   "RSCAP\x02",
+		"truncated": "RSCAP\x01\x00\x00\x00",
+		"direction": "RSCAP\x01\x07\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := tap.ReadAll(bytes.NewBufferString(data))
+			assert.ErrorIs(t, err, tap.ErrInvalidCapture)
+		})
+	}
+}
+
+// leaseFrame is a LEASE frame, which frames.Decode does not support.
+type leaseFrame struct {
+	frames.Cancel
+}
+
+func (f *leaseFrame) Type() frames.FrameType {
+	return 0x02
+}
+
+func (f *leaseFrame) Encode(buffer []byte) {
+	copy(buffer, []byte{0, 0, 0, 0, 0x02 << 2, 0})
+}
+
+func TestClone(t *testing.T) {
+	payload := []byte("data")
+	e := tap.Event{Time: observedAt, Direction: tap.Inbound, Frame: &frames.Payload{StreamID: 1, Data: payload, Next: true}}
+	clone, err := tap.Clone(e)
+	require.NoError(t, err)
+	payload[0] = 'D'
+	assert.Equal(t, []byte("data"), clone.Frame.(*frames.Payload).Data)
+
+	_, err = tap.Clone(tap.Event{Time: observedAt, Direction: tap.Inbound, Frame: &leaseFrame{}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/nanobus/iota/go/metadata"
+	"github.com/nanobus/iota/go/payload"
+)
+
+// JSON returns a transform that encodes values as JSON.
+func JSON[T any]() Transform[T] {
+	return Transform[T]{
+		Decode: JSONDecode[T],
+		Encode: JSONEncode[T],
+	}
+}
+
+func JSONDecode[T any](raw payload.Payload) (value T, err error) {
+	err = json.Unmarshal(raw.Data(), &value)
+	return value, err
+}
+
+func JSONEncode[T any](value T) (payload.Payload, error) {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return payload.New(buf), nil
+}
+
+// Negotiate returns the transform for the data MIME type of the
+// connection a request arrived on. It is JSON when the client
+// announced metadata.MimeTypeJSON in SETUP and t otherwise.
+func Negotiate[T any](ctx context.Context, t Transform[T]) Transform[T] {
+	if m, ok := metadata.MimeTypesFromContext(ctx); ok && m.Data == metadata.MimeTypeJSON {
+		return JSON[T]()
+	}
+	return t
+}
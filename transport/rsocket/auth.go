@@ -0,0 +1,187 @@
+package rsocket
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/metadata"
+	"github.com/nanobus/iota/go/payload"
+)
+
+// Setup is the SETUP frame a client opens a connection with.
+type Setup = frames.Setup
+
+// SetupAcceptor is called by a server with the SETUP frame of a new
+// connection and the remote address of the client. It returns the
+// principal of the client, which handlers get from PeerFromContext.
+// An empty principal keeps the one from the client certificate or
+// SETUP metadata. If an error is returned, the connection is rejected
+// with ErrCodeRejectedSetup and the error message as the reason, unless
+// the error is a *payload.Error with another code.
+type SetupAcceptor func(ctx context.Context, setup *Setup, addr string) (principal string, err error)
+
+const (
+	// AuthTypeHMAC is the authentication type sent by clients using
+	// WithHMAC.
+	AuthTypeHMAC = "hmac"
+	// AuthTypePrincipal is the authentication type sent by clients
+	// using WithPrincipal. Its payload is the unauthenticated principal.
+	AuthTypePrincipal = "principal"
+)
+
+var (
+	// ErrMissingCredentials rejects clients that sent no
+	// authentication metadata in SETUP.
+	ErrMissingCredentials = payload.NewError(payload.ErrCodeRejectedSetup, "missing credentials")
+	// ErrInvalidCredentials rejects clients whose credentials
+	// could not be verified.
+	ErrInvalidCredentials = payload.NewError(payload.ErrCodeRejectedSetup, "invalid credentials")
+)
+
+// SetupAuthentication returns the authentication metadata of a SETUP
+// frame. With composite metadata it is the authentication entry.
+// Otherwise the whole metadata is the authentication entry content.
+func SetupAuthentication(setup *Setup) (metadata.Authentication, bool) {
+	if len(setup.Metadata) == 0 {
+		return metadata.Authentication{}, false
+	}
+	if setup.MimeMetadata == metadata.MimeTypeComposite {
+		c, err := metadata.ParseComposite(setup.Metadata)
+		if err != nil {
+			return metadata.Authentication{}, false
+		}
+		return c.Authentication()
+	}
+	auth, err := metadata.ParseAuthentication(setup.Metadata)
+	return auth, err == nil
+}
+
+// BearerTokenVerifier creates a SetupAcceptor that passes the bearer
+// token sent with WithBearerToken to verify, which returns the
+// principal the token belongs to.
+func BearerTokenVerifier(verify func(ctx context.Context, token string) (principal string, err error)) SetupAcceptor {
+	return func(ctx context.Context, setup *Setup, addr string) (string, error) {
+		auth, ok := SetupAuthentication(setup)
+		if !ok {
+			return "", ErrMissingCredentials
+		}
+		token, ok := auth.BearerToken()
+		if !ok {
+			return "", ErrMissingCredentials
+		}
+		return verify(ctx, token)
+	}
+}
+
+// StaticBearerTokens creates a SetupAcceptor that accepts the tokens
+// in the map and uses the mapped value as the principal.
+func StaticBearerTokens(tokens map[string]string) SetupAcceptor {
+	return BearerTokenVerifier(func(ctx context.Context, token string) (string, error) {
+		for t, principal := range tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				return principal, nil
+			}
+		}
+		return "", ErrInvalidCredentials
+	})
+}
+
+// HMACVerifier creates a SetupAcceptor for clients using WithHMAC with
+// the same key. The signature covers the principal and the time the
+// SETUP frame was created, which must be within maxSkew of the
+// server's clock so that captured frames cannot be replayed later.
+func HMACVerifier(key []byte, maxSkew time.Duration) SetupAcceptor {
+	return func(ctx context.Context, setup *Setup, addr string) (string, error) {
+		auth, ok := SetupAuthentication(setup)
+		if !ok || auth.Type != AuthTypeHMAC {
+			return "", ErrMissingCredentials
+		}
+
+		// The payload is "principal:timestamp:signature".
+		message, signature, ok := cutLast(string(auth.Payload), ':')
+		if !ok {
+			return "", ErrInvalidCredentials
+		}
+		principal, timestamp, ok := cutLast(message, ':')
+		if !ok {
+			return "", ErrInvalidCredentials
+		}
+		sig, err := base64.RawURLEncoding.DecodeString(signature)
+		if err != nil || !hmac.Equal(sig, signHMAC(key, message)) {
+			return "", ErrInvalidCredentials
+		}
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return "", ErrInvalidCredentials
+		}
+		if skew := time.Since(time.Unix(seconds, 0)); skew > maxSkew || skew < -maxSkew {
+			return "", payload.NewError(payload.ErrCodeRejectedSetup, "credentials expired")
+		}
+
+		return principal, nil
+	}
+}
+
+// SetupPrincipal returns the principal a client sent with WithPrincipal
+// in the authentication metadata of its SETUP frame.
+func SetupPrincipal(setup *Setup) (string, bool) {
+	auth, ok := SetupAuthentication(setup)
+	if !ok || auth.Type != AuthTypePrincipal || len(auth.Payload) == 0 {
+		return "", false
+	}
+	return string(auth.Payload), true
+}
+
+// principalAuthentication creates the authentication metadata sent by
+// WithPrincipal.
+func principalAuthentication(principal string) metadata.Authentication {
+	return metadata.Authentication{
+		Type:    AuthTypePrincipal,
+		Payload: []byte(principal),
+	}
+}
+
+// hmacAuthentication creates the authentication metadata sent by WithHMAC.
+func hmacAuthentication(principal string, key []byte, now time.Time) metadata.Authentication {
+	message := principal + ":" + strconv.FormatInt(now.Unix(), 10)
+	signature := base64.RawURLEncoding.EncodeToString(signHMAC(key, message))
+	return metadata.Authentication{
+		Type:    AuthTypeHMAC,
+		Payload: []byte(message + ":" + signature),
+	}
+}
+
+func signHMAC(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+func cutLast(s string, sep byte) (before, after string, found bool) {
+	if i := strings.LastIndexByte(s, sep); i >= 0 {
+		return s[:i], s[i+1:], true
+	}
+	return s, "", false
+}
+
+// setupError creates the ERROR frame that rejects a connection
+// because its SETUP frame was not accepted.
+func setupError(err error) *frames.Error {
+	code := frames.ErrCodeRejectedSetup
+	var perr *payload.Error
+	if errors.As(err, &perr) && perr.Code >= payload.ErrCodeInvalidSetup && perr.Code <= payload.ErrCodeRejectedSetup {
+		code = frames.ErrCode(perr.Code)
+	}
+	return &frames.Error{
+		Code: code,
+		Data: err.Error(),
+	}
+}
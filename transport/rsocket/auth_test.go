@@ -0,0 +1,99 @@
+package rsocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/metadata"
+	"github.com/nanobus/iota/go/payload"
+)
+
+// rejected connects a client to a server that is expected to reject its
+// SETUP frame and returns the error the client received.
+func rejected(t *testing.T, serverOpts, clientOpts []Option) *payload.Error {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := NewServer(func(ctx context.Context, caller invoke.Caller, onClose func(*Transport)) {
+		t.Error("rejected connection was accepted")
+	}, append([]Option{WithAddress("127.0.0.1:0")}, serverOpts...)...)
+	notifier := make(chan bool, 1)
+	go server.Listen(ctx, notifier)
+	require.True(t, <-notifier, "server is not listening")
+	defer server.Close()
+	addr := server.(*tcpServerTransport).l.Addr().String()
+
+	clientOpts = append([]Option{WithAddress(addr)}, clientOpts...)
+	h := Handler(ctx)
+	tp, err := NewClient(ctx, h, clientOpts...)
+	require.NoError(t, err)
+	o := newOptions("", clientOpts)
+	require.NoError(t, tp.Send(newSetup(&o), true))
+
+	err = tp.Start(ctx)
+	var perr *payload.Error
+	require.ErrorAs(t, err, &perr)
+	return perr
+}
+
+func TestSetupAcceptorPrincipal(t *testing.T) {
+	acceptor := func(ctx context.Context, setup *Setup, addr string) (string, error) {
+		assert.NotEmpty(t, addr)
+		return "svc:" + setup.MimeData, nil
+	}
+	principal := whoami(t, []Option{WithSetupAcceptor(acceptor)}, nil)
+	assert.Equal(t, "svc:"+metadata.MimeTypeMsgPack, principal)
+}
+
+func TestSetupAcceptorReject(t *testing.T) {
+	acceptor := func(ctx context.Context, setup *Setup, addr string) (string, error) {
+		return "", payload.NewError(payload.ErrCodeRejectedSetup, "go away")
+	}
+	err := rejected(t, []Option{WithSetupAcceptor(acceptor)}, nil)
+	assert.Equal(t, payload.ErrCodeRejectedSetup, err.Code)
+	assert.Equal(t, "go away", err.Message)
+}
+
+func TestBearerToken(t *testing.T) {
+	acceptor := StaticBearerTokens(map[string]string{"secret": "billing"})
+
+	principal := whoami(t, []Option{WithSetupAcceptor(acceptor)}, []Option{WithBearerToken("secret")})
+	assert.Equal(t, "billing", principal)
+
+	err := rejected(t, []Option{WithSetupAcceptor(acceptor)}, []Option{WithBearerToken("wrong")})
+	assert.Equal(t, payload.ErrCodeRejectedSetup, err.Code)
+	assert.Equal(t, ErrInvalidCredentials.Message, err.Message)
+
+	err = rejected(t, []Option{WithSetupAcceptor(acceptor)}, nil)
+	assert.Equal(t, ErrMissingCredentials.Message, err.Message)
+}
+
+func TestBearerTokenComposite(t *testing.T) {
+	acceptor := StaticBearerTokens(map[string]string{"secret": "billing"})
+
+	o := newOptions("", []Option{WithBearerToken("secret"), WithMetadataMimeType(metadata.MimeTypeComposite)})
+	principal, err := acceptor(context.Background(), newSetup(&o), "")
+	require.NoError(t, err)
+	assert.Equal(t, "billing", principal)
+}
+
+func TestHMAC(t *testing.T) {
+	key := []byte("shared key")
+	acceptor := HMACVerifier(key, time.Minute)
+
+	principal := whoami(t, []Option{WithSetupAcceptor(acceptor)}, []Option{WithHMAC("orders:v1", key)})
+	assert.Equal(t, "orders:v1", principal)
+
+	err := rejected(t, []Option{WithSetupAcceptor(acceptor)}, []Option{WithHMAC("orders:v1", []byte("other key"))})
+	assert.Equal(t, ErrInvalidCredentials.Message, err.Message)
+
+	setup := Setup{Metadata: hmacAuthentication("orders:v1", key, time.Now().Add(-time.Hour)).Encode()}
+	_, verr := acceptor(context.Background(), &setup, "")
+	assert.EqualError(t, verr, "credentials expired")
+}
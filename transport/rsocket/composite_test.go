@@ -0,0 +1,84 @@
+package rsocket_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/handler"
+	"github.com/nanobus/iota/go/metadata"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/transport/rsocket"
+)
+
+func connectComposite(t *testing.T) *handler.Handler {
+	t.Helper()
+	registerHandlers()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	server := handler.New(ctx, handler.ServerMode)
+	client := handler.New(ctx, handler.ClientMode)
+	_, _, err := rsocket.Pipe(ctx, server, client,
+		rsocket.WithMetadataMimeType(metadata.MimeTypeComposite))
+	require.NoError(t, err)
+
+	return server
+}
+
+func compositeRequest(t *testing.T, data string, b *metadata.Builder) payload.Payload {
+	t.Helper()
+	md, err := b.Build()
+	require.NoError(t, err)
+	return payload.New([]byte(data), md)
+}
+
+func TestCompositeRoute(t *testing.T) {
+	server := connectComposite(t)
+
+	p := compositeRequest(t, "by name", metadata.NewBuilder().
+		Route(metadata.Route(testNamespace, "echo")))
+	result, err := server.RequestResponse(context.Background(), p).Block()
+	require.NoError(t, err)
+	assert.Equal(t, "BY NAME", string(result.Data()))
+
+	values, err := collect(t, server.RequestStream(context.Background(), compositeRequest(t, "",
+		metadata.NewBuilder().Route(metadata.Route(testNamespace, "count")))))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "three"}, values)
+}
+
+func TestCompositeOperationIndex(t *testing.T) {
+	server := connectComposite(t)
+	op := server.ImportRequestResponse(testNamespace, "echo")
+
+	p := compositeRequest(t, "by index", metadata.NewBuilder().
+		OperationIndex(op).
+		BearerToken("secret"))
+	result, err := server.RequestResponse(context.Background(), p).Block()
+	require.NoError(t, err)
+	assert.Equal(t, "BY INDEX", string(result.Data()))
+}
+
+func TestCompositeRouteNotFound(t *testing.T) {
+	server := connectComposite(t)
+
+	p := compositeRequest(t, "", metadata.NewBuilder().
+		Route(metadata.Route(testNamespace, "missing")))
+	_, err := server.RequestResponse(context.Background(), p).Block()
+	require.Error(t, err)
+	assert.Equal(t, "not_found", err.Error())
+	assertErrCode(t, payload.ErrCodeInvalid, err)
+}
+
+func TestCompositeMissingRoute(t *testing.T) {
+	server := connectComposite(t)
+
+	p := compositeRequest(t, "", metadata.NewBuilder().BearerToken("secret"))
+	_, err := server.RequestResponse(context.Background(), p).Block()
+	require.Error(t, err)
+	assert.Equal(t, "Invalid metadata", err.Error())
+}
@@ -16,7 +16,7 @@ const (
 )
 
 // ErrIncompleteHeader is error of incomplete header.
-var ErrIncompleteHeader = errors.New("incomplete frame header")
+var ErrIncompleteHeader = frames.ErrIncompleteHeader
 
 // from core, errors.go
 var ErrInvalidFrameLength = errors.New("rsocket: invalid frame length")
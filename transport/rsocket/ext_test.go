@@ -0,0 +1,63 @@
+package rsocket_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/handler"
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/operations"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/transport/rsocket"
+)
+
+func TestExtension(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sc, cc := rsocket.NewPipeConns()
+	server := handler.New(ctx, handler.ServerMode)
+	st := rsocket.NewTransport(sc, server, true)
+	server.SetFrameSender(func(f frames.Frame) error {
+		return st.Send(f, true)
+	})
+	// Extension 7 is answered with extension 8.
+	server.SetExtensionHandler(7, func(ctx context.Context, streamID uint32, p payload.Payload) {
+		data := append([]byte("ack "), p.Data()...)
+		_ = server.SendExtension(streamID, 8, payload.New(data), false)
+	})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- st.Start(ctx)
+	}()
+
+	go func() {
+		_ = cc.Write(&frames.Setup{MinorVersion: 2, Data: operations.Table{}.ToBytes()})
+		_ = cc.Write(&frames.Ext{ExtendedType: 7, Data: []byte("hibernate")})
+		_ = cc.Write(&frames.Ext{StreamID: 3, ExtendedType: 9, Ignore: true})
+		_ = cc.Write(&frames.Ext{StreamID: 5, ExtendedType: 10})
+		_ = cc.Write(&frames.Ext{ExtendedType: 11})
+		_ = cc.Flush()
+	}()
+
+	f, err := cc.Read()
+	require.NoError(t, err)
+	ext, ok := f.(*frames.Ext)
+	require.True(t, ok, "expected an EXT frame, got %T", f)
+	assert.Equal(t, uint32(8), ext.ExtendedType)
+	assert.Equal(t, "ack hibernate", string(ext.Data))
+
+	// Extension 9 is ignored and extension 10 is rejected.
+	f, err = cc.Read()
+	require.NoError(t, err)
+	assert.Equal(t, &frames.Error{StreamID: 5, Code: frames.ErrCodeRejected, Data: "unsupported extension 10"}, f)
+
+	// Extension 11 on stream 0 is a connection error.
+	f, err = cc.Read()
+	require.NoError(t, err)
+	assert.Equal(t, &frames.Error{Code: frames.ErrCodeConnectionError, Data: "unsupported extension 11"}, f)
+	assert.Error(t, <-errCh)
+}
@@ -7,15 +7,15 @@ import (
 	"github.com/nanobus/iota/go/handler"
 	"github.com/nanobus/iota/go/internal/frames"
 	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/metadata"
 )
 
-func NewServer(acceptor ServerTransportAcceptor) ServerTransport {
-	network := getEnvOrDefault("RSOCKET_NETWORK", "tcp")
-	addr := getEnvOrDefault("RSOCKET_ADDRESS", ":7878")
-	lf := NewTCPListenerFactory(network, addr, nil)
+func NewServer(acceptor ServerTransportAcceptor, opts ...Option) ServerTransport {
+	o := newOptions(":7878", opts)
+	lf := NewTCPListenerFactory(o.network, o.address, o.tlsConfig)
 	server := NewTCPServerTransport(lf, func(ctx context.Context) (DuplexHandler, error) {
 		return handler.New(ctx, handler.ServerMode), nil
-	})
+	}, opts...)
 	server.Accept(acceptor)
 
 	return server
@@ -25,15 +25,18 @@ func Handler(ctx context.Context) *handler.Handler {
 	return handler.New(ctx, handler.ClientMode)
 }
 
-func NewClient(ctx context.Context, h *handler.Handler) (*Transport, error) {
-	network := getEnvOrDefault("RSOCKET_NETWORK", "tcp")
-	addr := getEnvOrDefault("RSOCKET_ADDRESS", "127.0.0.1:7878")
-	conn, err := NewConnWithAddr(ctx, network, addr, nil)
+func NewClient(ctx context.Context, h *handler.Handler, opts ...Option) (*Transport, error) {
+	o := newOptions("127.0.0.1:7878", opts)
+	conn, err := NewConnWithAddr(ctx, o.network, o.address, o.tlsConfig)
 	if err != nil {
 		return nil, err
 	}
 
 	t := NewTCPClientTransport(conn, h)
+	t.SetKeepaliveInterval(o.keepaliveInterval)
+	t.SetLifetime(o.keepaliveMaxLifetime)
+	t.SetFrameObserver(o.frameObserver)
+	h.SetMimeTypes(o.mimeTypes())
 	h.SetFrameSender(func(f frames.Frame) error {
 		return t.Send(f, true)
 	})
@@ -41,25 +44,64 @@ func NewClient(ctx context.Context, h *handler.Handler) (*Transport, error) {
 	return t, nil
 }
 
-func Connect(h *handler.Handler) error {
+func Connect(h *handler.Handler, opts ...Option) error {
 	ctx := context.Background()
-	t, err := NewClient(ctx, h)
+	t, err := NewClient(ctx, h, opts...)
 	if err != nil {
 		return err
 	}
 
-	// Send operation list in setup frame.
-	list := invoke.GetOperationsTable()
-	payload := list.ToBytes()
-	t.Send(&frames.Setup{
-		MajorVersion: 0,
-		MinorVersion: 2,
-		Data:         payload,
-	}, true)
+	o := newOptions("", opts)
+	if err = t.Send(newSetup(&o), true); err != nil {
+		_ = t.Close()
+		return err
+	}
 
 	return t.Start(ctx)
 }
 
+// newSetup creates the SETUP frame a client sends to announce
+// its operation list.
+func newSetup(o *options) *frames.Setup {
+	data := o.setupData
+	if data == nil {
+		list := invoke.GetOperationsTable()
+		data = list.ToBytes()
+	}
+	return &frames.Setup{
+		MajorVersion:         handler.MajorVersion,
+		MinorVersion:         handler.MinorVersion,
+		TimeBetweenKeepalive: o.keepaliveInterval,
+		MaxLifetime:          o.keepaliveMaxLifetime,
+		MimeMetadata:         o.metadataMimeType,
+		MimeData:             o.dataMimeType,
+		Metadata:             setupMetadata(o),
+		Data:                 data,
+	}
+}
+
+// setupMetadata returns the authentication metadata of the client,
+// which carries its principal if it has no other credentials.
+func setupMetadata(o *options) []byte {
+	var auth metadata.Authentication
+	switch {
+	case o.authentication != nil:
+		auth = o.authentication()
+	case o.principal != "":
+		auth = principalAuthentication(o.principal)
+	default:
+		return nil
+	}
+	if o.metadataMimeType != metadata.MimeTypeComposite {
+		return auth.Encode()
+	}
+	md, err := metadata.NewBuilder().Authentication(auth).Build()
+	if err != nil {
+		return nil
+	}
+	return md
+}
+
 func getEnvOrDefault(key string, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -0,0 +1,128 @@
+package rsocket_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/handler"
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/operations"
+	"github.com/nanobus/iota/go/transport/rsocket"
+)
+
+// rawFrame writes bytes that need not decode as a frame.
+type rawFrame []byte
+
+func (f rawFrame) GetStreamID() uint32                                 { return frames.ParseFrameHeader(f).StreamID() }
+func (f rawFrame) Type() frames.FrameType                              { return frames.ParseFrameHeader(f).Type() }
+func (f rawFrame) Flags() frames.FrameFlag                             { return frames.ParseFrameHeader(f).Flag() }
+func (f rawFrame) Decode(header *frames.FrameHeader, raw []byte) error { return nil }
+func (f rawFrame) Size() uint32                                        { return uint32(len(f)) }
+func (f rawFrame) Encode(buf []byte)                                   { copy(buf, f) }
+
+func TestInvalidFrame(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sc, cc := rsocket.NewPipeConns()
+	server := handler.New(ctx, handler.ServerMode)
+	st := rsocket.NewTransport(sc, server, true)
+	server.SetFrameSender(func(f frames.Frame) error {
+		return st.Send(f, true)
+	})
+	go func() {
+		_ = st.Start(ctx)
+	}()
+
+	// A REQUEST_N frame without N.
+	header := frames.NewFrameHeader(3, frames.FrameTypeRequestN, 0)
+	go func() {
+		_ = cc.Write(&frames.Setup{MinorVersion: 2, Data: operations.Table{}.ToBytes()})
+		_ = cc.Write(rawFrame(header.Bytes()))
+		_ = cc.Write(&frames.Keepalive{Respond: true, Data: []byte("ping")})
+		_ = cc.Flush()
+	}()
+	f, err := cc.Read()
+	require.NoError(t, err)
+	e, ok := f.(*frames.Error)
+	require.True(t, ok, "expected an ERROR frame, got %T", f)
+	assert.Equal(t, uint32(3), e.StreamID)
+	assert.Equal(t, frames.ErrCodeInvalid, e.Code)
+	assert.Equal(t, "invalid REQUEST_N frame: request N is truncated", e.Data)
+
+	// The connection stays open.
+	f, err = cc.Read()
+	require.NoError(t, err)
+	keepalive, ok := f.(*frames.Keepalive)
+	require.True(t, ok, "expected a KEEPALIVE frame, got %T", f)
+	assert.Equal(t, "ping", string(keepalive.Data))
+}
+
+func TestInvalidSetupFrame(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sc, cc := rsocket.NewPipeConns()
+	server := handler.New(ctx, handler.ServerMode)
+	st := rsocket.NewTransport(sc, server, true)
+	server.SetFrameSender(func(f frames.Frame) error {
+		return st.Send(f, true)
+	})
+	go func() {
+		_ = st.Start(ctx)
+	}()
+
+	// A SETUP frame without its version.
+	header := frames.NewFrameHeader(0, frames.FrameTypeSetup, 0)
+	go func() {
+		_ = cc.Write(rawFrame(header.Bytes()))
+		_ = cc.Flush()
+	}()
+	f, err := cc.Read()
+	require.NoError(t, err)
+	e, ok := f.(*frames.Error)
+	require.True(t, ok, "expected an ERROR frame, got %T", f)
+	assert.Equal(t, uint32(0), e.StreamID)
+	assert.Equal(t, frames.ErrCodeInvalidSetup, e.Code)
+
+	_, err = cc.Read()
+	assert.Error(t, err)
+}
+
+func TestInvalidConnectionFrame(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sc, cc := rsocket.NewPipeConns()
+	server := handler.New(ctx, handler.ServerMode)
+	st := rsocket.NewTransport(sc, server, true)
+	server.SetFrameSender(func(f frames.Frame) error {
+		return st.Send(f, true)
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- st.Start(ctx)
+	}()
+
+	// A KEEPALIVE frame without its last received position.
+	header := frames.NewFrameHeader(0, frames.FrameTypeKeepalive, 0)
+	go func() {
+		_ = cc.Write(&frames.Setup{MinorVersion: 2, Data: operations.Table{}.ToBytes()})
+		_ = cc.Write(rawFrame(header.Bytes()))
+		_ = cc.Flush()
+	}()
+	f, err := cc.Read()
+	require.NoError(t, err)
+	e, ok := f.(*frames.Error)
+	require.True(t, ok, "expected an ERROR frame, got %T", f)
+	assert.Equal(t, uint32(0), e.StreamID)
+	assert.Equal(t, frames.ErrCodeConnectionError, e.Code)
+
+	// The connection is closed.
+	assert.ErrorIs(t, <-done, frames.ErrInvalidFrame)
+	_, err = cc.Read()
+	assert.Error(t, err)
+}
@@ -0,0 +1,210 @@
+package rsocket
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/nanobus/iota/go/handler"
+	"github.com/nanobus/iota/go/metadata"
+	"github.com/nanobus/iota/go/tap"
+)
+
+// Option configures servers and clients created by NewServer, NewClient,
+// Connect, NewReconnectingClient and Pipe. Options take precedence over the RSOCKET_NETWORK and
+// RSOCKET_ADDRESS environment variables.
+type Option func(*options)
+
+type options struct {
+	network              string
+	address              string
+	tlsConfig            *tls.Config
+	handshakeTimeout     time.Duration
+	setupData            []byte
+	keepaliveInterval    time.Duration
+	keepaliveMaxLifetime time.Duration
+	principal            string
+	setupPrincipal       bool
+	minBackoff           time.Duration
+	maxBackoff           time.Duration
+	requestQueue         int
+	onStateChange        func(ConnectionState)
+	metadataMimeType     string
+	dataMimeType         string
+	setupAcceptor        SetupAcceptor
+	authentication       func() metadata.Authentication
+	frameObserver        tap.FrameObserver
+}
+
+// WithNetwork sets the network to listen on or dial, such as "tcp",
+// "tcp4", "tcp6" or "unix". On Linux, a "unix" address starting with
+// "@" refers to the abstract socket namespace.
+func WithNetwork(network string) Option {
+	return func(o *options) {
+		o.network = network
+	}
+}
+
+// WithAddress sets the address to listen on or dial. For unix sockets
+// this is the path of the socket file.
+func WithAddress(address string) Option {
+	return func(o *options) {
+		o.address = address
+	}
+}
+
+// WithTLS enables TLS using the given configuration.
+func WithTLS(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config
+	}
+}
+
+// WithSetupData sets the data a client sends in its SETUP frame.
+// By default this is the operations table returned by
+// invoke.GetOperationsTable.
+func WithSetupData(data []byte) Option {
+	return func(o *options) {
+		o.setupData = data
+	}
+}
+
+// WithKeepalive enables KEEPALIVE frames from a client every interval.
+// The connection is closed if nothing is received from the peer within
+// maxLifetime. Servers use the values sent by the client in SETUP.
+func WithKeepalive(interval, maxLifetime time.Duration) Option {
+	return func(o *options) {
+		o.keepaliveInterval = interval
+		o.keepaliveMaxLifetime = maxLifetime
+	}
+}
+
+// WithPrincipal sets the principal a client sends in the authentication
+// metadata of its SETUP frame, with the AuthTypePrincipal type. It is
+// not sent if other credentials are configured.
+func WithPrincipal(principal string) Option {
+	return func(o *options) {
+		o.principal = principal
+	}
+}
+
+// WithSetupPrincipal makes a server accept the principal sent with
+// WithPrincipal when the client did not present a verified certificate. The
+// principal is not authenticated, so only enable this for trusted
+// networks such as unix sockets shared with a sidecar.
+func WithSetupPrincipal() Option {
+	return func(o *options) {
+		o.setupPrincipal = true
+	}
+}
+
+// WithSetupAcceptor makes a server call acceptor with the SETUP frame of
+// each new connection to authenticate the client or reject it.
+func WithSetupAcceptor(acceptor SetupAcceptor) Option {
+	return func(o *options) {
+		o.setupAcceptor = acceptor
+	}
+}
+
+// WithBearerToken makes a client send a bearer token in its SETUP
+// metadata for servers using BearerTokenVerifier.
+func WithBearerToken(token string) Option {
+	return func(o *options) {
+		o.authentication = func() metadata.Authentication {
+			return metadata.BearerAuthentication(token)
+		}
+	}
+}
+
+// WithHMAC makes a client send its principal signed with key in its
+// SETUP metadata for servers using HMACVerifier.
+func WithHMAC(principal string, key []byte) Option {
+	return func(o *options) {
+		o.authentication = func() metadata.Authentication {
+			return hmacAuthentication(principal, key, time.Now())
+		}
+	}
+}
+
+// WithMetadataMimeType sets the MIME type of request metadata a client
+// announces in SETUP. With metadata.MimeTypeComposite, requests carry
+// composite metadata and can be routed by name.
+func WithMetadataMimeType(mimeType string) Option {
+	return func(o *options) {
+		o.metadataMimeType = mimeType
+	}
+}
+
+// WithDataMimeType sets the MIME type of request data a client announces
+// in SETUP, such as metadata.MimeTypeJSON. The default is
+// metadata.MimeTypeMsgPack.
+func WithDataMimeType(mimeType string) Option {
+	return func(o *options) {
+		o.dataMimeType = mimeType
+	}
+}
+
+// WithFrameObserver sets an observer that sees every frame read from and
+// written to connections, such as a tap.Printer or a tap.Recorder. Servers
+// pass the frames of all connections to it. Pipe observes the frames of
+// the client transport.
+func WithFrameObserver(observer tap.FrameObserver) Option {
+	return func(o *options) {
+		o.frameObserver = observer
+	}
+}
+
+// WithBackoff sets the delay bounds between reconnect attempts of a
+// ReconnectingClient. The delay starts at min and doubles after each
+// failed attempt up to max.
+func WithBackoff(min, max time.Duration) Option {
+	return func(o *options) {
+		o.minBackoff = min
+		o.maxBackoff = max
+	}
+}
+
+// WithRequestQueue makes a ReconnectingClient queue up to size requests
+// while it is disconnected instead of failing them with ErrNotConnected.
+func WithRequestQueue(size int) Option {
+	return func(o *options) {
+		o.requestQueue = size
+	}
+}
+
+// WithHandshakeTimeout sets how long a server waits for TLS handshakes.
+func WithHandshakeTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.handshakeTimeout = timeout
+	}
+}
+
+// WithStateChange registers a callback that a ReconnectingClient calls
+// when its connection state changes.
+func WithStateChange(fn func(ConnectionState)) Option {
+	return func(o *options) {
+		o.onStateChange = fn
+	}
+}
+
+func newOptions(defaultAddress string, opts []Option) options {
+	o := options{
+		network:              getEnvOrDefault("RSOCKET_NETWORK", "tcp"),
+		address:              getEnvOrDefault("RSOCKET_ADDRESS", defaultAddress),
+		keepaliveMaxLifetime: DefaultKeepaliveMaxLifetime,
+		handshakeTimeout:     DefaultHandshakeTimeout,
+		dataMimeType:         handler.DefaultDataMimeType,
+		minBackoff:           DefaultMinBackoff,
+		maxBackoff:           DefaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o *options) mimeTypes() metadata.MimeTypes {
+	return metadata.MimeTypes{
+		Metadata: o.metadataMimeType,
+		Data:     o.dataMimeType,
+	}
+}
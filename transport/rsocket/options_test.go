@@ -0,0 +1,115 @@
+package rsocket_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/handler"
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/transport/rsocket"
+)
+
+func roundTrip(t *testing.T, opts ...rsocket.Option) {
+	t.Helper()
+	registerHandlers()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make(chan string, 1)
+	server := rsocket.NewServer(func(ctx context.Context, caller invoke.Caller, onClose func(*rsocket.Transport)) {
+		op := caller.ImportRequestResponse(testNamespace, "echo")
+		result, err := caller.RequestResponse(ctx, request(op, "over the socket")).Block()
+		if err != nil {
+			results <- err.Error()
+			return
+		}
+		results <- string(result.Data())
+	}, opts...)
+
+	notifier := make(chan bool, 1)
+	go server.Listen(ctx, notifier)
+	require.True(t, <-notifier, "server is not listening")
+	defer server.Close()
+
+	go rsocket.Connect(rsocket.Handler(ctx), opts...)
+
+	select {
+	case result := <-results:
+		assert.Equal(t, "OVER THE SOCKET", result)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for round trip")
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rsocket.sock")
+	roundTrip(t, rsocket.WithNetwork("unix"), rsocket.WithAddress(path))
+}
+
+func TestUnixSocketStaleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rsocket.sock")
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, l.Close())
+	_, err = os.Stat(path)
+	require.NoError(t, err, "expected a stale socket file")
+
+	roundTrip(t, rsocket.WithNetwork("unix"), rsocket.WithAddress(path))
+}
+
+func TestAbstractUnixSocket(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("abstract unix sockets are only supported on Linux")
+	}
+	addr := "@rsocket-test-" + strconv.Itoa(os.Getpid())
+	roundTrip(t, rsocket.WithNetwork("unix"), rsocket.WithAddress(addr))
+}
+
+func TestKeepalive(t *testing.T) {
+	registerHandlers()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := handler.New(ctx, handler.ServerMode)
+	client := handler.New(ctx, handler.ClientMode)
+	_, _, err := rsocket.Pipe(ctx, server, client,
+		rsocket.WithKeepalive(10*time.Millisecond, 50*time.Millisecond))
+	require.NoError(t, err)
+
+	// Idle for several lifetimes. Keepalives hold the connection open.
+	time.Sleep(200 * time.Millisecond)
+
+	op := server.ImportRequestResponse(testNamespace, "echo")
+	result, err := server.RequestResponse(ctx, request(op, "still here")).Block()
+	require.NoError(t, err)
+	assert.Equal(t, "STILL HERE", string(result.Data()))
+}
+
+func TestUnixSocketInUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rsocket.sock")
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer l.Close()
+
+	listen := rsocket.NewTCPListenerFactory("unix", path, nil)
+	_, err = listen(context.Background())
+	assert.ErrorIs(t, err, syscall.EADDRINUSE)
+
+	// The live server keeps its socket.
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	conn.Close()
+}
@@ -0,0 +1,67 @@
+package rsocket
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"time"
+)
+
+// Peer describes the remote side of a server connection.
+type Peer struct {
+	// Addr is the remote address of the connection.
+	Addr string
+	// Certificate is the verified client certificate when mutual TLS is
+	// used. Its Subject, DNSNames, URIs and EmailAddresses identify the
+	// client.
+	Certificate *x509.Certificate
+	// Principal identifies the client. It is the common name of the
+	// verified certificate or, if enabled with WithSetupPrincipal, the
+	// principal sent by the client with WithPrincipal.
+	Principal string
+}
+
+type peerKey struct{}
+
+// PeerFromContext returns the peer of the connection a request arrived on.
+// It is available from the context passed to invoke handler functions.
+func PeerFromContext(ctx context.Context) (*Peer, bool) {
+	p, ok := ctx.Value(peerKey{}).(*Peer)
+	return p, ok
+}
+
+// WithPeer returns a copy of ctx carrying peer.
+func WithPeer(ctx context.Context, peer *Peer) context.Context {
+	return context.WithValue(ctx, peerKey{}, peer)
+}
+
+// newPeer creates the peer for a server connection. For TLS connections
+// the handshake is completed first so the client certificate is known.
+// A handshake that does not complete within timeout fails, so stalled
+// clients do not hold on to the connection.
+func newPeer(ctx context.Context, c net.Conn, timeout time.Duration) (*Peer, error) {
+	p := Peer{
+		Addr: c.RemoteAddr().String(),
+	}
+
+	tc, ok := c.(*tls.Conn)
+	if !ok {
+		return &p, nil
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := tc.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+	state := tc.ConnectionState()
+	if len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+		p.Certificate = state.VerifiedChains[0][0]
+		p.Principal = p.Certificate.Subject.CommonName
+	}
+
+	return &p, nil
+}
@@ -0,0 +1,188 @@
+package rsocket
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/metadata"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx/mono"
+)
+
+var registerWhoami sync.Once
+
+// whoami starts a server, connects a client to it and has the client
+// call an operation that reports the peer principal seen by the server.
+func whoami(t *testing.T, serverOpts, clientOpts []Option) string {
+	t.Helper()
+	registerWhoami.Do(func() {
+		invoke.ExportRequestResponse("rsocket.peer", "whoami", func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+			peer, ok := PeerFromContext(ctx)
+			if !ok {
+				return mono.Just[payload.Payload](payload.New([]byte("<none>")))
+			}
+			return mono.Just[payload.Payload](payload.New([]byte(peer.Principal)))
+		})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := NewServer(func(ctx context.Context, caller invoke.Caller, onClose func(*Transport)) {},
+		append([]Option{WithAddress("127.0.0.1:0")}, serverOpts...)...)
+	notifier := make(chan bool, 1)
+	go server.Listen(ctx, notifier)
+	require.True(t, <-notifier, "server is not listening")
+	defer server.Close()
+	addr := server.(*tcpServerTransport).l.Addr().String()
+
+	clientOpts = append([]Option{WithAddress(addr)}, clientOpts...)
+	h := Handler(ctx)
+	index := h.ImportRequestResponse("rsocket.peer", "whoami")
+	tp, err := NewClient(ctx, h, clientOpts...)
+	require.NoError(t, err)
+	go tp.Start(ctx)
+	o := newOptions("", clientOpts)
+	require.NoError(t, tp.Send(newSetup(&o), true))
+
+	var metadata [8]byte
+	binary.BigEndian.PutUint32(metadata[:], index)
+	result, err := h.RequestResponse(ctx, payload.New(nil, metadata[:])).Block()
+	require.NoError(t, err)
+
+	return string(result.Data())
+}
+
+func TestPeerMutualTLS(t *testing.T) {
+	ca, caKey := newCertificate(t, "test-ca", nil, nil)
+	caCert := ca.Leaf
+	serverCert, _ := newCertificate(t, "localhost", caCert, caKey)
+	clientCert, _ := newCertificate(t, "billing-service", caCert, caKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	serverTLS := &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	clientTLS := &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      pool,
+		ServerName:   "localhost",
+	}
+
+	principal := whoami(t, []Option{WithTLS(serverTLS)}, []Option{WithTLS(clientTLS)})
+	assert.Equal(t, "billing-service", principal)
+}
+
+func TestPeerHandshakeTimeout(t *testing.T) {
+	serverCert, _ := newCertificate(t, "localhost", nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := NewServer(func(ctx context.Context, caller invoke.Caller, onClose func(*Transport)) {},
+		WithAddress("127.0.0.1:0"),
+		WithTLS(&tls.Config{Certificates: []tls.Certificate{serverCert}}),
+		WithHandshakeTimeout(50*time.Millisecond))
+	notifier := make(chan bool, 1)
+	go server.Listen(ctx, notifier)
+	require.True(t, <-notifier, "server is not listening")
+	defer server.Close()
+	addr := server.(*tcpServerTransport).l.Addr().String()
+
+	// The client never starts the handshake.
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err = conn.Read(make([]byte, 1))
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		assert.False(t, netErr.Timeout(), "the server did not close the connection")
+	}
+	assert.Error(t, err)
+}
+
+func TestPeerSetupPrincipal(t *testing.T) {
+	principal := whoami(t, []Option{WithSetupPrincipal()}, []Option{WithPrincipal("sidecar")})
+	assert.Equal(t, "sidecar", principal)
+}
+
+func TestSetupPrincipalComposite(t *testing.T) {
+	o := newOptions("", []Option{
+		WithPrincipal("sidecar"),
+		WithMetadataMimeType(metadata.MimeTypeComposite),
+	})
+	principal, ok := SetupPrincipal(newSetup(&o))
+	assert.True(t, ok)
+	assert.Equal(t, "sidecar", principal)
+}
+
+func TestPeerSetupPrincipalOtherCredentials(t *testing.T) {
+	principal := whoami(t, []Option{WithSetupPrincipal()}, []Option{WithBearerToken("sidecar")})
+	assert.Equal(t, "", principal)
+}
+
+func TestPeerSetupPrincipalNotTrusted(t *testing.T) {
+	principal := whoami(t, nil, []Option{WithPrincipal("sidecar")})
+	assert.Equal(t, "", principal)
+}
+
+// newCertificate creates a certificate for commonName signed by parent.
+// If parent is nil, a self-signed CA certificate is created.
+func newCertificate(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (tls.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{commonName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := &template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent, parentKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        cert,
+	}, key
+}
@@ -0,0 +1,73 @@
+package rsocket
+
+import (
+	"context"
+	"net"
+
+	"github.com/nanobus/iota/go/handler"
+	"github.com/nanobus/iota/go/internal/frames"
+)
+
+// NewPipeConns creates a pair of connected in-memory connections.
+// Frames written to one side are read from the other, so a client
+// and a server can talk RSocket without opening a socket.
+func NewPipeConns() (Conn, Conn) {
+	c1, c2 := net.Pipe()
+	return NewTCPConn(c1), NewTCPConn(c2)
+}
+
+// Pipe connects a server and a client handler inside one process.
+// The client sends its SETUP frame with the operations table and Pipe
+// returns once the server has processed it. Both transports are closed
+// when ctx is done. Network, address and TLS options do not apply.
+func Pipe(ctx context.Context, server, client *handler.Handler, opts ...Option) (*Transport, *Transport, error) {
+	o := newOptions("", opts)
+	sc, cc := NewPipeConns()
+
+	st := NewTransport(sc, server, true)
+	server.SetFrameSender(func(f frames.Frame) error {
+		return st.Send(f, true)
+	})
+
+	ct := NewTransport(cc, client, false)
+	ct.SetKeepaliveInterval(o.keepaliveInterval)
+	ct.SetLifetime(o.keepaliveMaxLifetime)
+	ct.SetFrameObserver(o.frameObserver)
+	client.SetMimeTypes(o.mimeTypes())
+	client.SetFrameSender(func(f frames.Frame) error {
+		return ct.Send(f, true)
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- st.Start(ctx)
+	}()
+	go ct.Start(ctx)
+
+	closeAll := func() {
+		_ = ct.Close()
+		_ = st.Close()
+	}
+
+	if err := ct.Send(newSetup(&o), true); err != nil {
+		closeAll()
+		return nil, nil, err
+	}
+
+	select {
+	case <-st.ready:
+	case err := <-errCh:
+		closeAll()
+		return nil, nil, err
+	case <-ctx.Done():
+		closeAll()
+		return nil, nil, ctx.Err()
+	}
+
+	go func() {
+		<-ctx.Done()
+		closeAll()
+	}()
+
+	return st, ct, nil
+}
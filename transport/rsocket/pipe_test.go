@@ -0,0 +1,269 @@
+package rsocket_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/handler"
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/invoke/apperror"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/transport/rsocket"
+)
+
+const testNamespace = "rsocket.test"
+
+var (
+	registerOnce sync.Once
+	fnfCh        = make(chan string, 1)
+	cancelCh     = make(chan struct{}, 1)
+)
+
+func registerHandlers() {
+	registerOnce.Do(func() {
+		invoke.ExportRequestResponse(testNamespace, "echo", func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+			return mono.Just[payload.Payload](payload.New(bytes.ToUpper(p.Data())))
+		})
+		invoke.ExportRequestResponse(testNamespace, "fail", func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+			return mono.Error[payload.Payload](errors.New("boom"))
+		})
+		invoke.ExportRequestResponse(testNamespace, "reject", func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+			return mono.Error[payload.Payload](payload.NewError(payload.ErrCodeRejected, "busy"))
+		})
+		invoke.ExportRequestResponse(testNamespace, "validate", func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+			return mono.Error[payload.Payload](apperror.New("invalid_argument", "name is required", map[string]any{
+				"field": "name",
+			}))
+		})
+		invoke.ExportFireAndForget(testNamespace, "notify", func(ctx context.Context, p payload.Payload) {
+			fnfCh <- string(p.Data())
+		})
+		invoke.ExportRequestStream(testNamespace, "count", func(ctx context.Context, p payload.Payload) flux.Flux[payload.Payload] {
+			return flux.FromSlice([]payload.Payload{
+				payload.New([]byte("one")),
+				payload.New([]byte("two")),
+				payload.New([]byte("three")),
+			})
+		})
+		invoke.ExportRequestStream(testNamespace, "failStream", func(ctx context.Context, p payload.Payload) flux.Flux[payload.Payload] {
+			return flux.Create(func(sink flux.Sink[payload.Payload]) {
+				sink.OnSubscribe(flux.OnSubscribe{
+					Request: func(n int) {
+						sink.Next(payload.New([]byte("one")))
+						sink.Error(errors.New("stream boom"))
+					},
+				})
+			})
+		})
+		invoke.ExportRequestStream(testNamespace, "infinite", func(ctx context.Context, p payload.Payload) flux.Flux[payload.Payload] {
+			return flux.Create(func(sink flux.Sink[payload.Payload]) {
+				sink.OnSubscribe(flux.OnSubscribe{
+					Request: func(n int) {
+						for i := 0; i < n; i++ {
+							sink.Next(payload.New([]byte("tick")))
+						}
+					},
+					Cancel: func() {
+						cancelCh <- struct{}{}
+					},
+				})
+			})
+		})
+		invoke.ExportRequestChannel(testNamespace, "upper", func(ctx context.Context, p payload.Payload, in flux.Flux[payload.Payload]) flux.Flux[payload.Payload] {
+			return flux.Map(in, func(p payload.Payload) (payload.Payload, error) {
+				return payload.New(bytes.ToUpper(p.Data())), nil
+			})
+		})
+	})
+}
+
+func connect(t *testing.T) *handler.Handler {
+	t.Helper()
+	registerHandlers()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	server := handler.New(ctx, handler.ServerMode)
+	client := handler.New(ctx, handler.ClientMode)
+	_, _, err := rsocket.Pipe(ctx, server, client)
+	require.NoError(t, err)
+
+	return server
+}
+
+func request(index uint32, data string) payload.Payload {
+	var metadata [8]byte
+	binary.BigEndian.PutUint32(metadata[:], index)
+	return payload.New([]byte(data), metadata[:])
+}
+
+func collect(t *testing.T, f flux.Flux[payload.Payload]) ([]string, error) {
+	t.Helper()
+	var values []string
+	err := f.Block(flux.Subscribe[payload.Payload]{
+		OnNext: func(p payload.Payload) {
+			values = append(values, string(p.Data()))
+		},
+	})
+	return values, err
+}
+
+func assertErrCode(t *testing.T, expected payload.ErrCode, err error) {
+	t.Helper()
+	var perr *payload.Error
+	require.True(t, errors.As(err, &perr), "expected a *payload.Error, got %T", err)
+	assert.Equal(t, expected, perr.Code)
+}
+
+func TestPipeRequestResponse(t *testing.T) {
+	server := connect(t)
+	op := server.ImportRequestResponse(testNamespace, "echo")
+
+	result, err := server.RequestResponse(context.Background(), request(op, "hello")).Block()
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO", string(result.Data()))
+}
+
+func TestPipeRequestResponseError(t *testing.T) {
+	server := connect(t)
+	op := server.ImportRequestResponse(testNamespace, "fail")
+
+	_, err := server.RequestResponse(context.Background(), request(op, "hello")).Block()
+	require.Error(t, err)
+	assert.Equal(t, "boom", err.Error())
+	assertErrCode(t, payload.ErrCodeApplicationError, err)
+}
+
+func TestPipeRequestResponseRejected(t *testing.T) {
+	server := connect(t)
+	op := server.ImportRequestResponse(testNamespace, "reject")
+
+	_, err := server.RequestResponse(context.Background(), request(op, "hello")).Block()
+	require.Error(t, err)
+	assert.Equal(t, "busy", err.Error())
+	assertErrCode(t, payload.ErrCodeRejected, err)
+}
+
+func TestPipeRequestResponseStructuredError(t *testing.T) {
+	server := connect(t)
+	op := server.ImportRequestResponse(testNamespace, "validate")
+
+	_, err := server.RequestResponse(context.Background(), request(op, "")).Block()
+	var ierr *apperror.Error
+	require.True(t, errors.As(err, &ierr), "expected an *apperror.Error, got %T", err)
+	assert.Equal(t, "invalid_argument", ierr.Code)
+	assert.Equal(t, "name is required", ierr.Message)
+	assert.Equal(t, map[string]any{"field": "name"}, ierr.Details)
+	assertErrCode(t, payload.ErrCodeApplicationError, err)
+}
+
+func TestPipeRequestResponseNotFound(t *testing.T) {
+	server := connect(t)
+
+	_, err := server.RequestResponse(context.Background(), request(9999, "hello")).Block()
+	require.Error(t, err)
+	assert.Equal(t, "not_found", err.Error())
+	assertErrCode(t, payload.ErrCodeInvalid, err)
+}
+
+func TestPipeRequestResponseInvalidMetadata(t *testing.T) {
+	server := connect(t)
+
+	_, err := server.RequestResponse(context.Background(), payload.New([]byte("hello"))).Block()
+	require.Error(t, err)
+	assert.Equal(t, "Invalid metadata", err.Error())
+	assertErrCode(t, payload.ErrCodeInvalid, err)
+}
+
+func TestPipeFireAndForget(t *testing.T) {
+	server := connect(t)
+	op := server.ImportFireAndForget(testNamespace, "notify")
+
+	server.FireAndForget(context.Background(), request(op, "ping"))
+	select {
+	case got := <-fnfCh:
+		assert.Equal(t, "ping", got)
+	case <-time.After(time.Second):
+		t.Fatal("fire and forget was not delivered")
+	}
+}
+
+func TestPipeRequestStream(t *testing.T) {
+	server := connect(t)
+	op := server.ImportRequestStream(testNamespace, "count")
+
+	values, err := collect(t, server.RequestStream(context.Background(), request(op, "")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "three"}, values)
+}
+
+func TestPipeRequestStreamError(t *testing.T) {
+	server := connect(t)
+	op := server.ImportRequestStream(testNamespace, "failStream")
+
+	values, err := collect(t, server.RequestStream(context.Background(), request(op, "")))
+	require.Error(t, err)
+	assert.Equal(t, "stream boom", err.Error())
+	assertErrCode(t, payload.ErrCodeApplicationError, err)
+	assert.Equal(t, []string{"one"}, values)
+}
+
+func TestPipeRequestStreamCancel(t *testing.T) {
+	server := connect(t)
+	op := server.ImportRequestStream(testNamespace, "infinite")
+
+	received := make(chan struct{}, 2)
+	var sub rx.Subscription
+	server.RequestStream(context.Background(), request(op, "")).Subscribe(flux.Subscribe[payload.Payload]{
+		OnNext: func(p payload.Payload) {
+			received <- struct{}{}
+		},
+		OnRequest: func(s rx.Subscription) {
+			if sub == nil {
+				sub = s
+				s.Request(2)
+			}
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatal("expected stream values")
+		}
+	}
+	sub.Cancel()
+
+	select {
+	case <-cancelCh:
+	case <-time.After(time.Second):
+		t.Fatal("cancel was not delivered to the responder")
+	}
+}
+
+func TestPipeRequestChannel(t *testing.T) {
+	server := connect(t)
+	op := server.ImportRequestChannel(testNamespace, "upper")
+
+	in := flux.FromSlice([]payload.Payload{
+		payload.New([]byte("a")),
+		payload.New([]byte("b")),
+		payload.New([]byte("c")),
+	})
+	values, err := collect(t, server.RequestChannel(context.Background(), request(op, ""), in))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A", "B", "C"}, values)
+}
@@ -0,0 +1,508 @@
+package rsocket
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nanobus/iota/go/handler"
+	"github.com/nanobus/iota/go/internal/common"
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/metadata"
+	"github.com/nanobus/iota/go/operations"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/mono"
+)
+
+var (
+	// ErrNotConnected is returned for requests made while the client
+	// is not connected and request queueing is disabled.
+	ErrNotConnected = errors.New("rsocket: not connected")
+	// ErrQueueFull is returned for requests made while the client is
+	// not connected and the request queue is full.
+	ErrQueueFull = errors.New("rsocket: request queue is full")
+	// ErrConnectionLost is returned for requests that were in flight
+	// when the connection was lost.
+	ErrConnectionLost = errors.New("rsocket: connection lost")
+	// ErrClientClosed is returned for requests made after Close.
+	ErrClientClosed = errors.New("rsocket: client closed")
+)
+
+const (
+	// DefaultMinBackoff is the default delay before the first reconnect.
+	DefaultMinBackoff = 100 * time.Millisecond
+	// DefaultMaxBackoff is the default upper bound of the reconnect delay.
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// ConnectionState is the state of a ReconnectingClient.
+type ConnectionState int
+
+const (
+	// Connecting means the client is dialing the server.
+	Connecting ConnectionState = iota
+	// Connected means requests are sent to the server.
+	Connected
+	// Disconnected means the client is waiting before it reconnects.
+	Disconnected
+	// Closed means Close was called. The client does not reconnect.
+	Closed
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case Connecting:
+		return "connecting"
+	case Connected:
+		return "connected"
+	case Disconnected:
+		return "disconnected"
+	case Closed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// ReconnectingClient is an invoke.Caller that keeps a connection to a
+// server open. When the connection is lost it is re-dialed with
+// jittered exponential backoff and the SETUP frame, including the
+// operations table, is sent again.
+//
+// Requests made while disconnected fail with ErrNotConnected unless a
+// request queue is configured with WithRequestQueue. Queued requests are
+// sent once the client reconnects. Fire-and-forget requests have no way
+// to report an error, so they are always queued while disconnected until
+// their context is done, within the limit of the request queue if one
+// is configured. Requests in flight when the connection is lost fail
+// with ErrConnectionLost. Once the server announces that it is shutting
+// down, new requests are handled as if the client was disconnected.
+//
+// The server links imports by the operations table sent in SETUP. A
+// request for an import registered after SETUP makes the client drain
+// the connection and reconnect to announce it. Requests made meanwhile
+// wait for the new connection.
+type ReconnectingClient struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	opts   []Option
+	o      options
+	done   chan struct{}
+
+	mu        sync.Mutex
+	state     ConnectionState
+	handler   *handler.Handler
+	transport *Transport
+	queue     []*pendingRequest
+	// imports are the imports announced in the SETUP frame of the
+	// current connection, or nil if SETUP data is not an operations table.
+	imports map[importKey]struct{}
+	// reannouncing is set while the client reconnects to announce imports
+	// registered after SETUP.
+	reannouncing bool
+}
+
+type importKey struct {
+	requestType operations.RequestType
+	index       uint32
+}
+
+type pendingRequest struct {
+	ctx  context.Context
+	fn   func(*handler.Handler, error)
+	once sync.Once
+	done chan struct{}
+}
+
+func (r *pendingRequest) resolve(h *handler.Handler, err error) {
+	r.once.Do(func() {
+		close(r.done)
+		r.fn(h, err)
+	})
+}
+
+// NewReconnectingClient creates a client and starts connecting in the
+// background. Use Close to disconnect and stop reconnecting.
+func NewReconnectingClient(ctx context.Context, opts ...Option) *ReconnectingClient {
+	ctx, cancel := context.WithCancel(ctx)
+	c := ReconnectingClient{
+		ctx:    ctx,
+		cancel: cancel,
+		opts:   opts,
+		o:      newOptions("127.0.0.1:7878", opts),
+		done:   make(chan struct{}),
+		state:  Disconnected,
+	}
+	go c.run()
+	return &c
+}
+
+// State returns the current connection state.
+func (c *ReconnectingClient) State() ConnectionState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Close closes the connection and fails queued requests
+// with ErrClientClosed.
+func (c *ReconnectingClient) Close() error {
+	c.cancel()
+	<-c.done
+	return nil
+}
+
+func (c *ReconnectingClient) run() {
+	defer func() {
+		c.mu.Lock()
+		queue := c.queue
+		c.queue = nil
+		c.mu.Unlock()
+		for _, r := range queue {
+			r.resolve(nil, ErrClientClosed)
+		}
+		c.setState(Closed, nil)
+		close(c.done)
+	}()
+
+	for attempt := 0; ; attempt++ {
+		c.setState(Connecting, nil)
+		if c.connect() {
+			attempt = 0
+		} else {
+			c.endReannounce()
+		}
+		if c.ctx.Err() != nil {
+			return
+		}
+		if c.isReannouncing() {
+			// Reconnect right away to announce new imports.
+			continue
+		}
+		c.setState(Disconnected, nil)
+
+		timer := time.NewTimer(c.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-c.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// connect dials the server and serves the connection until it is lost.
+// It returns true if the connection was established.
+func (c *ReconnectingClient) connect() bool {
+	h := handler.New(c.ctx, handler.ClientMode)
+	t, err := NewClient(c.ctx, h, c.opts...)
+	if err != nil {
+		return false
+	}
+	setup := newSetup(&c.o)
+	if err = t.Send(setup, true); err != nil {
+		_ = t.Close()
+		return false
+	}
+
+	c.mu.Lock()
+	c.transport = t
+	c.imports = announcedImports(setup)
+	c.mu.Unlock()
+	c.setState(Connected, h)
+	_ = t.Start(c.ctx)
+
+	c.mu.Lock()
+	c.handler = nil
+	c.transport = nil
+	c.mu.Unlock()
+	if c.ctx.Err() != nil {
+		h.Terminate(ErrClientClosed)
+	} else {
+		h.Terminate(ErrConnectionLost)
+	}
+
+	return true
+}
+
+// backoff returns the delay before the next connection attempt.
+// Half of the delay is random so that clients do not reconnect in step.
+func (c *ReconnectingClient) backoff(attempt int) time.Duration {
+	d := c.o.minBackoff
+	for i := 0; i < attempt && d < c.o.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > c.o.maxBackoff {
+		d = c.o.maxBackoff
+	}
+	half := int64(d / 2)
+	if half <= 0 {
+		return d
+	}
+	return time.Duration(half + common.RandInt63n(half+1))
+}
+
+func (c *ReconnectingClient) setState(state ConnectionState, h *handler.Handler) {
+	c.mu.Lock()
+	if c.state == state {
+		c.mu.Unlock()
+		return
+	}
+	c.state = state
+	var queue []*pendingRequest
+	if state == Connected {
+		c.handler = h
+		c.reannouncing = false
+		queue = c.queue
+		c.queue = nil
+	}
+	c.mu.Unlock()
+
+	if c.o.onStateChange != nil {
+		c.o.onStateChange(state)
+	}
+	for _, r := range queue {
+		if err := r.ctx.Err(); err != nil {
+			r.resolve(nil, err)
+		} else {
+			r.resolve(h, nil)
+		}
+	}
+}
+
+// current returns the handler of the current connection or nil.
+// A connection the server is closing does not take new requests.
+// It is called with mu held.
+func (c *ReconnectingClient) current() *handler.Handler {
+	if c.handler == nil || c.handler.Closing() || c.reannouncing {
+		return nil
+	}
+	return c.handler
+}
+
+// handlerFor returns the handler of the current connection for a
+// request of requestType, or nil if the client is not connected. If the
+// import that p calls was registered after the SETUP frame of the
+// connection, the client starts reconnecting to announce it and nil is
+// returned.
+func (c *ReconnectingClient) handlerFor(requestType operations.RequestType, p payload.Payload) *handler.Handler {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h := c.current()
+	if h == nil || c.announced(requestType, p) {
+		return h
+	}
+	c.reannouncing = true
+	t := c.transport
+	go func() {
+		// In-flight requests finish on the old connection.
+		_ = h.Shutdown(c.ctx)
+		_ = t.Close()
+	}()
+	return nil
+}
+
+// announced reports whether the import that p calls was announced in
+// the SETUP frame of the current connection. Requests that the server
+// cannot link either way, such as ones routed with composite metadata
+// or for unknown imports, count as announced. It is called with mu held.
+func (c *ReconnectingClient) announced(requestType operations.RequestType, p payload.Payload) bool {
+	md := p.Metadata()
+	if c.imports == nil || c.o.metadataMimeType == metadata.MimeTypeComposite || len(md) < 4 {
+		return true
+	}
+	key := importKey{requestType, binary.BigEndian.Uint32(md)}
+	if _, ok := c.imports[key]; ok {
+		return true
+	}
+	for _, op := range invoke.GetOperationsTable() {
+		if op.Direction == operations.Import && op.Type == key.requestType && op.Index == key.index {
+			return false
+		}
+	}
+	return true
+}
+
+// announcedImports returns the imports in the operations table of setup,
+// or nil if its data is not an operations table.
+func announcedImports(setup *frames.Setup) map[importKey]struct{} {
+	table, err := operations.FromBytes(setup.Data)
+	if err != nil {
+		return nil
+	}
+	imports := make(map[importKey]struct{})
+	for _, op := range table {
+		if op.Direction == operations.Import {
+			imports[importKey{op.Type, op.Index}] = struct{}{}
+		}
+	}
+	return imports
+}
+
+func (c *ReconnectingClient) isReannouncing() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reannouncing
+}
+
+// endReannounce stops waiting for a connection that announces new
+// imports. Requests made from now on are handled as if the client was
+// disconnected.
+func (c *ReconnectingClient) endReannounce() {
+	c.mu.Lock()
+	c.reannouncing = false
+	c.mu.Unlock()
+}
+
+// whenConnected calls fn with the handler of the current connection.
+// While disconnected, fn is queued until the client reconnects or is
+// called with an error if the request cannot be queued. If wait is
+// true, the request is queued even if no request queue is configured.
+func (c *ReconnectingClient) whenConnected(ctx context.Context, wait bool, fn func(*handler.Handler, error)) {
+	r := &pendingRequest{ctx: ctx, fn: fn, done: make(chan struct{})}
+
+	c.mu.Lock()
+	h := c.current()
+	var err error
+	switch {
+	case h != nil:
+	case c.state == Closed || c.ctx.Err() != nil:
+		err = ErrClientClosed
+	case c.reannouncing:
+		// The request waits for the imports to be announced.
+		c.queue = append(c.queue, r)
+		c.mu.Unlock()
+		go c.expire(r)
+		return
+	case c.o.requestQueue <= 0 && !wait:
+		err = ErrNotConnected
+	case c.o.requestQueue > 0 && len(c.queue) >= c.o.requestQueue:
+		err = ErrQueueFull
+	default:
+		c.queue = append(c.queue, r)
+		c.mu.Unlock()
+		go c.expire(r)
+		return
+	}
+	c.mu.Unlock()
+
+	r.resolve(h, err)
+}
+
+// expire fails a queued request when its context is done.
+func (c *ReconnectingClient) expire(r *pendingRequest) {
+	select {
+	case <-r.ctx.Done():
+	case <-r.done:
+		return
+	}
+
+	c.mu.Lock()
+	for i, q := range c.queue {
+		if q == r {
+			c.queue = append(c.queue[:i], c.queue[i+1:]...)
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	r.resolve(nil, r.ctx.Err())
+}
+
+func (c *ReconnectingClient) ImportRequestResponse(namespace, operation string) uint32 {
+	return invoke.ImportRequestResponse(namespace, operation)
+}
+
+func (c *ReconnectingClient) ImportFireAndForget(namespace, operation string) uint32 {
+	return invoke.ImportFireAndForget(namespace, operation)
+}
+
+func (c *ReconnectingClient) ImportRequestStream(namespace, operation string) uint32 {
+	return invoke.ImportRequestStream(namespace, operation)
+}
+
+func (c *ReconnectingClient) ImportRequestChannel(namespace, operation string) uint32 {
+	return invoke.ImportRequestChannel(namespace, operation)
+}
+
+func (c *ReconnectingClient) RequestResponse(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+	if h := c.handlerFor(operations.RequestResponse, p); h != nil {
+		return h.RequestResponse(ctx, p)
+	}
+	return mono.Create(func(sink mono.Sink[payload.Payload]) {
+		c.whenConnected(ctx, false, func(h *handler.Handler, err error) {
+			if err != nil {
+				sink.Error(err)
+				return
+			}
+			h.RequestResponse(ctx, p).Subscribe(mono.Subscribe[payload.Payload]{
+				OnSuccess: sink.Success,
+				OnError:   sink.Error,
+			})
+		})
+	})
+}
+
+func (c *ReconnectingClient) FireAndForget(ctx context.Context, p payload.Payload) {
+	if h := c.handlerFor(operations.FireAndForget, p); h != nil {
+		h.FireAndForget(ctx, p)
+		return
+	}
+	c.whenConnected(ctx, true, func(h *handler.Handler, err error) {
+		if err == nil {
+			h.FireAndForget(ctx, p)
+		}
+	})
+}
+
+func (c *ReconnectingClient) RequestStream(ctx context.Context, p payload.Payload) flux.Flux[payload.Payload] {
+	if h := c.handlerFor(operations.RequestStream, p); h != nil {
+		return h.RequestStream(ctx, p)
+	}
+	return flux.Create(func(sink flux.Sink[payload.Payload]) {
+		c.whenConnected(ctx, false, func(h *handler.Handler, err error) {
+			if err != nil {
+				sink.Error(err)
+				return
+			}
+			forward(h.RequestStream(ctx, p), sink)
+		})
+	})
+}
+
+func (c *ReconnectingClient) RequestChannel(ctx context.Context, p payload.Payload, in flux.Flux[payload.Payload]) flux.Flux[payload.Payload] {
+	if h := c.handlerFor(operations.RequestChannel, p); h != nil {
+		return h.RequestChannel(ctx, p, in)
+	}
+	return flux.Create(func(sink flux.Sink[payload.Payload]) {
+		c.whenConnected(ctx, false, func(h *handler.Handler, err error) {
+			if err != nil {
+				sink.Error(err)
+				return
+			}
+			forward(h.RequestChannel(ctx, p, in), sink)
+		})
+	})
+}
+
+// forward subscribes to f and relays its signals to sink.
+// Demand and cancellation from the sink's subscriber are passed to f.
+func forward(f flux.Flux[payload.Payload], sink flux.Sink[payload.Payload]) {
+	f.Subscribe(flux.Subscribe[payload.Payload]{
+		OnNext:     sink.Next,
+		OnComplete: sink.Complete,
+		OnError:    sink.Error,
+		NoRequest:  true,
+	})
+	sink.OnSubscribe(flux.OnSubscribe{
+		Request: func(n int) {
+			f.Subscription().Request(n)
+		},
+		Cancel: func() {
+			f.Subscription().Cancel()
+		},
+	})
+}
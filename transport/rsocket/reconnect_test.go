@@ -0,0 +1,200 @@
+package rsocket_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/transport/rsocket"
+)
+
+// freeAddress returns a local address that nothing is listening on.
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	return addr
+}
+
+func listen(t *testing.T, addr string) rsocket.ServerTransport {
+	t.Helper()
+	server := rsocket.NewServer(func(ctx context.Context, caller invoke.Caller, onClose func(*rsocket.Transport)) {},
+		rsocket.WithAddress(addr))
+	notifier := make(chan bool, 1)
+	go server.Listen(context.Background(), notifier)
+	require.True(t, <-notifier, "server is not listening")
+	return server
+}
+
+func waitForState(t *testing.T, states <-chan rsocket.ConnectionState, want rsocket.ConnectionState) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case state := <-states:
+			if state == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for state %s", want)
+		}
+	}
+}
+
+func TestReconnectingClientFailFast(t *testing.T) {
+	registerHandlers()
+
+	op := invoke.ImportRequestResponse(testNamespace, "echo")
+	client := rsocket.NewReconnectingClient(context.Background(),
+		rsocket.WithAddress(freeAddress(t)),
+		rsocket.WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+	defer client.Close()
+
+	_, err := client.RequestResponse(context.Background(), request(op, "hello")).Block()
+	assert.ErrorIs(t, err, rsocket.ErrNotConnected)
+}
+
+func TestReconnectingClientQueueFull(t *testing.T) {
+	registerHandlers()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	op := invoke.ImportRequestResponse(testNamespace, "echo")
+	client := rsocket.NewReconnectingClient(context.Background(),
+		rsocket.WithAddress(freeAddress(t)),
+		rsocket.WithBackoff(10*time.Millisecond, 50*time.Millisecond),
+		rsocket.WithRequestQueue(1))
+	defer client.Close()
+
+	queued := make(chan error, 1)
+	go func() {
+		_, err := client.RequestResponse(ctx, request(op, "first")).Block()
+		queued <- err
+	}()
+
+	// Wait for the first request to take the only queue slot.
+	require.Eventually(t, func() bool {
+		_, err := client.RequestResponse(ctx, request(op, "second")).Block()
+		return err == rsocket.ErrQueueFull
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-queued:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("queued request was not failed when its context was canceled")
+	}
+}
+
+func TestReconnectingClient(t *testing.T) {
+	registerHandlers()
+	addr := freeAddress(t)
+
+	// Imports are announced in SETUP so they are registered before connecting.
+	op := invoke.ImportRequestResponse(testNamespace, "echo")
+	states := make(chan rsocket.ConnectionState, 100)
+	client := rsocket.NewReconnectingClient(context.Background(),
+		rsocket.WithAddress(addr),
+		rsocket.WithBackoff(10*time.Millisecond, 50*time.Millisecond),
+		rsocket.WithRequestQueue(10),
+		rsocket.WithStateChange(func(state rsocket.ConnectionState) {
+			states <- state
+		}))
+
+	// Requests made before the server is up are queued.
+	results := make(chan string, 1)
+	go func() {
+		result, err := client.RequestResponse(context.Background(), request(op, "queued")).Block()
+		if err != nil {
+			results <- err.Error()
+			return
+		}
+		results <- string(result.Data())
+	}()
+	waitForState(t, states, rsocket.Disconnected)
+
+	server := listen(t, addr)
+	select {
+	case result := <-results:
+		assert.Equal(t, "QUEUED", result)
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued request was not sent")
+	}
+	assert.Equal(t, rsocket.Connected, client.State())
+
+	// Lose the connection and bring the server back.
+	require.NoError(t, server.Close())
+	waitForState(t, states, rsocket.Disconnected)
+	server = listen(t, addr)
+	defer server.Close()
+	waitForState(t, states, rsocket.Connected)
+
+	result, err := client.RequestResponse(context.Background(), request(op, "again")).Block()
+	require.NoError(t, err)
+	assert.Equal(t, "AGAIN", string(result.Data()))
+
+	require.NoError(t, client.Close())
+	waitForState(t, states, rsocket.Closed)
+	_, err = client.RequestResponse(context.Background(), request(op, "closed")).Block()
+	assert.ErrorIs(t, err, rsocket.ErrClientClosed)
+}
+
+func TestReconnectingClientLateImport(t *testing.T) {
+	registerHandlers()
+	invoke.ExportRequestResponse(testNamespace, "late", func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+		return mono.Just[payload.Payload](payload.New([]byte("late " + string(p.Data()))))
+	})
+	addr := freeAddress(t)
+	server := listen(t, addr)
+	defer server.Close()
+
+	states := make(chan rsocket.ConnectionState, 100)
+	client := rsocket.NewReconnectingClient(context.Background(),
+		rsocket.WithAddress(addr),
+		rsocket.WithBackoff(10*time.Millisecond, 50*time.Millisecond),
+		rsocket.WithStateChange(func(state rsocket.ConnectionState) {
+			states <- state
+		}))
+	defer client.Close()
+	waitForState(t, states, rsocket.Connected)
+
+	// The import was not announced in SETUP, so the client reconnects.
+	op := client.ImportRequestResponse(testNamespace, "late")
+	result, err := client.RequestResponse(context.Background(), request(op, "import")).Block()
+	require.NoError(t, err)
+	assert.Equal(t, "late import", string(result.Data()))
+}
+
+func TestReconnectingClientQueuesFireAndForget(t *testing.T) {
+	registerHandlers()
+	addr := freeAddress(t)
+
+	op := invoke.ImportFireAndForget(testNamespace, "notify")
+	client := rsocket.NewReconnectingClient(context.Background(),
+		rsocket.WithAddress(addr),
+		rsocket.WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+	defer client.Close()
+
+	// No request queue is configured, but the request is not dropped.
+	client.FireAndForget(context.Background(), request(op, "while disconnected"))
+
+	server := listen(t, addr)
+	defer server.Close()
+	select {
+	case data := <-fnfCh:
+		assert.Equal(t, "while disconnected", data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("fire-and-forget request was dropped")
+	}
+}
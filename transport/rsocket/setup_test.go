@@ -0,0 +1,149 @@
+package rsocket_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/handler"
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/metadata"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/transform"
+	"github.com/nanobus/iota/go/transport/rsocket"
+)
+
+var registerGreetOnce sync.Once
+
+// registerGreet exports an operation that uses the codec
+// of the data MIME type negotiated in SETUP.
+func registerGreet() {
+	registerGreetOnce.Do(func() {
+		invoke.ExportRequestResponse(testNamespace, "greet", func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+			t := transform.Negotiate(ctx, transform.String)
+			name, err := t.Decode(p)
+			if err != nil {
+				return mono.Error[payload.Payload](err)
+			}
+			result, err := t.Encode("Hello, " + name)
+			if err != nil {
+				return mono.Error[payload.Payload](err)
+			}
+			return mono.Just(result)
+		})
+	})
+}
+
+func TestSetupDataMimeType(t *testing.T) {
+	registerGreet()
+	for _, tc := range []struct {
+		mimeType string
+		codec    transform.Transform[string]
+	}{
+		{metadata.MimeTypeMsgPack, transform.String},
+		{metadata.MimeTypeJSON, transform.JSON[string]()},
+	} {
+		t.Run(tc.mimeType, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			server := handler.New(ctx, handler.ServerMode)
+			client := handler.New(ctx, handler.ClientMode)
+			_, _, err := rsocket.Pipe(ctx, server, client, rsocket.WithDataMimeType(tc.mimeType))
+			require.NoError(t, err)
+			assert.Equal(t, tc.mimeType, server.MimeTypes().Data)
+
+			data, err := tc.codec.Encode("world")
+			require.NoError(t, err)
+			op := server.ImportRequestResponse(testNamespace, "greet")
+			result, err := server.RequestResponse(ctx, request(op, string(data.Data()))).Block()
+			require.NoError(t, err)
+			greeting, err := tc.codec.Decode(result)
+			require.NoError(t, err)
+			assert.Equal(t, "Hello, world", greeting)
+		})
+	}
+}
+
+func TestSetupUnsupportedMimeType(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := handler.New(ctx, handler.ServerMode)
+	client := handler.New(ctx, handler.ClientMode)
+	_, _, err := rsocket.Pipe(ctx, server, client, rsocket.WithDataMimeType("text/plain"))
+	require.Error(t, err)
+	assertErrCode(t, payload.ErrCodeUnsupportedSetup, err)
+}
+
+// rejectSetup sends setup to a server and returns the ERROR frame it responds with.
+func rejectSetup(t *testing.T, setup *frames.Setup) *frames.Error {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sc, cc := rsocket.NewPipeConns()
+	server := handler.New(ctx, handler.ServerMode)
+	st := rsocket.NewTransport(sc, server, true)
+	server.SetFrameSender(func(f frames.Frame) error {
+		return st.Send(f, true)
+	})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- st.Start(ctx)
+	}()
+
+	go func() {
+		_ = cc.Write(setup)
+		_ = cc.Flush()
+	}()
+	f, err := cc.Read()
+	require.NoError(t, err)
+	e, ok := f.(*frames.Error)
+	require.True(t, ok, "expected an ERROR frame, got %T", f)
+	assert.Equal(t, uint32(0), e.StreamID)
+
+	// The server closes the connection.
+	assert.Error(t, <-errCh)
+	return e
+}
+
+func TestSetupRejected(t *testing.T) {
+	for name, tc := range map[string]struct {
+		setup frames.Setup
+		code  frames.ErrCode
+	}{
+		"major version": {
+			frames.Setup{MajorVersion: 1, MinorVersion: 0},
+			frames.ErrCodeUnsupportedSetup,
+		},
+		"minor version": {
+			frames.Setup{MajorVersion: 0, MinorVersion: 3},
+			frames.ErrCodeUnsupportedSetup,
+		},
+		"metadata MIME type": {
+			frames.Setup{MajorVersion: 0, MinorVersion: 2, MimeMetadata: "text/plain"},
+			frames.ErrCodeUnsupportedSetup,
+		},
+		"invalid MIME type": {
+			frames.Setup{MajorVersion: 0, MinorVersion: 2, MimeData: "application/json; charset=utf-8"},
+			frames.ErrCodeInvalidSetup,
+		},
+		"operations table": {
+			frames.Setup{MajorVersion: 0, MinorVersion: 2, Data: []byte{1, 2, 3}},
+			frames.ErrCodeInvalidSetup,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			setup := tc.setup
+			e := rejectSetup(t, &setup)
+			assert.Equal(t, tc.code, e.Code)
+			assert.NotEmpty(t, e.Data)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package rsocket_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/transport/rsocket"
+)
+
+var (
+	registerSlowOnce sync.Once
+	slowStarted      = make(chan struct{}, 1)
+	slowRelease      = make(chan struct{})
+)
+
+// connectSlow starts a server and a client and has the client call an
+// operation that does not respond until slowRelease is signaled.
+func connectSlow(t *testing.T) (rsocket.ServerTransport, *rsocket.ReconnectingClient, <-chan error) {
+	t.Helper()
+	registerSlowOnce.Do(func() {
+		invoke.ExportRequestResponse(testNamespace, "slow", func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+			return mono.Create(func(sink mono.Sink[payload.Payload]) {
+				slowStarted <- struct{}{}
+				<-slowRelease
+				sink.Success(payload.New([]byte("done")))
+			})
+		})
+	})
+
+	// Imports are announced in SETUP so they are registered before connecting.
+	op := invoke.ImportRequestResponse(testNamespace, "slow")
+	invoke.ImportRequestResponse(testNamespace, "echo")
+
+	addr := freeAddress(t)
+	server := listen(t, addr)
+
+	states := make(chan rsocket.ConnectionState, 100)
+	client := rsocket.NewReconnectingClient(context.Background(),
+		rsocket.WithAddress(addr),
+		rsocket.WithBackoff(time.Second, time.Second),
+		rsocket.WithStateChange(func(state rsocket.ConnectionState) {
+			states <- state
+		}))
+	t.Cleanup(func() { client.Close() })
+	waitForState(t, states, rsocket.Connected)
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := client.RequestResponse(context.Background(), request(op, "")).Block()
+		result <- err
+	}()
+	select {
+	case <-slowStarted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow request was not started")
+	}
+
+	return server, client, result
+}
+
+func TestShutdown(t *testing.T) {
+	server, client, result := connectSlow(t)
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- server.Shutdown(ctx)
+	}()
+
+	// The client stops starting requests on the closing connection.
+	op := client.ImportRequestResponse(testNamespace, "echo")
+	require.Eventually(t, func() bool {
+		_, err := client.RequestResponse(context.Background(), request(op, "hello")).Block()
+		return err == rsocket.ErrNotConnected
+	}, time.Second, 10*time.Millisecond)
+
+	// The outstanding request finishes before the connection is closed.
+	slowRelease <- struct{}{}
+	select {
+	case err := <-result:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("outstanding request did not finish")
+	}
+	select {
+	case err := <-shutdown:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown did not return")
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	server, _, result := connectSlow(t)
+	defer func() { slowRelease <- struct{}{} }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := server.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	select {
+	case err := <-result:
+		assert.ErrorIs(t, err, rsocket.ErrConnectionLost)
+	case <-time.After(5 * time.Second):
+		t.Fatal("outstanding request was not failed")
+	}
+}
+
+func TestShutdownDrainedAfterDeadline(t *testing.T) {
+	server := listen(t, freeAddress(t))
+
+	// Nothing is left to drain, so the expired context is not an error.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, server.Shutdown(ctx))
+}
@@ -0,0 +1,57 @@
+package rsocket_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/handler"
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/tap"
+	"github.com/nanobus/iota/go/transform"
+	"github.com/nanobus/iota/go/transport/rsocket"
+)
+
+type observedFrame struct {
+	direction tap.Direction
+	frameType frames.FrameType
+	streamID  uint32
+}
+
+func TestFrameObserver(t *testing.T) {
+	registerGreet()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		observed []observedFrame
+	)
+	observer := tap.FrameObserverFunc(func(e tap.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		observed = append(observed, observedFrame{e.Direction, e.Type(), e.StreamID()})
+	})
+
+	server := handler.New(ctx, handler.ServerMode)
+	client := handler.New(ctx, handler.ClientMode)
+	_, _, err := rsocket.Pipe(ctx, server, client, rsocket.WithFrameObserver(observer))
+	require.NoError(t, err)
+
+	data, err := transform.String.Encode("world")
+	require.NoError(t, err)
+	op := server.ImportRequestResponse(testNamespace, "greet")
+	_, err = server.RequestResponse(ctx, request(op, string(data.Data()))).Block()
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []observedFrame{
+		{tap.Outbound, frames.FrameTypeSetup, 0},
+		{tap.Inbound, frames.FrameTypeRequestResponse, 2},
+		{tap.Outbound, frames.FrameTypePayload, 2},
+	}, observed)
+}
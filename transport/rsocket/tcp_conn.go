@@ -7,6 +7,7 @@ import (
 	"net"
 	"time"
 
+	"github.com/nanobus/iota/go/internal/buffer"
 	"github.com/nanobus/iota/go/internal/frames"
 	"github.com/nanobus/iota/go/internal/u24"
 )
@@ -46,75 +47,41 @@ func (p *TCPConn) Read() (f frames.Frame, err error) {
 		return
 	}
 
-	header := frames.ParseFrameHeader(raw)
-	buffer := raw[frames.FrameHeaderLen:]
-
-	switch header.Type() {
-	case frames.FrameTypeSetup:
-		var setup frames.Setup
-		if err := setup.Decode(&header, buffer); err != nil {
-			return nil, err
-		}
-		return &setup, nil
-
-	case frames.FrameTypeRequestResponse, frames.FrameTypeRequestFNF,
-		frames.FrameTypeRequestStream, frames.FrameTypeRequestChannel:
-		var rr frames.RequestPayload
-		if err := rr.Decode(&header, buffer); err != nil {
-			return nil, err
-		}
-		return &rr, nil
-
-	case frames.FrameTypeRequestN:
-		var rn frames.RequestN
-		if err := rn.Decode(&header, buffer); err != nil {
-			return nil, err
-		}
-		return &rn, nil
-
-	case frames.FrameTypeCancel:
-		var cancel frames.Cancel
-		if err := cancel.Decode(&header, buffer); err != nil {
-			return nil, err
-		}
-		return &cancel, nil
-
-	case frames.FrameTypePayload:
-		var p frames.Payload
-		if err := p.Decode(&header, buffer); err != nil {
-			return nil, err
-		}
-		return &p, nil
-
-	case frames.FrameTypeError:
-		var e frames.Error
-		if err := e.Decode(&header, buffer); err != nil {
-			return nil, err
-		}
-		return &e, nil
-
-	default:
-		return nil, fmt.Errorf("unknown frame %d", header.Type())
-	}
+	// The decoder reuses its buffer for the next frame while this one
+	// is still being handled, so frames must not point into it.
+	raw = append([]byte(nil), raw...)
+	return frames.Decode(raw)
 }
 
+// vectoredWriteSize is the payload data size from which the data is
+// written directly to the connection after the rest of the frame, with a
+// single vectored write, instead of being copied into the write buffer.
+const vectoredWriteSize = 16 * 1024
+
 // Write writes a frame.
 func (p *TCPConn) Write(frame frames.Frame) (err error) {
 	size := frame.Size()
 	// if p.counter != nil && frame.Header().Resumable() {
 	// 	p.counter.IncWriteBytes(size)
 	// }
-	_, err = u24.MustNewUint24(size).WriteTo(p.writer)
-	if err != nil {
-		err = fmt.Errorf("write frame failed: %w", err)
-		return
+	data := frames.TrailingData(frame)
+	if len(data) < vectoredWriteSize {
+		data = nil
 	}
 
-	// TODO: remove alloc
-	buf := make([]byte, size)
-	frame.Encode(buf)
-	_, err = p.writer.Write(buf)
-	// _, err = frame.WriteTo(p.writer)
+	// Encode the length and the frame, except for data written separately.
+	buf := buffer.Get(lengthFieldSize + int(size) - len(data))
+	defer buf.Release()
+	b := buf.Bytes()
+	copy(b, u24.MustNewUint24(size).Bytes())
+	frame.Encode(b[lengthFieldSize:])
+
+	if data == nil {
+		_, err = p.writer.Write(b)
+	} else if err = p.writer.Flush(); err == nil {
+		bufs := net.Buffers{b, data}
+		_, err = bufs.WriteTo(p.conn)
+	}
 	if err != nil {
 		err = fmt.Errorf("write frame failed: %w", err)
 		return
@@ -0,0 +1,79 @@
+package rsocket_test
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/transport/rsocket"
+)
+
+func TestTCPConnWriteRead(t *testing.T) {
+	small := bytes.Repeat([]byte("s"), 100)
+	large := bytes.Repeat([]byte("l"), 64*1024)
+	sent := []*frames.Payload{
+		{StreamID: 1, Metadata: []byte("metadata"), Data: small, Next: true},
+		{StreamID: 3, Metadata: []byte("metadata"), Data: large, Next: true},
+		{StreamID: 5, Data: small, Complete: true},
+	}
+
+	c1, c2 := rsocket.NewPipeConns()
+	defer c1.Close()
+	defer c2.Close()
+	go func() {
+		for _, f := range sent {
+			if err := c1.Write(f); err != nil {
+				return
+			}
+			if err := c1.Flush(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Frames stay valid after the next frame is read.
+	var received []*frames.Payload
+	for range sent {
+		f, err := c2.Read()
+		require.NoError(t, err)
+		p, ok := f.(*frames.Payload)
+		require.True(t, ok, "expected a PAYLOAD frame, got %T", f)
+		received = append(received, p)
+	}
+	for i, p := range received {
+		assert.Equal(t, sent[i].StreamID, p.StreamID)
+		assert.Equal(t, string(sent[i].Metadata), string(p.Metadata))
+		assert.Equal(t, sent[i].Data, p.Data)
+		assert.Equal(t, sent[i].Complete, p.Complete)
+	}
+}
+
+func BenchmarkTCPConnWrite(b *testing.B) {
+	for _, size := range []int{128, 64 * 1024} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			c1, c2 := net.Pipe()
+			defer c1.Close()
+			go io.Copy(io.Discard, c2) //nolint:errcheck
+			conn := rsocket.NewTCPConn(c1)
+			f := frames.Payload{StreamID: 1, Data: make([]byte, size), Next: true}
+
+			b.ReportAllocs()
+			b.SetBytes(int64(size))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := conn.Write(&f); err != nil {
+					b.Fatal(err)
+				}
+				if err := conn.Flush(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -3,14 +3,21 @@ package rsocket
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/nanobus/iota/go/handler"
 	"github.com/nanobus/iota/go/internal/frames"
 	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/tap"
 )
 
 type tcpServerTransport struct {
@@ -21,6 +28,12 @@ type tcpServerTransport struct {
 	l        net.Listener
 	acceptor ServerTransportAcceptor
 	done     chan struct{}
+	shutdown chan struct{}
+
+	setupPrincipal   bool
+	setupAcceptor    SetupAcceptor
+	frameObserver    tap.FrameObserver
+	handshakeTimeout time.Duration
 }
 
 func (t *tcpServerTransport) Accept(acceptor ServerTransportAcceptor) {
@@ -51,16 +64,72 @@ func (t *tcpServerTransport) Close() (err error) {
 	return
 }
 
+// shutdowner is implemented by handlers that can drain their streams.
+type shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+func (t *tcpServerTransport) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	select {
+	case <-t.done:
+		t.mu.Unlock()
+		return nil
+	case <-t.shutdown:
+	default:
+		close(t.shutdown)
+		if t.l != nil {
+			_ = t.l.Close()
+		}
+	}
+	transports := make([]*Transport, 0, len(t.m))
+	for tp := range t.m {
+		transports = append(transports, tp)
+	}
+	t.mu.Unlock()
+
+	var wg sync.WaitGroup
+	var drained atomic.Bool
+	drained.Store(true)
+	for _, tp := range transports {
+		if s, ok := tp.handler.(shutdowner); ok {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if s.Shutdown(ctx) != nil {
+					drained.Store(false)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	_ = t.Close()
+	if drained.Load() {
+		return nil
+	}
+	return ctx.Err()
+}
+
 func (t *tcpServerTransport) Listen(ctx context.Context, notifier chan<- bool) (err error) {
-	t.l, err = t.lf(ctx)
+	l, err := t.lf(ctx)
 	if err != nil {
 		notifier <- false
 		err = fmt.Errorf("listen tcp server failed: %w", err)
 		return
 	}
+	t.mu.Lock()
+	t.l = l
+	t.mu.Unlock()
 
 	defer func() {
-		_ = t.Close()
+		select {
+		case <-t.shutdown:
+			// Shutdown closes the connections once they are drained.
+			<-t.done
+		default:
+			_ = t.Close()
+		}
 	}()
 
 	notifier <- true
@@ -79,7 +148,7 @@ func (t *tcpServerTransport) Listen(ctx context.Context, notifier chan<- bool) (
 	// Start loop of accepting connections.
 	for {
 		var c net.Conn
-		c, err = t.l.Accept()
+		c, err = l.Accept()
 		if err == io.EOF || isClosedErr(err) {
 			err = nil
 			break
@@ -89,26 +158,65 @@ func (t *tcpServerTransport) Listen(ctx context.Context, notifier chan<- bool) (
 			continue
 		}
 
-		// Dispatch raw conn.
-		h := handler.New(ctx, handler.ServerMode)
-		tp := NewTransport(NewTCPConn(c), h, true)
-		h.SetFrameSender(func(f frames.Frame) error {
-			return tp.Send(f, true)
-		})
+		go t.serve(ctx, c)
+	}
+	return
+}
 
-		if t.putTransport(tp) {
-			go tp.Start(ctx)
-			go func() {
-				tp.WaitUntilReady()
-				t.acceptor(ctx, h, func(tp *Transport) {
-					t.removeTransport(tp)
-				})
-			}()
-		} else {
-			_ = t.Close()
+// serve runs the RSocket protocol on a newly accepted connection.
+func (t *tcpServerTransport) serve(ctx context.Context, c net.Conn) {
+	peer, err := newPeer(ctx, c, t.handshakeTimeout)
+	if err != nil {
+		_ = c.Close()
+		return
+	}
+	ctx = WithPeer(ctx, peer)
+
+	h := handler.New(ctx, handler.ServerMode)
+	tp := NewTransport(NewTCPConn(c), h, true)
+	tp.SetFrameObserver(t.frameObserver)
+	tp.acceptSetup = func(setup *frames.Setup) error {
+		if t.setupPrincipal && peer.Certificate == nil {
+			if principal, ok := SetupPrincipal(setup); ok {
+				peer.Principal = principal
+			}
+		}
+		if t.setupAcceptor == nil {
+			return nil
+		}
+		principal, err := t.setupAcceptor(ctx, setup, peer.Addr)
+		if err != nil {
+			return err
+		}
+		if principal != "" {
+			peer.Principal = principal
 		}
+		return nil
 	}
-	return
+	h.SetFrameSender(func(f frames.Frame) error {
+		return tp.Send(f, true)
+	})
+
+	if !t.putTransport(tp) {
+		_ = tp.Close()
+		return
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		_ = tp.Start(ctx)
+		t.removeTransport(tp)
+		close(stopped)
+	}()
+	select {
+	case <-tp.ready:
+	case <-stopped:
+		// The SETUP frame was rejected.
+		return
+	}
+	t.acceptor(ctx, h, func(tp *Transport) {
+		t.removeTransport(tp)
+	})
 }
 
 func (t *tcpServerTransport) removeTransport(tp *Transport) {
@@ -124,6 +232,9 @@ func (t *tcpServerTransport) putTransport(tp *Transport) bool {
 	case <-t.done:
 		// already closed
 		return false
+	case <-t.shutdown:
+		// not accepting connections
+		return false
 	default:
 		if t.m == nil {
 			return false
@@ -134,19 +245,30 @@ func (t *tcpServerTransport) putTransport(tp *Transport) bool {
 }
 
 // NewTCPServerTransport creates a new server-side transport.
-func NewTCPServerTransport(lf ListenerFactory, hf HandlerFactory) ServerTransport {
+func NewTCPServerTransport(lf ListenerFactory, hf HandlerFactory, opts ...Option) ServerTransport {
+	o := newOptions("", opts)
 	return &tcpServerTransport{
-		lf:       lf,
-		hf:       hf,
-		m:        make(map[*Transport]struct{}),
-		done:     make(chan struct{}),
-		acceptor: func(ctx context.Context, caller invoke.Caller, onClose func(*Transport)) {},
+		lf:               lf,
+		hf:               hf,
+		m:                make(map[*Transport]struct{}),
+		done:             make(chan struct{}),
+		shutdown:         make(chan struct{}),
+		acceptor:         func(ctx context.Context, caller invoke.Caller, onClose func(*Transport)) {},
+		setupPrincipal:   o.setupPrincipal,
+		setupAcceptor:    o.setupAcceptor,
+		frameObserver:    o.frameObserver,
+		handshakeTimeout: o.handshakeTimeout,
 	}
 }
 
 // NewTCPListenerFactory creates a new server-side transport.
 func NewTCPListenerFactory(network, addr string, tlsConfig *tls.Config) ListenerFactory {
 	return func(ctx context.Context) (net.Listener, error) {
+		if network == "unix" {
+			if err := removeStaleSocket(ctx, addr); err != nil {
+				return nil, err
+			}
+		}
 		var c net.ListenConfig
 		l, err := c.Listen(ctx, network, addr)
 		if err != nil {
@@ -159,6 +281,29 @@ func NewTCPListenerFactory(network, addr string, tlsConfig *tls.Config) Listener
 	}
 }
 
+// removeStaleSocket removes a socket file left behind by a previous
+// process so that listening on the same path succeeds. The file is only
+// removed if connecting to it is refused; if a server still listens on
+// it, an error wrapping syscall.EADDRINUSE is returned. Abstract socket
+// addresses (starting with "@") have no file.
+func removeStaleSocket(ctx context.Context, path string) error {
+	if strings.HasPrefix(path, "@") {
+		return nil
+	}
+	var dial net.Dialer
+	conn, err := dial.DialContext(ctx, "unix", path)
+	if err == nil {
+		conn.Close()
+		return &net.OpError{Op: "listen", Net: "unix", Addr: &net.UnixAddr{Name: path, Net: "unix"}, Err: syscall.EADDRINUSE}
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
 // NewTCPClientTransport creates new transport.
 func NewTCPClientTransport(c net.Conn, handler DuplexHandler) *Transport {
 	return NewTransport(NewTCPConn(c), handler, false)
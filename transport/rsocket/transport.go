@@ -12,6 +12,7 @@ import (
 	"github.com/nanobus/iota/go/internal/buffer"
 	"github.com/nanobus/iota/go/internal/frames"
 	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/tap"
 )
 
 var (
@@ -24,6 +25,9 @@ const (
 	DefaultKeepaliveInterval = 20 * time.Second
 	// DefaultKeepaliveMaxLifetime is default keepalive max lifetime.
 	DefaultKeepaliveMaxLifetime = 90 * time.Second
+	// DefaultHandshakeTimeout is the default time a server waits for
+	// the TLS handshake of a new connection.
+	DefaultHandshakeTimeout = 10 * time.Second
 )
 
 // FrameHandler is an alias of frame handler.
@@ -45,16 +49,31 @@ type ServerTransport interface {
 	// Listen listens on the network address addr and handles requests on incoming connections.
 	// You can specify notifier chan, it'll be sent true/false when server listening success/failed.
 	Listen(ctx context.Context, notifier chan<- bool) error
+	// Shutdown stops accepting connections and tells every client that the
+	// connection is closing so that no new requests are started. It waits
+	// for outstanding streams to finish or for ctx to be done, then closes
+	// all connections. It returns ctx.Err() if streams were still active.
+	Shutdown(ctx context.Context) error
 }
 
 // Transport is RSocket transport which is used to carry RSocket frames.
 type Transport struct {
 	conn        Conn
+	writeMu     sync.Mutex
 	maxLifetime time.Duration
+	keepalive   time.Duration
 	once        sync.Once
 	handler     DuplexHandler
 	isServer    bool
 	ready       chan struct{}
+
+	// acceptSetup is called with the SETUP frame on server transports
+	// before it is passed to the handler. If it returns an error, the
+	// connection is rejected.
+	acceptSetup func(*frames.Setup) error
+
+	// observer sees every frame read from and written to the connection.
+	observer tap.FrameObserver
 }
 
 // NewTransport creates new transport.
@@ -96,6 +115,28 @@ func (p *Transport) SetLifetime(lifetime time.Duration) {
 	p.maxLifetime = lifetime
 }
 
+// SetKeepaliveInterval set the interval between KEEPALIVE frames sent
+// by a client transport. Zero disables keepalive.
+func (p *Transport) SetKeepaliveInterval(interval time.Duration) {
+	if interval < 0 {
+		return
+	}
+	p.keepalive = interval
+}
+
+// SetFrameObserver sets an observer that sees every frame read from and
+// written to the connection, including KEEPALIVE frames. It must be
+// called before the transport is started.
+func (p *Transport) SetFrameObserver(observer tap.FrameObserver) {
+	p.observer = observer
+}
+
+func (p *Transport) observe(direction tap.Direction, f frames.Frame) {
+	if p.observer != nil {
+		p.observer.ObserveFrame(tap.NewEvent(direction, f))
+	}
+}
+
 // Send send a frame.
 func (p *Transport) Send(frame frames.Frame, flush bool) (err error) {
 	// defer func() {
@@ -108,6 +149,10 @@ func (p *Transport) Send(frame frames.Frame, flush bool) (err error) {
 		err = errTransportClosed
 		return
 	}
+	// Frames are sent from many goroutines so writes must be serialized.
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	p.observe(tap.Outbound, frame)
 	err = p.conn.Write(frame)
 	if err != nil {
 		return
@@ -125,6 +170,8 @@ func (p *Transport) Flush() (err error) {
 		err = errTransportClosed
 		return
 	}
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
 	err = p.conn.Flush()
 	return
 }
@@ -145,7 +192,12 @@ func (p *Transport) ReadFirst(ctx context.Context) (frame frames.Frame, err erro
 	default:
 		frame, err = p.conn.Read()
 		if err != nil {
+			if invalidFrame(err) {
+				_ = p.Send(frameError(err), true)
+			}
 			err = fmt.Errorf("read first frame failed: %w", err)
+		} else {
+			p.observe(tap.Inbound, frame)
 		}
 	}
 	if err != nil {
@@ -167,6 +219,9 @@ func (p *Transport) loopReadBuffer(ctx context.Context, bf *buffer.Unbounded[fra
 			case errCh <- err:
 			default:
 			}
+			// Handlers only return connection errors. Closing the
+			// connection unblocks the read loop of Start.
+			_ = p.Close()
 		}
 		if bf.Load() == 0 {
 			runtime.Gosched()
@@ -195,18 +250,42 @@ func (p *Transport) Start(ctx context.Context) error {
 
 	go p.loopReadBuffer(ctx, framesBuffer, errChan, done)
 
+	// Unblock reads when the context is done.
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = p.Close()
+		case <-stopped:
+		}
+	}()
+
 	if p.isServer {
 		first, err := p.ReadFirst(ctx)
 		if err != nil {
 			return fmt.Errorf("read first failed: %w", err)
 		}
 		if setup, ok := first.(*frames.Setup); ok {
+			if p.acceptSetup != nil {
+				if err := p.acceptSetup(setup); err != nil {
+					_ = p.Send(setupError(err), true)
+					return err
+				}
+			}
 			if err := p.handler.HandleFrame(setup); err != nil {
 				return err
 			}
+			// The server follows the keepalive settings of the client.
+			p.SetKeepaliveInterval(setup.TimeBetweenKeepalive)
+			p.SetLifetime(setup.MaxLifetime)
 		} else {
 			return errors.New("expected first frame to be a setup frame")
 		}
+	} else if p.keepalive > 0 {
+		stop := make(chan struct{})
+		defer close(stop)
+		go p.loopKeepalive(p.keepalive, stop)
 	}
 
 	close(p.ready)
@@ -218,19 +297,104 @@ func (p *Transport) Start(ctx context.Context) error {
 		case err := <-errChan:
 			return fmt.Errorf("dispatch incoming frame failed: %w", err)
 		default:
+			if p.keepalive > 0 {
+				if err := p.conn.SetDeadline(time.Now().Add(p.maxLifetime)); err != nil {
+					return err
+				}
+			}
 			f, err := p.conn.Read()
+			if err != nil {
+				select {
+				case err := <-errChan:
+					return fmt.Errorf("dispatch incoming frame failed: %w", err)
+				default:
+				}
+			}
 			if err == io.EOF {
 				return nil
 			}
+			if invalidFrame(err) {
+				// The frame was read but could not be decoded.
+				// The peer is told and the connection stays open
+				// unless the frame was not on a stream.
+				e := frameError(err)
+				if err := p.Send(e, true); err != nil {
+					return err
+				}
+				if e.Code == frames.ErrCodeConnectionError {
+					return err
+				}
+				continue
+			}
 			if err != nil {
 				return err
 			}
+			p.observe(tap.Inbound, f)
+
+			if keepalive, ok := f.(*frames.Keepalive); ok {
+				if keepalive.Respond {
+					if err := p.Send(&frames.Keepalive{
+						Data: keepalive.Data,
+					}, true); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			// Connection errors, such as a rejected SETUP, are handled
+			// here so they are returned even when the peer closes the
+			// connection right after sending them.
+			if e, ok := f.(*frames.Error); ok && e.StreamID == 0 && e.Code != frames.ErrCodeConnectionClose {
+				return p.handler.HandleFrame(e)
+			}
 
 			framesBuffer.Put(f)
 		}
 	}
 }
 
+// invalidFrame returns true if err is returned for
+// a frame that was read but could not be decoded.
+func invalidFrame(err error) bool {
+	return errors.Is(err, frames.ErrInvalidFrame) || errors.Is(err, frames.ErrIncompleteHeader)
+}
+
+// frameError returns the ERROR frame that tells the peer that a frame
+// could not be decoded because of err. A frame of a stream fails that
+// stream, a SETUP frame is an invalid setup and any other frame fails
+// the connection.
+func frameError(err error) *frames.Error {
+	e := frames.InvalidFrame(0, err)
+	if e.StreamID != 0 {
+		return e
+	}
+	var ferr *frames.InvalidFrameError
+	if errors.As(err, &ferr) && ferr.Type == frames.FrameTypeSetup {
+		e.Code = frames.ErrCodeInvalidSetup
+	} else {
+		e.Code = frames.ErrCodeConnectionError
+	}
+	return e
+}
+
+func (p *Transport) loopKeepalive(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := p.Send(&frames.Keepalive{
+				Respond: true,
+			}, true); err != nil {
+				return
+			}
+		}
+	}
+}
+
 func (p *Transport) WaitUntilReady() {
 	<-p.ready
 }
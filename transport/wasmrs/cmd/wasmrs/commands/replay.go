@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nanobus/iota/go/handler"
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/operations"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/tap"
+	"github.com/nanobus/iota/go/tap/replay"
+	"github.com/nanobus/iota/go/transport/wasmrs/host"
+	"github.com/nanobus/iota/go/transport/wasmrs/mesh"
+)
+
+type ReplayCmd struct {
+	Verbose bool          `help:"Print every frame sent during the replay."`
+	Handler bool          `help:"Replay a capture of an RSocket connection into a handler serving the modules instead of replaying the frames the host sent into the first module."`
+	Timeout time.Duration `default:"1s" help:"How long to wait for the frames the capture expects."`
+	Capture string        `arg:"" type:"existingfile" help:"The capture file to replay"`
+	Modules []string      `arg:"" type:"existingfile" help:"The WasmRS modules to load"`
+}
+
+func (c *ReplayCmd) Run() error {
+	ctx := context.Background()
+	events, err := tap.ReadFile(c.Capture)
+	if err != nil {
+		return fmt.Errorf("could not read capture: %w", err)
+	}
+
+	m := mesh.New()
+	defer m.Close()
+
+	// The capture was recorded on the instance of the first module.
+	// The frames the host sent are replayed into that module and the
+	// frames it sends back are compared with the capture. The other
+	// modules satisfy its imports.
+	instances := make([]*host.Instance, 0, len(c.Modules))
+	for _, filename := range c.Modules {
+		inst, err := m.LoadModule(ctx, filename)
+		if err != nil {
+			return err
+		}
+		instances = append(instances, inst)
+	}
+
+	target := replay.Target(instances[0].Guest())
+	opts := []replay.Option{replay.WithTimeout(c.Timeout), replay.WithReverse()}
+	if c.Handler {
+		for _, inst := range instances {
+			exportOperations(m, inst.Operations())
+		}
+		h := handler.New(ctx, handler.ServerMode)
+		h.SetFrameSender(func(f frames.Frame) error { return nil })
+		target = h
+		opts = []replay.Option{replay.WithTimeout(c.Timeout)}
+	}
+
+	result, err := replay.Run(ctx, target, events, opts...)
+	if err != nil {
+		return err
+	}
+
+	if c.Verbose {
+		for _, e := range result.Actual {
+			fmt.Println(tap.Format(e))
+		}
+		fmt.Println()
+	}
+	for _, d := range result.Differences {
+		fmt.Printf("stream %d:\n%s\n", d.StreamID, d)
+	}
+	fmt.Printf("%d frames expected, %d sent, %d differences\n",
+		len(result.Expected), len(result.Actual), len(result.Differences))
+	if len(result.Differences) > 0 {
+		return fmt.Errorf("replay of %s does not match", c.Capture)
+	}
+
+	return nil
+}
+
+// exportOperations exports the operations of a module so that
+// handlers can serve them.
+func exportOperations(m *mesh.Mesh, opers operations.Table) {
+	for _, op := range opers {
+		if op.Direction != operations.Export {
+			continue
+		}
+		namespace, operation := op.Namespace, op.Operation
+		switch op.Type {
+		case operations.RequestResponse:
+			invoke.ExportRequestResponse(namespace, operation, func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+				return m.RequestResponse(ctx, namespace, operation, p)
+			})
+		case operations.FireAndForget:
+			invoke.ExportFireAndForget(namespace, operation, func(ctx context.Context, p payload.Payload) {
+				m.FireAndForget(ctx, namespace, operation, p)
+			})
+		case operations.RequestStream:
+			invoke.ExportRequestStream(namespace, operation, func(ctx context.Context, p payload.Payload) flux.Flux[payload.Payload] {
+				return m.RequestStream(ctx, namespace, operation, p)
+			})
+		case operations.RequestChannel:
+			invoke.ExportRequestChannel(namespace, operation, func(ctx context.Context, p payload.Payload, in flux.Flux[payload.Payload]) flux.Flux[payload.Payload] {
+				return m.RequestChannel(ctx, namespace, operation, p, in)
+			})
+		}
+	}
+}
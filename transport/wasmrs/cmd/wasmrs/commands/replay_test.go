@@ -0,0 +1,67 @@
+package commands_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/tap"
+	"github.com/nanobus/iota/go/transport/wasmrs/cmd/wasmrs/commands"
+)
+
+// echoModule sends every frame it receives back to the host. It is
+// assembled from testdata/echo.wat.
+const echoModule = "testdata/echo.wasm"
+
+// writeCapture writes a capture of the host sending a request to a
+// guest that answers with reply.
+func writeCapture(t *testing.T, reply tap.Frame) string {
+	name := filepath.Join(t.TempDir(), "capture.tap")
+	r, err := tap.Create(name)
+	require.NoError(t, err)
+	r.ObserveFrame(tap.NewEvent(tap.Outbound, &frames.RequestPayload{
+		FrameType: frames.FrameTypeRequestResponse,
+		StreamID:  2,
+		Metadata:  []byte{0, 0, 0, 0, 0, 0, 0, 0},
+		Data:      []byte("ping"),
+		Complete:  true,
+		InitialN:  1,
+	}))
+	r.ObserveFrame(tap.NewEvent(tap.Inbound, reply))
+	require.NoError(t, r.Close())
+	return name
+}
+
+func TestReplay(t *testing.T) {
+	cmd := commands.ReplayCmd{
+		Timeout: 100 * time.Millisecond,
+		Capture: writeCapture(t, &frames.RequestPayload{
+			FrameType: frames.FrameTypeRequestResponse,
+			StreamID:  2,
+			Metadata:  []byte{0, 0, 0, 0, 0, 0, 0, 0},
+			Data:      []byte("ping"),
+			Complete:  true,
+			InitialN:  1,
+		}),
+		Modules: []string{echoModule},
+	}
+	assert.NoError(t, cmd.Run())
+}
+
+func TestReplayDifferences(t *testing.T) {
+	cmd := commands.ReplayCmd{
+		Timeout: 100 * time.Millisecond,
+		Capture: writeCapture(t, &frames.Payload{
+			StreamID: 2,
+			Data:     []byte("pong"),
+			Next:     true,
+			Complete: true,
+		}),
+		Modules: []string{echoModule},
+	}
+	assert.ErrorContains(t, cmd.Run(), "does not match")
+}
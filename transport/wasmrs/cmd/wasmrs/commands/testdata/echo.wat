@@ -0,0 +1,19 @@
+;; echo.wasm is a guest that sends every frame it receives back to the
+;; host unchanged.
+(module
+  (import "wasmrs" "__init_buffers" (func $init_buffers (param i32 i32)))
+  (import "wasmrs" "__op_list" (func $op_list (param i32 i32)))
+  (import "wasmrs" "__send" (func $send (param i32)))
+  (memory (export "memory") 1)
+
+  ;; The host writes frames at 1024 and reads frames at 32768.
+  (func (export "__wasmrs_init") (param i32 i32 i32) (result i32)
+    (call $init_buffers (i32.const 1024) (i32.const 32768))
+    (i32.const 1))
+
+  (func (export "__wasmrs_op_list_request")
+    (call $op_list (i32.const 0) (i32.const 0)))
+
+  (func (export "__wasmrs_send") (param $size i32)
+    (memory.copy (i32.const 32768) (i32.const 1024) (local.get $size))
+    (call $send (local.get $size))))
@@ -15,6 +15,8 @@ var cli struct {
 	// List commands.ListCmd `cmd:"" help:"List info contained in a WasmRS module."`
 	// Invoke reinstalls the base module dependencies.
 	Invoke commands.InvokeCmd `cmd:"" help:"Invokes a WasmRS module."`
+	// Replay compares the frames of a module with a capture.
+	Replay commands.ReplayCmd `cmd:"" help:"Replays a frame capture against WasmRS modules and reports differences."`
 	// Version prints out the version of this program and runtime info.
 	Version versionCmd `cmd:""`
 }
@@ -0,0 +1,64 @@
+package concat_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/operations"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/rxtest"
+	"github.com/nanobus/iota/go/transform"
+	"github.com/nanobus/iota/go/transport/wasmrs/example/concat"
+)
+
+func TestMain(m *testing.M) {
+	concat.Register()
+	os.Exit(m.Run())
+}
+
+func concatHandler(t *testing.T) invoke.RequestChannelHandler {
+	for _, op := range invoke.GetOperationsTable() {
+		if op.Direction == operations.Export && op.Namespace == "concat" && op.Operation == "Concat" {
+			return invoke.GetRequestChannelHandler(op.Index)
+		}
+	}
+	t.Fatal("concat/Concat is not exported")
+	return nil
+}
+
+func TestConcat(t *testing.T) {
+	handler := concatHandler(t)
+	strings, err := transform.CodecEncode(&concat.Strings{Left: "Hello,", Right: "Ann"})
+	require.NoError(t, err)
+	port := invoke.PortIO{Port: "strings", Next: true, Complete: true}
+	in := flux.FromSlice([]payload.Payload{payload.New(strings.Data(), port.Encode())})
+
+	rxtest.Flux(handler(context.Background(), payload.New(nil), in)).
+		ThenRequest(rx.RequestMax).
+		ExpectNextMatches(func(p payload.Payload) bool {
+			var port invoke.PortIO
+			if err := port.Decode(p.Metadata()); err != nil || port.Port != "value" {
+				return false
+			}
+			value, err := transform.String.Decode(p)
+			return err == nil && value == "Hello, Ann"
+		}).
+		ExpectComplete().
+		Verify(t)
+}
+
+func TestConcatInvalidPort(t *testing.T) {
+	handler := concatHandler(t)
+	in := flux.FromSlice([]payload.Payload{payload.New(nil, nil)})
+
+	rxtest.Flux(handler(context.Background(), payload.New(nil), in)).
+		ThenRequest(rx.RequestMax).
+		ExpectError(invoke.ErrInvalidPortIO).
+		Verify(t)
+}
@@ -0,0 +1,46 @@
+package greeter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/operations"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/rxtest"
+	"github.com/nanobus/iota/go/transform"
+	"github.com/nanobus/iota/go/transport/wasmrs/example/greeter"
+)
+
+func TestGreeter(t *testing.T) {
+	greeter.Register()
+	var handler invoke.RequestChannelHandler
+	for _, op := range invoke.GetOperationsTable() {
+		if op.Direction == operations.Export && op.Namespace == "greeter" && op.Operation == "Greeter" {
+			handler = invoke.GetRequestChannelHandler(op.Index)
+		}
+	}
+	require.NotNil(t, handler)
+
+	name, err := transform.String.Encode("Ann")
+	require.NoError(t, err)
+	port := invoke.PortIO{Port: "name", Next: true, Complete: true}
+	in := flux.FromSlice([]payload.Payload{payload.New(name.Data(), port.Encode())})
+
+	rxtest.Flux(handler(context.Background(), payload.New(nil), in)).
+		ThenRequest(rx.RequestMax).
+		ExpectNextMatches(func(p payload.Payload) bool {
+			var port invoke.PortIO
+			if err := port.Decode(p.Metadata()); err != nil || port.Port != "message" || !port.Complete {
+				return false
+			}
+			message, err := transform.String.Decode(p)
+			return err == nil && message == "Hello, Ann"
+		}).
+		ExpectComplete().
+		Verify(t)
+}
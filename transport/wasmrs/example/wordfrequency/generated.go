@@ -4,9 +4,9 @@ import (
 	"context"
 
 	"github.com/nanobus/iota/go/invoke"
+	"github.com/nanobus/iota/go/invoke/ports"
 	"github.com/nanobus/iota/go/msgpack"
 	"github.com/nanobus/iota/go/payload"
-	"github.com/nanobus/iota/go/rx"
 	"github.com/nanobus/iota/go/rx/flux"
 	"github.com/nanobus/iota/go/transform"
 )
@@ -33,91 +33,25 @@ func Register() {
 }
 
 func wordFrequencyHandler(ctx context.Context, pl payload.Payload, in flux.Flux[payload.Payload]) flux.Flux[payload.Payload] {
-	return flux.Create(func(sink flux.Sink[payload.Payload]) {
-		// Processors for each input and output.
-		words := flux.NewProcessor[string]()
-		counts := flux.NewProcessor[WordCount]()
-
-		// Handle closing `sink` when all outputs are finalized.
-		const numSinks = 1
-		finalized := 0
-		final := func(_ rx.SignalType) {
-			finalized++
-			if finalized == numSinks {
-				sink.Complete()
-			}
-		}
-
-		// Subscribe to counts and forward to `sink`
-		// with PortIO metadata.
-		counts.Subscribe(flux.Subscribe[WordCount]{
-			OnNext: func(next WordCount) {
-				p, err := transform.CodecEncode(&next)
-				if err != nil {
-					sink.Error(err)
-					return
-				}
-				portIO := invoke.PortIO{
-					Port: "counts",
-					Next: true,
-				}
-				p = payload.New(p.Data(), portIO.Encode())
-				sink.Next(p)
-			},
-			OnComplete: func() {
-				pp := invoke.PortIO{
-					Port:     "counts",
-					Complete: true,
-				}
-				p := payload.New(nil, pp.Encode())
-				sink.Next(p)
-			},
-			Finally: final,
-		})
-
+	return ports.Channel(in, func(d *ports.Demux, m *ports.Mux) {
 		// Create and invoke component.
 		comp := WordFrequency{
 			Input: WordFrequencyInputs{
-				Words: words,
+				Words: ports.Input(d, "words", transform.String),
 			},
 			Output: WordFrequencyOutputs{
-				Counts: counts,
+				Counts: ports.Output(m, "counts", wordCountTransform),
 			},
 		}
 		comp.Process(ctx)
-
-		// Now that the component is ready,
-		// Subscribe to `in`.
-		in.Subscribe(flux.Subscribe[payload.Payload]{
-			OnNext: func(p payload.Payload) {
-				var port invoke.PortIO
-				if err := port.Decode(p.Metadata()); err != nil {
-					sink.Error(err)
-					return
-				}
-
-				switch port.Port {
-				case "words":
-					if port.Next {
-						next, err := transform.String.Decode(p)
-						if err != nil {
-							sink.Error(err)
-							return
-						}
-						words.Next(next)
-					}
-					if port.Complete {
-						words.Complete()
-					}
-				}
-			},
-			OnComplete: func() {
-				words.Complete()
-			},
-		})
 	})
 }
 
+var wordCountTransform = transform.Transform[WordCount]{
+	Decode: transform.MsgPackDecode[WordCount],
+	Encode: transform.MsgPackEncode[WordCount],
+}
+
 type WordCount struct {
 	Word  string `json:"word" msgpack:"word"`
 	Count uint64 `json:"count" msgpack:"count"`
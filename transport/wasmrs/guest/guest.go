@@ -3,13 +3,17 @@ package guest
 import (
 	"context"
 	"encoding/binary"
-	"errors"
+	"math"
 	"reflect"
 	"strconv"
 	"unsafe"
 
 	"github.com/nanobus/iota/go/internal/frames"
 	"github.com/nanobus/iota/go/invoke"
+	// Registers the decoder of structured APPLICATION_ERROR data.
+	_ "github.com/nanobus/iota/go/invoke/apperror"
+	"github.com/nanobus/iota/go/metadata"
+	"github.com/nanobus/iota/go/operations"
 	"github.com/nanobus/iota/go/payload"
 	"github.com/nanobus/iota/go/proxy"
 	"github.com/nanobus/iota/go/rx"
@@ -32,6 +36,17 @@ var (
 
 	maxFrameSize       uint32
 	fragmentedPayloads map[uint32]fragmentedPayload
+
+	// extensions maps extended types to the handlers of EXT frames.
+	extensions map[uint32]invoke.ExtensionHandler
+
+	// mimeTypes are the MIME types of request metadata and data that
+	// the guest and the host agreed on.
+	mimeTypes metadata.MimeTypes
+
+	// borrowPayloads makes payloads point into the guest buffer instead
+	// of copying them.
+	borrowPayloads bool
 )
 
 type fragmentedPayload struct {
@@ -66,25 +81,63 @@ func OperationList() {
 	opList(bytesToPointer(payload), uint32(len(payload)))
 }
 
+// GuestSend handles the frames the host has written to the guest buffer.
+// Each frame is copied out of the buffer unless SetBorrowPayloads is
+// enabled.
+//
 //go:export __wasmrs_send
 func GuestSend(endPos uint32) {
 	ctx := context.Background()
-	var lengthBuf [4]byte
-	curPos := uint32(0)
-	buf := guestBuffer
-
-	for curPos < endPos {
-		copy(lengthBuf[1:], buf)
-		frameLen := binary.BigEndian.Uint32(lengthBuf[:])
-		frameBuf := make([]byte, frameLen)
-		copy(frameBuf, buf[3:])
-		curPos += 3 + frameLen
-		buf = buf[3+frameLen:]
-
-		// lengthPos = 1 // Reset for next frame
+	if int(endPos) > len(guestBuffer) {
+		sendFrame(&frames.Error{
+			Code: frames.ErrCodeInvalid,
+			Data: "frames exceed the guest buffer",
+		})
+		return
+	}
+	buf := guestBuffer[:endPos]
+
+	for len(buf) > 0 {
+		if len(buf) < 3 {
+			sendFrame(&frames.Error{
+				Code: frames.ErrCodeInvalid,
+				Data: "frame length is truncated",
+			})
+			return
+		}
+		frameLen := int(buf[0])<<16 | int(buf[1])<<8 | int(buf[2])
+		buf = buf[3:]
+		if frameLen > len(buf) {
+			sendFrame(&frames.Error{
+				Code: frames.ErrCodeInvalid,
+				Data: "frame length " + strconv.Itoa(frameLen) + " exceeds the remaining " + strconv.Itoa(len(buf)) + " bytes",
+			})
+			return
+		}
+		frameBuf := buf[:frameLen]
+		buf = buf[frameLen:]
+		if !borrowPayloads {
+			frameBuf = append([]byte(nil), frameBuf...)
+		}
+		if len(frameBuf) < frames.FrameHeaderLen {
+			sendFrame(frames.InvalidFrame(0, frames.ErrIncompleteHeader))
+			continue
+		}
+
 		header := frames.ParseFrameHeader(frameBuf)
 		buffer := frameBuf[frames.FrameHeaderLen:]
 
+		if header.Type() == frames.FrameTypeExt {
+			// Extensions need not belong to an active stream.
+			var ext frames.Ext
+			if err := ext.Decode(&header, buffer); err != nil {
+				sendFrame(frames.InvalidFrame(header.StreamID(), err))
+				continue
+			}
+			handleExt(ctx, &ext)
+			continue
+		}
+
 		var str proxy.Stream
 		if header.Type() >= frames.FrameTypeRequestN {
 			var ok bool
@@ -105,10 +158,7 @@ func GuestSend(endPos uint32) {
 		case frames.FrameTypeRequestResponse:
 			var rr frames.RequestPayload
 			if err := rr.Decode(&header, buffer); err != nil {
-				sendFrame(&frames.Error{
-					StreamID: rr.StreamID,
-					Data:     err.Error(),
-				})
+				sendFrame(frames.InvalidFrame(header.StreamID(), err))
 				continue
 			}
 			if checkFollows(&rr) {
@@ -121,10 +171,7 @@ func GuestSend(endPos uint32) {
 		case frames.FrameTypeRequestFNF:
 			var rr frames.RequestPayload
 			if err := rr.Decode(&header, buffer); err != nil {
-				sendFrame(&frames.Error{
-					StreamID: rr.StreamID,
-					Data:     err.Error(),
-				})
+				sendFrame(frames.InvalidFrame(header.StreamID(), err))
 				continue
 			}
 			if checkFollows(&rr) {
@@ -137,10 +184,7 @@ func GuestSend(endPos uint32) {
 		case frames.FrameTypeRequestStream:
 			var rs frames.RequestPayload
 			if err := rs.Decode(&header, buffer); err != nil {
-				sendFrame(&frames.Error{
-					StreamID: rs.StreamID,
-					Data:     err.Error(),
-				})
+				sendFrame(frames.InvalidFrame(header.StreamID(), err))
 				continue
 			}
 			if checkFollows(&rs) {
@@ -153,11 +197,8 @@ func GuestSend(endPos uint32) {
 		case frames.FrameTypeRequestChannel:
 			var rc frames.RequestPayload
 			if err := rc.Decode(&header, buffer); err != nil {
-				sendFrame(&frames.Error{
-					StreamID: rc.StreamID,
-					Data:     err.Error(),
-				})
-				return
+				sendFrame(frames.InvalidFrame(header.StreamID(), err))
+				continue
 			}
 			if checkFollows(&rc) {
 				// Will be processed under frames.FrameTypePayload
@@ -169,23 +210,25 @@ func GuestSend(endPos uint32) {
 		case frames.FrameTypeRequestN:
 			var rn frames.RequestN
 			if err := rn.Decode(&header, buffer); err != nil {
-				sendFrame(&frames.Error{
-					StreamID: rn.StreamID,
-					Data:     err.Error(),
-				})
-				return
+				sendFrame(frames.InvalidFrame(header.StreamID(), err))
+				continue
 			}
 
 			s := str.(DoRequest)
 			s.DoRequest(int(rn.N))
 
 		case frames.FrameTypeCancel:
-			str.OnComplete()
+			if c, ok := str.(DoCancel); ok {
+				c.DoCancel()
+			} else {
+				str.OnComplete()
+			}
 			removeStream(header.StreamID())
 
 		case frames.FrameTypePayload:
 			var p frames.Payload
 			if err := p.Decode(&header, buffer); err != nil {
+				sendFrame(frames.InvalidFrame(header.StreamID(), err))
 				continue
 			}
 
@@ -225,7 +268,7 @@ func GuestSend(endPos uint32) {
 			// switch pl.frameType {
 			// case frames.FrameTypePayload:
 			if p.Next {
-				str.OnNext(payload.New(pl.data, pl.metadata))
+				str.OnNext(newPayload(pl.data, pl.metadata))
 			}
 
 			// case frames.FrameTypeRequestResponse:
@@ -249,31 +292,94 @@ func GuestSend(endPos uint32) {
 		case frames.FrameTypeError:
 			var p frames.Error
 			if err := p.Decode(&header, buffer); err != nil {
+				sendFrame(frames.InvalidFrame(header.StreamID(), err))
 				continue
 			}
-			str.OnError(errors.New(p.Data))
+			str.OnError(invoke.ParseError(payload.ErrCode(p.Code), p.Data))
 			str.OnComplete()
 			removeStream(header.StreamID())
 		}
 	}
 }
 
-func handleRequestResponse(ctx context.Context, streamID uint32, data, metadata []byte) {
-	if !checkMetadata(streamID, metadata) {
+// SetMimeTypes sets the MIME types of request metadata and data. The
+// host must use the same types, see host.Instance.SetMimeTypes. It must
+// be called before the host sends frames, such as from main.
+func SetMimeTypes(types metadata.MimeTypes) {
+	mimeTypes = types
+}
+
+// SetBorrowPayloads makes the payloads of frames from the host point
+// into the guest buffer instead of copying them. Borrowed payloads,
+// including values decoded from them that share their memory, are only
+// valid until the handler function or OnNext callback they are passed
+// to returns. Use payload.Retain to keep them. It must be called before
+// the host sends frames, such as from main.
+func SetBorrowPayloads(borrow bool) {
+	borrowPayloads = borrow
+}
+
+// newPayload returns the payload of a frame from the host.
+func newPayload(data, metadata []byte) payload.Payload {
+	if borrowPayloads {
+		return payload.Borrow(data, metadata, nil)
+	}
+	return payload.New(data, metadata)
+}
+
+// SetExtensionHandler sets the handler of the EXT frames with
+// extendedType that the host sends. It must be called before
+// the host sends frames, such as from main.
+func SetExtensionHandler(extendedType uint32, handler invoke.ExtensionHandler) {
+	if extensions == nil {
+		extensions = make(map[uint32]invoke.ExtensionHandler)
+	}
+	extensions[extendedType] = handler
+}
+
+// SendExtension sends an EXT frame with extendedType to the host on a
+// stream, or on stream 0 for the guest. If ignore is true, hosts that
+// do not support the extension drop the frame instead of rejecting it.
+func SendExtension(streamID, extendedType uint32, p payload.Payload, ignore bool) error {
+	return sendFrame(&frames.Ext{
+		StreamID:     streamID,
+		ExtendedType: extendedType,
+		Metadata:     p.Metadata(),
+		Data:         p.Data(),
+		Ignore:       ignore,
+	})
+}
+
+// handleExt passes an EXT frame to the handler of its extended type.
+// Unsupported extensions are dropped if the IGNORE flag is set and
+// rejected otherwise.
+func handleExt(ctx context.Context, f *frames.Ext) {
+	handler, ok := extensions[f.ExtendedType]
+	if !ok {
+		if !f.Ignore {
+			sendFrame(frames.UnsupportedExt(f))
+		}
 		return
 	}
+	handler(ctx, f.StreamID, payload.New(f.Data, f.Metadata))
+}
 
-	operationID := binary.BigEndian.Uint32(metadata)
+func handleRequestResponse(ctx context.Context, streamID uint32, data, metadata []byte) {
+	ctx, operationID, ok := operation(ctx, streamID, operations.RequestResponse, metadata)
+	if !ok {
+		return
+	}
 	handler := invoke.GetRequestResponseHandler(operationID)
 	if handler == nil {
 		sendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "not_found",
 		})
 		return
 	}
 
-	p := payload.New(data, metadata[8:])
+	p := newPayload(data, handlerMetadata(metadata))
 	s := requestStream{ctx: ctx, streamID: streamID}
 	ctx = proxy.WithContext(ctx, &s)
 	handler(ctx, p).Subscribe(mono.Subscribe[payload.Payload]{
@@ -287,30 +393,27 @@ func handleRequestResponse(ctx context.Context, streamID uint32, data, metadata
 			})
 		},
 		OnError: func(err error) {
-			sendFrame(&frames.Error{
-				StreamID: streamID,
-				Data:     err.Error(),
-			})
+			sendFrame(frames.NewError(streamID, err))
 		},
 	})
 }
 
 func handleFireAndForget(ctx context.Context, streamID uint32, data, metadata []byte) {
-	if !checkMetadata(streamID, metadata) {
+	ctx, operationID, ok := operation(ctx, streamID, operations.FireAndForget, metadata)
+	if !ok {
 		return
 	}
-
-	operationID := binary.BigEndian.Uint32(metadata)
 	handler := invoke.GetFireAndForgetHandler(operationID)
 	if handler == nil {
 		sendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "not_found",
 		})
 		return
 	}
 
-	p := payload.New(data, metadata[8:])
+	p := newPayload(data, handlerMetadata(metadata))
 	s := requestStream{ctx: ctx, streamID: streamID}
 	registerStream(&s)
 	ctx = proxy.WithContext(ctx, &s)
@@ -318,25 +421,25 @@ func handleFireAndForget(ctx context.Context, streamID uint32, data, metadata []
 }
 
 func handleRequestStream(ctx context.Context, streamID uint32, data, metadata []byte, initialN uint32) {
-	if !checkMetadata(streamID, metadata) {
+	ctx, operationID, ok := operation(ctx, streamID, operations.RequestStream, metadata)
+	if !ok {
 		return
 	}
-
-	operationID := binary.BigEndian.Uint32(metadata)
 	handler := invoke.GetRequestStreamHandler(operationID)
 	if handler == nil {
 		sendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "not_found",
 		})
 		return
 	}
 
-	p := payload.New(data, metadata)
+	p := newPayload(data, metadata)
 	s := requestStream{ctx: ctx, streamID: streamID}
 	registerStream(&s) // Need to register for RequestN frames
 	// ctx := stream.WithContext(context.Background(), s)
-	f := handler(ctx, p)
+	f := proxy.Responder(handler(ctx, p))
 	f.Subscribe(flux.Subscribe[payload.Payload]{
 		OnNext: func(p payload.Payload) {
 			sendFrame(&frames.Payload{
@@ -353,10 +456,7 @@ func handleRequestStream(ctx context.Context, streamID uint32, data, metadata []
 			})
 		},
 		OnError: func(err error) {
-			sendFrame(&frames.Error{
-				StreamID: streamID,
-				Data:     err.Error(),
-			})
+			sendFrame(frames.NewError(streamID, err))
 		},
 		NoRequest: true,
 	})
@@ -366,21 +466,21 @@ func handleRequestStream(ctx context.Context, streamID uint32, data, metadata []
 }
 
 func handleRequestChannel(ctx context.Context, streamID uint32, data, metadata []byte, initialN uint32) {
-	if !checkMetadata(streamID, metadata) {
+	ctx, operationID, ok := operation(ctx, streamID, operations.RequestChannel, metadata)
+	if !ok {
 		return
 	}
-
-	operationID := binary.BigEndian.Uint32(metadata)
 	handler := invoke.GetRequestChannelHandler(operationID)
 	if handler == nil {
 		sendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "not_found",
 		})
 		return
 	}
 
-	p := payload.New(data, metadata)
+	p := newPayload(data, metadata)
 	s := requestStream{streamID: streamID}
 	registerStream(&s) // Need to register for RequestN frames
 	in := flux.Create(func(sink flux.Sink[payload.Payload]) {
@@ -399,7 +499,7 @@ func handleRequestChannel(ctx context.Context, streamID uint32, data, metadata [
 			},
 		})
 	})
-	f := handler(ctx, p, in)
+	f := proxy.Responder(handler(ctx, p, in))
 	f.Subscribe(flux.Subscribe[payload.Payload]{
 		OnNext: func(p payload.Payload) {
 			sendFrame(&frames.Payload{
@@ -416,10 +516,7 @@ func handleRequestChannel(ctx context.Context, streamID uint32, data, metadata [
 			})
 		},
 		OnError: func(err error) {
-			sendFrame(&frames.Error{
-				StreamID: streamID,
-				Data:     err.Error(),
-			})
+			sendFrame(frames.NewError(streamID, err))
 		},
 		NoRequest: true,
 	})
@@ -428,10 +525,75 @@ func handleRequestChannel(ctx context.Context, streamID uint32, data, metadata [
 	s.Request(int(initialN))
 }
 
+// operation resolves the exported operation a request from the host
+// invokes and returns the context to handle it with. Plain metadata
+// starts with the export index. Composite metadata carries the export
+// index or a route to the export, as with handler.Handler.
+func operation(ctx context.Context, streamID uint32, requestType operations.RequestType, md []byte) (context.Context, uint32, bool) {
+	if mimeTypes.Metadata != "" {
+		ctx = metadata.WithMimeTypes(ctx, mimeTypes)
+	}
+	if mimeTypes.Metadata != metadata.MimeTypeComposite {
+		if !checkMetadata(streamID, md) {
+			return ctx, 0, false
+		}
+		return ctx, binary.BigEndian.Uint32(md), true
+	}
+
+	c, err := metadata.ParseComposite(md)
+	if err != nil {
+		sendFrame(&frames.Error{
+			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
+			Data:     err.Error(),
+		})
+		return ctx, 0, false
+	}
+	if index, ok := c.OperationIndex(); ok {
+		return ctx, index, true
+	}
+	if route, ok := c.Route(); ok {
+		if namespace, operation, ok := metadata.SplitRoute(route); ok {
+			return ctx, exportIndex(requestType, namespace, operation), true
+		}
+	}
+
+	sendFrame(&frames.Error{
+		StreamID: streamID,
+		Code:     frames.ErrCodeInvalid,
+		Data:     "Invalid metadata",
+	})
+	return ctx, 0, false
+}
+
+// handlerMetadata returns the request metadata passed to request-response
+// and fire-and-forget handlers.
+func handlerMetadata(md []byte) []byte {
+	if mimeTypes.Metadata == metadata.MimeTypeComposite {
+		return md
+	}
+	return md[8:]
+}
+
+// exportIndex returns the index of an export or math.MaxUint32 if
+// there is no such export.
+func exportIndex(requestType operations.RequestType, namespace, operation string) uint32 {
+	for _, op := range invoke.GetOperationsTable() {
+		if op.Direction == operations.Export &&
+			op.Type == requestType &&
+			op.Namespace == namespace &&
+			op.Operation == operation {
+			return op.Index
+		}
+	}
+	return math.MaxUint32
+}
+
 func checkMetadata(streamID uint32, metadata []byte) bool {
 	if len(metadata) < 8 { // 48 before but why?
 		sendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "Invalid metadata",
 		})
 		return false
@@ -536,6 +698,10 @@ type DoRequest interface {
 	DoRequest(n int)
 }
 
+type DoCancel interface {
+	DoCancel()
+}
+
 type requestStream struct {
 	ctx      context.Context
 	streamID uint32
@@ -562,6 +728,12 @@ func (r *requestStream) DoRequest(n int) {
 	r.sub.Request(n)
 }
 
+func (r *requestStream) DoCancel() {
+	if r.sub != nil {
+		r.sub.Cancel()
+	}
+}
+
 func (r *requestStream) OnNext(p payload.Payload) {
 	r.sink.Next(p)
 }
@@ -0,0 +1,57 @@
+package guest
+
+import (
+	"context"
+	"time"
+
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx"
+	"github.com/nanobus/iota/go/transport/wasmrs/timer"
+)
+
+// Scheduler runs tasks on timers that the host provides. Time-based
+// operators in guests use it in place of rx.HostScheduler.
+var Scheduler rx.Scheduler = &scheduler{
+	tasks: make(map[uint32]func()),
+}
+
+type scheduler struct {
+	nextID uint32
+	tasks  map[uint32]func()
+}
+
+func init() {
+	SetExtensionHandler(timer.ExtFired, Scheduler.(*scheduler).fire)
+}
+
+func (s *scheduler) Now() time.Time {
+	return time.Now()
+}
+
+func (s *scheduler) Schedule(delay time.Duration, task func()) rx.FnCancel {
+	s.nextID++
+	id := s.nextID
+	s.tasks[id] = task
+	SendExtension(0, timer.ExtSchedule, payload.New(timer.EncodeSchedule(id, delay)), false)
+	return func() {
+		if _, ok := s.tasks[id]; !ok {
+			return
+		}
+		delete(s.tasks, id)
+		SendExtension(0, timer.ExtCancel, payload.New(timer.EncodeID(id)), false)
+	}
+}
+
+// fire runs the task of a timer that the host reports as fired.
+func (s *scheduler) fire(_ context.Context, _ uint32, p payload.Payload) {
+	id, err := timer.DecodeID(p.Data())
+	if err != nil {
+		return
+	}
+	task, ok := s.tasks[id]
+	if !ok {
+		return
+	}
+	delete(s.tasks, id)
+	task()
+}
@@ -0,0 +1,164 @@
+package host
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/operations"
+)
+
+// memoryModule is a WebAssembly module that only exports a memory of
+// two pages.
+var memoryModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, // magic and version
+	0x05, 0x03, 0x01, 0x00, 0x02, // memory section: min 2 pages
+	0x07, 0x0a, 0x01, 0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00, // export "memory"
+}
+
+const (
+	// guestBuffer is where the host writes frames for the guest.
+	guestBuffer = 0
+	// hostBuffer is where the guest writes frames for the host.
+	hostBuffer = 64 * 1024
+)
+
+// fakeGuest stands in for a guest module. It exports the wasmrs
+// functions and keeps the frames the host sends to it.
+type fakeGuest struct {
+	t      *testing.T
+	i      *Instance
+	mem    api.Memory
+	ops    operations.Table
+	frames chan frames.Frame
+	// onReceive is called with each frame while the host waits for
+	// the guest to take it, if set.
+	onReceive func(frames.Frame)
+}
+
+// newFakeGuest returns the instance of a fake guest that announces ops.
+func newFakeGuest(t *testing.T, ops operations.Table) *fakeGuest {
+	ctx := context.Background()
+	r := wazero.NewRuntime(ctx)
+	t.Cleanup(func() { r.Close(ctx) })
+	mod, err := r.InstantiateModuleFromBinary(ctx, memoryModule)
+	require.NoError(t, err)
+
+	g := fakeGuest{
+		t:      t,
+		mem:    mod.Memory(),
+		ops:    ops,
+		frames: make(chan frames.Frame, 100),
+	}
+	m := fakeModule{
+		mem: g.mem,
+		funcs: map[string]api.Function{
+			"__wasmrs_init":            fakeFunction(g.init),
+			"__wasmrs_op_list_request": fakeFunction(g.opList),
+			"__wasmrs_send":            fakeFunction(g.receive),
+		},
+	}
+	g.i, err = NewInstance(ctx, &m)
+	require.NoError(t, err)
+	t.Cleanup(func() { g.i.Close() })
+	return &g
+}
+
+func (g *fakeGuest) init(ctx context.Context, params ...uint64) {
+	ctx.Value(instanceKey{}).(*Instance).setBuffers(guestBuffer, hostBuffer)
+}
+
+func (g *fakeGuest) opList(ctx context.Context, params ...uint64) {
+	b := g.ops.ToBytes()
+	g.mem.Write(hostBuffer, b)
+	ctx.Value(instanceKey{}).(*Instance).opList(ctx, hostBuffer, uint32(len(b)))
+}
+
+// receive decodes a frame the host has written to the guest buffer.
+func (g *fakeGuest) receive(ctx context.Context, params ...uint64) {
+	b, ok := g.mem.Read(guestBuffer, uint32(params[0]))
+	if !ok {
+		g.t.Errorf("frame of %d bytes is out of bounds", params[0])
+		return
+	}
+	f, err := frames.Decode(append([]byte(nil), b[3:]...))
+	if err != nil {
+		g.t.Errorf("decode: %v", err)
+		return
+	}
+	if g.onReceive != nil {
+		g.onReceive(f)
+	}
+	g.frames <- f
+}
+
+// send writes frames to the host buffer and passes them to the host.
+func (g *fakeGuest) send(fs ...frames.Frame) {
+	var b []byte
+	for _, f := range fs {
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], f.Size())
+		data := make([]byte, f.Size())
+		f.Encode(data)
+		b = append(b, length[1:]...)
+		b = append(b, data...)
+	}
+	g.mem.Write(hostBuffer, b)
+	g.i.hostSend(context.Background(), uint32(len(b)))
+}
+
+// next returns the next frame the host sent to the guest.
+func (g *fakeGuest) next() frames.Frame {
+	g.t.Helper()
+	select {
+	case f := <-g.frames:
+		return f
+	case <-time.After(time.Second):
+		g.t.Fatal("no frame received")
+		return nil
+	}
+}
+
+// none checks that the host sends no further frame.
+func (g *fakeGuest) none() {
+	g.t.Helper()
+	select {
+	case f := <-g.frames:
+		g.t.Fatalf("unexpected frame %#v", f)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+type fakeModule struct {
+	api.Module
+	mem   api.Memory
+	funcs map[string]api.Function
+}
+
+func (m *fakeModule) Memory() api.Memory {
+	return m.mem
+}
+
+func (m *fakeModule) ExportedFunction(name string) api.Function {
+	return m.funcs[name]
+}
+
+type fakeFunc struct {
+	api.Function
+	call func(ctx context.Context, params ...uint64)
+}
+
+func fakeFunction(call func(ctx context.Context, params ...uint64)) api.Function {
+	return &fakeFunc{call: call}
+}
+
+func (f *fakeFunc) Call(ctx context.Context, params ...uint64) ([]uint64, error) {
+	f.call(ctx, params...)
+	return nil, nil
+}
@@ -5,27 +5,34 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
 	"sync"
 	"sync/atomic"
 
 	"github.com/tetratelabs/wazero/api"
 
+	"github.com/nanobus/iota/go/internal/buffer"
 	"github.com/nanobus/iota/go/internal/frames"
 	"github.com/nanobus/iota/go/internal/socket"
 	"github.com/nanobus/iota/go/invoke"
+	// Registers the decoder of structured APPLICATION_ERROR data.
+	_ "github.com/nanobus/iota/go/invoke/apperror"
+	"github.com/nanobus/iota/go/metadata"
 	"github.com/nanobus/iota/go/operations"
 	"github.com/nanobus/iota/go/payload"
 	"github.com/nanobus/iota/go/proxy"
 	"github.com/nanobus/iota/go/rx"
 	"github.com/nanobus/iota/go/rx/flux"
 	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/tap"
+	"github.com/nanobus/iota/go/transport/wasmrs/timer"
 )
 
 type Instance struct {
 	ctx       context.Context
 	m         api.Module
-	sendCh    chan frames.Frame
-	recvCh    chan []byte
+	sendCh    chan *buffer.Pooled
 	sendFn    api.Function
 	sendPtr   uint32
 	sendSize  uint32
@@ -53,6 +60,35 @@ type Instance struct {
 	importedRFNF []invoke.FireAndForgetHandler
 	importedRS   []invoke.RequestStreamHandler
 	importedRC   []invoke.RequestChannelHandler
+
+	// extensions maps extended types to the handlers of EXT frames.
+	extensions map[uint32]invoke.ExtensionHandler
+
+	// timers are armed for the guest scheduler.
+	timers timers
+
+	// observer sees every frame received from and sent to the guest.
+	observer tap.FrameObserver
+	// detached is set when the frames from the guest are only observed
+	// and not handled, such as when replaying into the guest.
+	detached atomic.Bool
+
+	// mimeTypes are the MIME types of request metadata and data that
+	// the host and the guest agreed on. Composite metadata can route
+	// requests by name.
+	mimeTypes metadata.MimeTypes
+
+	// borrowPayloads makes payloads point into pooled buffers instead
+	// of copying them.
+	borrowPayloads bool
+
+	// received queues the batches of frames the guest sent until
+	// recvLoop handles them, so that the guest never waits for the
+	// host while it runs.
+	recvMu   sync.Mutex
+	recvCond sync.Cond
+	received []*buffer.Pooled
+	recvDone bool
 }
 
 type fragmentedPayload struct {
@@ -76,14 +112,16 @@ func NewInstance(ctx context.Context, m api.Module) (*Instance, error) {
 	i := &Instance{
 		ctx:                ctx,
 		m:                  m,
-		sendCh:             make(chan frames.Frame, 100),
-		recvCh:             make(chan []byte, 100),
+		sendCh:             make(chan *buffer.Pooled, 100),
 		sendFn:             send,
 		maxFrameSize:       1024 * 1024,
 		fragmentedPayloads: make(map[uint32]fragmentedPayload),
 		sendSize:           16 * 1024,
 		closed:             make(chan struct{}),
 	}
+	i.recvCond.L = &i.recvMu
+	i.SetExtensionHandler(timer.ExtSchedule, i.scheduleTimer)
+	i.SetExtensionHandler(timer.ExtCancel, i.cancelTimer)
 
 	ctx = context.WithValue(ctx, instanceKey{}, i)
 	_, err := init.Call(ctx, uint64(i.sendSize), uint64(16*1024), uint64(i.maxFrameSize))
@@ -97,8 +135,8 @@ func NewInstance(ctx context.Context, m api.Module) (*Instance, error) {
 		return nil, err
 	}
 
-	go i.recvLoop()
 	go i.sendLoop()
+	go i.recvLoop()
 
 	return i, nil
 }
@@ -111,8 +149,12 @@ func (i *Instance) Close() error {
 			<-i.closed
 		}
 
+		i.stopTimers()
+		i.recvMu.Lock()
+		i.recvDone = true
+		i.recvMu.Unlock()
+		i.recvCond.Broadcast()
 		close(i.sendCh)
-		close(i.recvCh)
 	})
 
 	return nil
@@ -148,7 +190,48 @@ func (i *Instance) getStream(streamID uint32) (proxy.Stream, bool) {
 	}
 }
 
+// SetMimeTypes sets the MIME types of request metadata and data. The
+// guest must use the same types. It must be called before requests are
+// made. Handler functions get them from the context with
+// metadata.MimeTypesFromContext.
+func (i *Instance) SetMimeTypes(mimeTypes metadata.MimeTypes) {
+	i.mimeTypes = mimeTypes
+}
+
+// MimeTypes returns the MIME types of request metadata and data.
+func (i *Instance) MimeTypes() metadata.MimeTypes {
+	return i.mimeTypes
+}
+
+// SetBorrowPayloads makes the payloads of frames from the guest point
+// into pooled buffers instead of copying them. Borrowed payloads,
+// including values decoded from them that share their memory, are only
+// valid until the OnNext callback they are passed to returns, and
+// request payloads until the request ends. Use payload.Retain to keep
+// them. It must be called before requests are made.
+func (i *Instance) SetBorrowPayloads(borrow bool) {
+	i.borrowPayloads = borrow
+}
+
+// SetFrameObserver sets an observer that sees every frame received
+// from and sent to the guest. It must be called before requests are made.
+func (i *Instance) SetFrameObserver(observer tap.FrameObserver) {
+	i.observer = observer
+}
+
 func (i *Instance) SendFrame(f frames.Frame) error {
+	size := f.Size()
+	if 3+size > i.sendSize {
+		// Frames are not fragmented yet, so a frame that does not fit
+		// into the guest buffer fails its stream instead of being
+		// written past the buffer.
+		err := fmt.Errorf("frame of %d bytes exceeds the guest buffer of %d bytes", 3+size, i.sendSize)
+		i.failStream(f, err)
+		return err
+	}
+	if i.observer != nil {
+		i.observer.ObserveFrame(tap.NewEvent(tap.Outbound, f))
+	}
 	// if frag, ok := f.(frames.Fragmentable); ok {
 	// 	if frames := frag.Fragment(i.maxFrameSize); frames != nil {
 	// 		for _, f := range frames {
@@ -160,31 +243,107 @@ func (i *Instance) SendFrame(f frames.Frame) error {
 	// } else {
 	// 	i.sendCh <- f
 	// }
-	i.sendCh <- f
+
+	// Frames are encoded right away so that the payloads they carry
+	// do not have to stay valid until the frame is sent.
+	buf := buffer.Get(3 + int(size))
+	b := buf.Bytes()
+	var lengthBytes [4]byte
+	binary.BigEndian.PutUint32(lengthBytes[:], size)
+	copy(b, lengthBytes[1:4])
+	f.Encode(b[3:])
+	i.sendCh <- buf
 	return nil
 }
 
+// failStream fails the stream of a frame that cannot be sent to the
+// guest. A guest that has the stream open is sent an ERROR or CANCEL
+// frame, and the host side of the stream is cancelled or fails with
+// err.
+func (i *Instance) failStream(f frames.Frame, err error) {
+	streamID := f.GetStreamID()
+	if streamID == 0 {
+		return
+	}
+	str, ok := i.getStream(streamID)
+	if ok {
+		i.removeStream(streamID)
+		if c, ok := str.(DoCancel); ok {
+			c.DoCancel()
+		}
+	}
+
+	if streamID&1 == 1 {
+		// The guest requested the stream.
+		i.SendFrame(&frames.Error{
+			StreamID: streamID,
+			Code:     frames.ErrCodeCanceled,
+			Data:     err.Error(),
+		})
+		return
+	}
+
+	// The host requested the stream.
+	switch f.Type() {
+	case frames.FrameTypeRequestResponse, frames.FrameTypeRequestFNF,
+		frames.FrameTypeRequestStream, frames.FrameTypeRequestChannel:
+	default:
+		i.SendFrame(&frames.Cancel{StreamID: streamID})
+	}
+	if ok {
+		str.OnError(err)
+	}
+}
+
+// HandleFrame handles a frame as if the guest had sent it, so that
+// captures of the instance can be replayed into the host.
+func (i *Instance) HandleFrame(f frames.Frame) error {
+	data := make([]byte, f.Size())
+	f.Encode(data)
+	i.recvOne(data, nil)
+	return nil
+}
+
+// Guest returns the guest of the instance as a target for replaying
+// captures of the instance into it with replay.WithReverse.
+func (i *Instance) Guest() *Guest {
+	return &Guest{i: i}
+}
+
+// Guest is the guest of an instance as a replay target. It sends
+// frames to the guest as is. Once it has an observer, the frames the
+// guest sends are only observed and no longer handled by the host, so
+// that the captured responses of the host are replayed instead.
+type Guest struct {
+	i *Instance
+}
+
+// HandleFrame sends a frame to the guest.
+func (g *Guest) HandleFrame(f frames.Frame) error {
+	return g.i.SendFrame(f)
+}
+
+// SetFrameObserver sets an observer of the frames received from and
+// sent to the guest and detaches the guest from the host.
+func (g *Guest) SetFrameObserver(observer tap.FrameObserver) {
+	g.i.SetFrameObserver(observer)
+	g.i.detached.Store(true)
+}
+
 func (i *Instance) setBuffers(sendPtr, recvPtr uint32) {
 	i.sendPtr = sendPtr
 	i.recvPtr = recvPtr
 }
 
 func (i *Instance) sendLoop() {
-	var lengthBytes [4]byte
 	ctx := context.WithValue(i.ctx, instanceKey{}, i)
 
-	for f := range i.sendCh {
-		mem := i.m.Memory()
-		buf, _ := mem.Read(i.sendPtr, i.sendSize)
-		byteCount := f.Size()
-
-		// Encode length and frame data.
-		binary.BigEndian.PutUint32(lengthBytes[:], byteCount)
-		copy(buf[0:], lengthBytes[1:4])
-		f.Encode(buf[3:])
-
+	for buf := range i.sendCh {
 		// Send frame data to guest.
-		i.sendFn.Call(ctx, uint64(3+byteCount))
+		b := buf.Bytes()
+		i.m.Memory().Write(i.sendPtr, b)
+		buf.Release()
+		i.sendFn.Call(ctx, uint64(len(b)))
 	}
 }
 func (i *Instance) Operations() operations.Table {
@@ -197,32 +356,118 @@ func (i *Instance) opList(ctx context.Context, opPtr uint32, opSize uint32) {
 	i.operations = operations
 }
 
+// hostSend queues the frames the guest has written to its send buffer.
+// They are copied once and handled in order by recvLoop, so the guest
+// does not wait for handlers or for the frames the host sends it.
 func (i *Instance) hostSend(ctx context.Context, recvPos uint32) {
-	buffer, _ := i.m.Memory().Read(i.recvPtr, recvPos)
-
-	for len(buffer) > 0 {
-		var length [4]byte
-		copy(length[1:4], buffer[0:3])
-		buffer = buffer[3:]
-		frameLength := binary.BigEndian.Uint32(length[0:4])
-		buf := make([]byte, int(frameLength))
-		copy(buf, buffer)
-		i.recvCh <- buf
-		buffer = buffer[frameLength:]
+	b, _ := i.m.Memory().Read(i.recvPtr, recvPos)
+	buf := buffer.Get(len(b))
+	copy(buf.Bytes(), b)
+
+	i.recvMu.Lock()
+	if i.recvDone {
+		i.recvMu.Unlock()
+		buf.Release()
+		return
 	}
+	i.received = append(i.received, buf)
+	i.recvMu.Unlock()
+	i.recvCond.Signal()
 }
 
+// recvLoop handles the batches of frames queued by hostSend until the
+// instance is closed.
 func (i *Instance) recvLoop() {
-	for buf := range i.recvCh {
-		i.recvOne(buf)
+	for {
+		i.recvMu.Lock()
+		for len(i.received) == 0 && !i.recvDone {
+			i.recvCond.Wait()
+		}
+		if len(i.received) == 0 {
+			i.recvMu.Unlock()
+			return
+		}
+		buf := i.received[0]
+		i.received[0] = nil
+		i.received = i.received[1:]
+		i.recvMu.Unlock()
+
+		i.recvFrames(buf)
+		buf.Release()
 	}
 }
 
-func (i *Instance) recvOne(data []byte) {
+// recvFrames handles a batch of frames from the guest in order. The
+// frames are decoded in place.
+func (i *Instance) recvFrames(batch *buffer.Pooled) {
+	buf := batch.Bytes()
+	for len(buf) > 0 {
+		if len(buf) < 3 {
+			i.SendFrame(&frames.Error{
+				Code: frames.ErrCodeInvalid,
+				Data: "frame length is truncated",
+			})
+			return
+		}
+		frameLength := int(buf[0])<<16 | int(buf[1])<<8 | int(buf[2])
+		buf = buf[3:]
+		if frameLength > len(buf) {
+			i.SendFrame(&frames.Error{
+				Code: frames.ErrCodeInvalid,
+				Data: "frame length " + strconv.Itoa(frameLength) + " exceeds the remaining " + strconv.Itoa(len(buf)) + " bytes",
+			})
+			return
+		}
+		i.recvOne(buf[:frameLength], batch)
+		buf = buf[frameLength:]
+	}
+}
+
+// recvOne handles a frame from the guest that is part of batch, or of
+// no pooled buffer if batch is nil.
+func (i *Instance) recvOne(data []byte, batch *buffer.Pooled) {
 	ctx := context.Background()
+	if len(data) < frames.FrameHeaderLen {
+		i.SendFrame(frames.InvalidFrame(0, frames.ErrIncompleteHeader))
+		return
+	}
 	header := frames.ParseFrameHeader(data)
+	if i.observer != nil {
+		if f, err := frames.Decode(data); err == nil {
+			i.observer.ObserveFrame(tap.NewEvent(tap.Inbound, f))
+		}
+	}
+	if i.detached.Load() {
+		return
+	}
+
+	// Requests are handled on new goroutines after the batch they
+	// arrived in is released. Borrowed requests hold on to the batch
+	// until the request ends and others are copied.
+	var buf *buffer.Pooled
+	switch header.Type() {
+	case frames.FrameTypeRequestResponse, frames.FrameTypeRequestFNF,
+		frames.FrameTypeRequestStream, frames.FrameTypeRequestChannel:
+		if i.borrowPayloads && batch != nil {
+			batch.Retain()
+			buf = batch
+		} else {
+			data = append([]byte(nil), data...)
+		}
+	}
 	data = data[frames.FrameHeaderLen:]
 
+	if header.Type() == frames.FrameTypeExt {
+		// Extensions need not belong to an active stream.
+		var ext frames.Ext
+		if err := ext.Decode(&header, data); err != nil {
+			i.SendFrame(frames.InvalidFrame(header.StreamID(), err))
+			return
+		}
+		i.handleExt(ctx, &ext)
+		return
+	}
+
 	var str proxy.Stream
 	if header.Type() >= frames.FrameTypeRequestN {
 		var ok bool
@@ -242,10 +487,8 @@ func (i *Instance) recvOne(data []byte) {
 	case frames.FrameTypeRequestResponse:
 		var rr frames.RequestPayload
 		if err := rr.Decode(&header, data); err != nil {
-			i.SendFrame(&frames.Error{
-				StreamID: rr.StreamID,
-				Data:     err.Error(),
-			})
+			releaseBuffer(buf)
+			i.SendFrame(frames.InvalidFrame(header.StreamID(), err))
 			return
 		}
 		if i.checkFollows(&rr) {
@@ -254,15 +497,13 @@ func (i *Instance) recvOne(data []byte) {
 		}
 
 		i.activeRequests.Add(1)
-		go i.handleRequestResponse(ctx, rr.StreamID, rr.Data, rr.Metadata)
+		go i.handleRequestResponse(ctx, rr.StreamID, rr.Data, rr.Metadata, buf)
 
 	case frames.FrameTypeRequestFNF:
 		var rr frames.RequestPayload
 		if err := rr.Decode(&header, data); err != nil {
-			i.SendFrame(&frames.Error{
-				StreamID: rr.StreamID,
-				Data:     err.Error(),
-			})
+			releaseBuffer(buf)
+			i.SendFrame(frames.InvalidFrame(header.StreamID(), err))
 			return
 		}
 		if i.checkFollows(&rr) {
@@ -270,15 +511,13 @@ func (i *Instance) recvOne(data []byte) {
 			return
 		}
 
-		go i.handleFireAndForget(ctx, rr.StreamID, rr.Data, rr.Metadata)
+		go i.handleFireAndForget(ctx, rr.StreamID, rr.Data, rr.Metadata, buf)
 
 	case frames.FrameTypeRequestStream:
 		var rs frames.RequestPayload
 		if err := rs.Decode(&header, data); err != nil {
-			i.SendFrame(&frames.Error{
-				StreamID: rs.StreamID,
-				Data:     err.Error(),
-			})
+			releaseBuffer(buf)
+			i.SendFrame(frames.InvalidFrame(header.StreamID(), err))
 			return
 		}
 		if i.checkFollows(&rs) {
@@ -287,15 +526,17 @@ func (i *Instance) recvOne(data []byte) {
 		}
 
 		i.activeRequests.Add(1)
-		go i.handleRequestStream(ctx, rs.StreamID, rs.Data, rs.Metadata, rs.InitialN)
+		// The stream is registered before its handler starts so that
+		// the REQUEST_N and CANCEL frames that follow reach it.
+		s := &requestStream{streamID: rs.StreamID}
+		i.registerStream(s)
+		go i.handleRequestStream(ctx, s, rs.Data, rs.Metadata, rs.InitialN, buf)
 
 	case frames.FrameTypeRequestChannel:
 		var rc frames.RequestPayload
 		if err := rc.Decode(&header, data); err != nil {
-			i.SendFrame(&frames.Error{
-				StreamID: rc.StreamID,
-				Data:     err.Error(),
-			})
+			releaseBuffer(buf)
+			i.SendFrame(frames.InvalidFrame(header.StreamID(), err))
 			return
 		}
 		if i.checkFollows(&rc) {
@@ -304,15 +545,14 @@ func (i *Instance) recvOne(data []byte) {
 		}
 
 		i.activeRequests.Add(1)
-		go i.handleRequestChannel(ctx, rc.StreamID, rc.Data, rc.Metadata, rc.InitialN)
+		s := &requestStream{streamID: rc.StreamID}
+		i.registerStream(s)
+		go i.handleRequestChannel(ctx, s, rc.Data, rc.Metadata, rc.InitialN, buf)
 
 	case frames.FrameTypeRequestN:
 		var rn frames.RequestN
 		if err := rn.Decode(&header, data); err != nil {
-			i.SendFrame(&frames.Error{
-				StreamID: rn.StreamID,
-				Data:     err.Error(),
-			})
+			i.SendFrame(frames.InvalidFrame(header.StreamID(), err))
 			return
 		}
 
@@ -320,12 +560,17 @@ func (i *Instance) recvOne(data []byte) {
 		go s.DoRequest(int(rn.N))
 
 	case frames.FrameTypeCancel:
-		str.OnComplete()
+		if c, ok := str.(DoCancel); ok {
+			c.DoCancel()
+		} else {
+			str.OnComplete()
+		}
 		i.removeStream(header.StreamID())
 
 	case frames.FrameTypePayload:
 		var p frames.Payload
 		if err := p.Decode(&header, data); err != nil {
+			i.SendFrame(frames.InvalidFrame(header.StreamID(), err))
 			return
 		}
 
@@ -375,7 +620,7 @@ func (i *Instance) recvOne(data []byte) {
 		// }
 
 		if p.Next {
-			str.OnNext(payload.New(p.Data, p.Metadata))
+			str.OnNext(i.newPayload(p.Data, p.Metadata, batch))
 		}
 
 		if p.Complete {
@@ -386,36 +631,35 @@ func (i *Instance) recvOne(data []byte) {
 	case frames.FrameTypeError:
 		var p frames.Error
 		if err := p.Decode(&header, data); err != nil {
+			i.SendFrame(frames.InvalidFrame(header.StreamID(), err))
 			return
 		}
-		str.OnError(errors.New(p.Data))
+		str.OnError(invoke.ParseError(payload.ErrCode(p.Code), p.Data))
 		str.OnComplete()
 		i.removeStream(header.StreamID())
 	}
 }
 
-func (i *Instance) handleRequestResponse(ctx context.Context, streamID uint32, data, metadata []byte) {
-	if !i.checkMetadata(streamID, metadata) {
+func (i *Instance) handleRequestResponse(ctx context.Context, streamID uint32, data, metadata []byte, buf *buffer.Pooled) {
+	release := requestRelease(buf)
+	ctx, operationID, ok := i.operation(ctx, streamID, operations.RequestResponse, metadata)
+	if !ok {
+		release()
+		i.reduceActiveRequests()
 		return
 	}
-
-	operationID := binary.BigEndian.Uint32(metadata)
-	parentStreamID := binary.BigEndian.Uint32(metadata[4:])
-	if parentStreamID != 0 {
-		if stream, ok := i.getStream(parentStreamID); ok {
-			ctx = stream.Context()
-		}
-	}
 	handler := i.getRequestResponseHandler(operationID)
 	if handler == nil {
+		release()
 		i.SendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "not_found",
 		})
 		i.reduceActiveRequests()
 		return
 	}
-	p := payload.New(data, metadata)
+	p := requestPayload(data, metadata, buf)
 	handler(ctx, p).Subscribe(mono.Subscribe[payload.Payload]{
 		OnSuccess: func(p payload.Payload) {
 			i.SendFrame(&frames.Payload{
@@ -425,66 +669,65 @@ func (i *Instance) handleRequestResponse(ctx context.Context, streamID uint32, d
 				Next:     true,
 				Complete: true,
 			})
+			release()
 			i.reduceActiveRequests()
 		},
 		OnError: func(err error) {
-			i.SendFrame(&frames.Error{
-				StreamID: streamID,
-				Data:     err.Error(),
-			})
+			i.SendFrame(frames.NewError(streamID, err))
+			release()
 			i.reduceActiveRequests()
 		},
 	})
 }
 
-func (i *Instance) handleFireAndForget(ctx context.Context, streamID uint32, data, metadata []byte) {
-	if !i.checkMetadata(streamID, metadata) {
-		return
-	}
+func (i *Instance) handleFireAndForget(ctx context.Context, streamID uint32, data, metadata []byte, buf *buffer.Pooled) {
+	// Fire-and-forget handlers must retain the payload to use it after
+	// they return.
+	defer releaseBuffer(buf)
 
-	operationID := binary.BigEndian.Uint32(metadata)
-	parentStreamID := binary.BigEndian.Uint32(metadata[4:])
-	if parentStreamID != 0 {
-		if stream, ok := i.getStream(parentStreamID); ok {
-			ctx = stream.Context()
-		}
+	ctx, operationID, ok := i.operation(ctx, streamID, operations.FireAndForget, metadata)
+	if !ok {
+		return
 	}
 	handler := i.getFireAndForgetHandler(operationID)
 	if handler == nil {
 		i.SendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "not_found",
 		})
 		return
 	}
-	p := payload.New(data, metadata)
+	p := requestPayload(data, metadata, buf)
 	handler(ctx, p)
 }
 
-func (i *Instance) handleRequestStream(ctx context.Context, streamID uint32, data, metadata []byte, initialN uint32) {
-	if !i.checkMetadata(streamID, metadata) {
+func (i *Instance) handleRequestStream(ctx context.Context, s *requestStream, data, metadata []byte, initialN uint32, buf *buffer.Pooled) {
+	streamID := s.streamID
+	release := requestRelease(buf)
+	ctx, operationID, ok := i.operation(ctx, streamID, operations.RequestStream, metadata)
+	if !ok {
+		release()
+		i.removeStream(streamID)
+		i.reduceActiveRequests()
 		return
 	}
-
-	operationID := binary.BigEndian.Uint32(metadata)
-	parentStreamID := binary.BigEndian.Uint32(metadata[4:])
-	if parentStreamID != 0 {
-		if stream, ok := i.getStream(parentStreamID); ok {
-			ctx = stream.Context()
-		}
-	}
 	handlerRS := i.getRequestStreamHandler(operationID)
 	if handlerRS == nil {
+		release()
 		i.SendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "not_found",
 		})
+		i.removeStream(streamID)
+		i.reduceActiveRequests()
 		return
 	}
 
-	p := payload.New(data, metadata)
-	s := requestStream{ctx: ctx, streamID: streamID}
-	f := handlerRS(ctx, p)
+	p := requestPayload(data, metadata, buf)
+	s.start(ctx, release)
+	f := proxy.Responder(handlerRS(ctx, p))
 	f.Subscribe(flux.Subscribe[payload.Payload]{
 		OnNext: func(p payload.Payload) {
 			i.SendFrame(&frames.Payload{
@@ -495,51 +738,55 @@ func (i *Instance) handleRequestStream(ctx context.Context, streamID uint32, dat
 			})
 		},
 		OnComplete: func() {
+			i.removeStream(streamID)
 			i.SendFrame(&frames.Payload{
 				StreamID: streamID,
 				Complete: true,
 			})
+			release()
 			i.reduceActiveRequests()
 		},
 		OnError: func(err error) {
-			i.SendFrame(&frames.Error{
-				StreamID: streamID,
-				Data:     err.Error(),
-			})
+			i.removeStream(streamID)
+			i.SendFrame(frames.NewError(streamID, err))
+			release()
 			i.reduceActiveRequests()
 		},
+		Finally: func(signal rx.SignalType) {
+			if signal == rx.SignalCancel {
+				i.reduceActiveRequests()
+			}
+		},
 		NoRequest: true,
 	})
-	sub := f.Subscription()
-	s.sub = sub
-	s.Request(int(initialN))
+	s.subscribe(f.Subscription(), int(initialN))
 }
 
-func (i *Instance) handleRequestChannel(ctx context.Context, streamID uint32, data, metadata []byte, initialN uint32) {
-	if !i.checkMetadata(streamID, metadata) {
+func (i *Instance) handleRequestChannel(ctx context.Context, s *requestStream, data, metadata []byte, initialN uint32, buf *buffer.Pooled) {
+	streamID := s.streamID
+	release := requestRelease(buf)
+	ctx, operationID, ok := i.operation(ctx, streamID, operations.RequestChannel, metadata)
+	if !ok {
+		release()
+		i.removeStream(streamID)
+		i.reduceActiveRequests()
 		return
 	}
-
-	operationID := binary.BigEndian.Uint32(metadata)
-	parentStreamID := binary.BigEndian.Uint32(metadata[4:])
-	if parentStreamID != 0 {
-		if stream, ok := i.getStream(parentStreamID); ok {
-			ctx = stream.Context()
-		}
-	}
 	handlerRC := i.getRequestChannelHandler(operationID)
 	if handlerRC == nil {
+		release()
 		i.SendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "not_found",
 		})
+		i.removeStream(streamID)
 		i.reduceActiveRequests()
 		return
 	}
 
-	p := payload.New(data, metadata)
-	s := requestStream{streamID: streamID}
-	i.registerStream(&s) // Need to register for RequestN frames
+	p := requestPayload(data, metadata, buf)
+	s.start(ctx, release)
 	in := flux.Create(func(sink flux.Sink[payload.Payload]) {
 		s.sink = sink
 		sink.OnSubscribe(flux.OnSubscribe{
@@ -557,7 +804,7 @@ func (i *Instance) handleRequestChannel(ctx context.Context, streamID uint32, da
 			},
 		})
 	})
-	f := handlerRC(ctx, p, in)
+	f := proxy.Responder(handlerRC(ctx, p, in))
 	f.Subscribe(flux.Subscribe[payload.Payload]{
 		OnNext: func(p payload.Payload) {
 			i.SendFrame(&frames.Payload{
@@ -572,20 +819,17 @@ func (i *Instance) handleRequestChannel(ctx context.Context, streamID uint32, da
 				StreamID: streamID,
 				Complete: true,
 			})
+			release()
 			i.reduceActiveRequests()
 		},
 		OnError: func(err error) {
-			i.SendFrame(&frames.Error{
-				StreamID: streamID,
-				Data:     err.Error(),
-			})
+			i.SendFrame(frames.NewError(streamID, err))
+			release()
 			i.reduceActiveRequests()
 		},
 		NoRequest: true,
 	})
-	sub := f.Subscription()
-	s.sub = sub
-	s.Request(int(initialN))
+	s.subscribe(f.Subscription(), int(initialN))
 }
 
 func (i *Instance) SetRequestResponseHandler(index uint32, handler invoke.RequestResponseHandler) {
@@ -644,10 +888,111 @@ func (i *Instance) getRequestChannelHandler(index uint32) invoke.RequestChannelH
 	return i.importedRC[index]
 }
 
+// SetExtensionHandler sets the handler of the EXT frames with
+// extendedType that the guest sends. It must be called before
+// requests are made. Payloads point into guest memory.
+func (i *Instance) SetExtensionHandler(extendedType uint32, handler invoke.ExtensionHandler) {
+	if i.extensions == nil {
+		i.extensions = make(map[uint32]invoke.ExtensionHandler)
+	}
+	i.extensions[extendedType] = handler
+}
+
+// SendExtension sends an EXT frame with extendedType to the guest on a
+// stream, or on stream 0 for the instance. If ignore is true, guests
+// that do not support the extension drop the frame instead of
+// rejecting it.
+func (i *Instance) SendExtension(streamID, extendedType uint32, p payload.Payload, ignore bool) error {
+	return i.SendFrame(&frames.Ext{
+		StreamID:     streamID,
+		ExtendedType: extendedType,
+		Metadata:     p.Metadata(),
+		Data:         p.Data(),
+		Ignore:       ignore,
+	})
+}
+
+// handleExt passes an EXT frame to the handler of its extended type.
+// Unsupported extensions are dropped if the IGNORE flag is set and
+// rejected otherwise.
+func (i *Instance) handleExt(ctx context.Context, f *frames.Ext) {
+	handler, ok := i.extensions[f.ExtendedType]
+	if !ok {
+		if !f.Ignore {
+			i.SendFrame(frames.UnsupportedExt(f))
+		}
+		return
+	}
+	handler(ctx, f.StreamID, payload.New(f.Data, f.Metadata))
+}
+
+// operation resolves the imported operation a request from the guest
+// invokes and returns the context to handle it with. Plain metadata
+// starts with the import index and the ID of the parent stream.
+// Composite metadata carries the import index or a route to the
+// import, as with handler.Handler.
+func (i *Instance) operation(ctx context.Context, streamID uint32, requestType operations.RequestType, md []byte) (context.Context, uint32, bool) {
+	if i.mimeTypes.Metadata != "" {
+		ctx = metadata.WithMimeTypes(ctx, i.mimeTypes)
+	}
+	if i.mimeTypes.Metadata != metadata.MimeTypeComposite {
+		if !i.checkMetadata(streamID, md) {
+			return ctx, 0, false
+		}
+		parentStreamID := binary.BigEndian.Uint32(md[4:])
+		if parentStreamID != 0 {
+			if stream, ok := i.getStream(parentStreamID); ok {
+				ctx = stream.Context()
+			}
+		}
+		return ctx, binary.BigEndian.Uint32(md), true
+	}
+
+	c, err := metadata.ParseComposite(md)
+	if err != nil {
+		i.SendFrame(&frames.Error{
+			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
+			Data:     err.Error(),
+		})
+		return ctx, 0, false
+	}
+	if index, ok := c.OperationIndex(); ok {
+		return ctx, index, true
+	}
+	if route, ok := c.Route(); ok {
+		if namespace, operation, ok := metadata.SplitRoute(route); ok {
+			return ctx, i.importIndex(requestType, namespace, operation), true
+		}
+	}
+
+	i.SendFrame(&frames.Error{
+		StreamID: streamID,
+		Code:     frames.ErrCodeInvalid,
+		Data:     "Invalid metadata",
+	})
+	return ctx, 0, false
+}
+
+// importIndex returns the index of an operation the guest imports or
+// math.MaxUint32 if there is no such import.
+func (i *Instance) importIndex(requestType operations.RequestType, namespace, operation string) uint32 {
+	for _, op := range i.operations {
+		if op.Direction == operations.Import &&
+			op.Type == requestType &&
+			op.Namespace == namespace &&
+			op.Operation == operation {
+			return op.Index
+		}
+	}
+	return math.MaxUint32
+}
+
 func (i *Instance) checkMetadata(streamID uint32, metadata []byte) bool {
 	if len(metadata) < 8 { // 48 before... but why?
 		i.SendFrame(&frames.Error{
 			StreamID: streamID,
+			Code:     frames.ErrCodeInvalid,
 			Data:     "Invalid metadata",
 		})
 		return false
@@ -670,21 +1015,85 @@ func (i *Instance) checkFollows(requestFrame *frames.RequestPayload) bool {
 	return false
 }
 
+// newPayload returns the payload of a frame from the guest in batch.
+func (i *Instance) newPayload(data, metadata []byte, batch *buffer.Pooled) payload.Payload {
+	if !i.borrowPayloads {
+		return payload.New(append([]byte(nil), data...), append([]byte(nil), metadata...))
+	}
+	if batch == nil {
+		return payload.Borrow(data, metadata, nil)
+	}
+	return payload.Borrow(data, metadata, batch)
+}
+
+// requestPayload returns the payload of a request that is borrowed
+// from buf, or owned if buf is nil.
+func requestPayload(data, metadata []byte, buf *buffer.Pooled) payload.Payload {
+	if buf == nil {
+		return payload.New(data, metadata)
+	}
+	return payload.Borrow(data, metadata, buf)
+}
+
+// requestRelease returns a function that releases the buffer of a
+// request once. Borrowed requests are valid until the request ends.
+func requestRelease(buf *buffer.Pooled) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			releaseBuffer(buf)
+		})
+	}
+}
+
+func releaseBuffer(buf *buffer.Pooled) {
+	if buf != nil {
+		buf.Release()
+	}
+}
+
 type DoRequest interface {
 	DoRequest(n int)
 }
 
+type DoCancel interface {
+	DoCancel()
+}
+
 type requestStream struct {
-	ctx      context.Context
 	streamID uint32
 	flux.Sink[payload.Payload]
-	sub  rx.Subscription
 	sink flux.Sink[payload.Payload]
+
+	mu  sync.Mutex
+	ctx context.Context
+	sub rx.Subscription
+	// pending counts the items requested before the subscription to
+	// the handler was made.
+	pending   int
+	cancelled bool
+	// release releases the request buffer once the stream ends.
+	release func()
 }
 
 var _ = (proxy.Stream)((*requestStream)(nil))
 
+// start sets the context of the handler of the stream and the function
+// that releases the request buffer.
+func (r *requestStream) start(ctx context.Context, release func()) {
+	r.mu.Lock()
+	r.ctx = ctx
+	r.release = release
+	cancelled := r.cancelled
+	r.mu.Unlock()
+	if cancelled {
+		release()
+	}
+}
+
 func (r *requestStream) Context() context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	return r.ctx
 }
 
@@ -692,12 +1101,56 @@ func (r *requestStream) StreamID() uint32 {
 	return r.streamID
 }
 
+// subscribe passes the initial request and the REQUEST_N and CANCEL
+// frames that arrived before it to sub.
+func (r *requestStream) subscribe(sub rx.Subscription, initialN int) {
+	r.mu.Lock()
+	r.sub = sub
+	n := addRequest(initialN, r.pending)
+	cancelled := r.cancelled
+	r.mu.Unlock()
+	if cancelled {
+		sub.Cancel()
+		return
+	}
+	sub.Request(n)
+}
+
 func (r *requestStream) Request(n int) {
-	r.sub.Request(n)
+	r.mu.Lock()
+	sub := r.sub
+	if sub == nil {
+		r.pending = addRequest(r.pending, n)
+	}
+	r.mu.Unlock()
+	if sub != nil {
+		sub.Request(n)
+	}
 }
 
 func (r *requestStream) DoRequest(n int) {
-	r.sub.Request(n)
+	r.Request(n)
+}
+
+func (r *requestStream) DoCancel() {
+	r.mu.Lock()
+	r.cancelled = true
+	sub, release := r.sub, r.release
+	r.mu.Unlock()
+	if sub != nil {
+		sub.Cancel()
+	}
+	if release != nil {
+		release()
+	}
+}
+
+// addRequest adds requests for n items, up to rx.RequestMax.
+func addRequest(requested, n int) int {
+	if n >= rx.RequestMax-requested {
+		return rx.RequestMax
+	}
+	return requested + n
 }
 
 func (r *requestStream) OnNext(p payload.Payload) {
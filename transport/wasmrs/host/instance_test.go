@@ -0,0 +1,372 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/internal/frames"
+	"github.com/nanobus/iota/go/metadata"
+	"github.com/nanobus/iota/go/operations"
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/rx/flux"
+	"github.com/nanobus/iota/go/rx/mono"
+	"github.com/nanobus/iota/go/rx/rxtest"
+	"github.com/nanobus/iota/go/tap"
+	"github.com/nanobus/iota/go/tap/replay"
+)
+
+var greeterOps = operations.Table{
+	{Index: 0, Type: operations.RequestResponse, Direction: operations.Import, Namespace: "greeter", Operation: "hello"},
+	{Index: 1, Type: operations.RequestResponse, Direction: operations.Import, Namespace: "greeter", Operation: "bye"},
+}
+
+// greet handles the imports of greeterOps.
+func greet(i *Instance) {
+	for _, op := range greeterOps {
+		greeting := op.Operation
+		i.SetRequestResponseHandler(op.Index, func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+			return mono.Just[payload.Payload](payload.New([]byte(greeting+" "+string(p.Data())), nil))
+		})
+	}
+}
+
+func request(streamID uint32, md []byte, data string) *frames.RequestPayload {
+	return &frames.RequestPayload{
+		FrameType: frames.FrameTypeRequestResponse,
+		StreamID:  streamID,
+		Metadata:  md,
+		Data:      []byte(data),
+		Complete:  true,
+		InitialN:  1,
+	}
+}
+
+// response is the PAYLOAD frame of a request-response with data.
+func response(streamID uint32, data string) *frames.Payload {
+	return &frames.Payload{
+		StreamID: streamID,
+		Metadata: []byte{},
+		Data:     []byte(data),
+		Next:     true,
+		Complete: true,
+	}
+}
+
+func plainMetadata(index uint32) []byte {
+	md := make([]byte, 8)
+	binary.BigEndian.PutUint32(md, index)
+	return md
+}
+
+func compositeMetadata(t *testing.T, b *metadata.Builder) []byte {
+	md, err := b.Build()
+	require.NoError(t, err)
+	return md
+}
+
+func TestInstancePlainMetadata(t *testing.T) {
+	g := newFakeGuest(t, greeterOps)
+	greet(g.i)
+
+	g.send(request(1, plainMetadata(1), "Ann"))
+	assert.Equal(t, response(1, "bye Ann"), g.next())
+
+	g.send(request(3, []byte{0, 0, 0, 1}, "Ann"))
+	f := g.next().(*frames.Error)
+	assert.Equal(t, frames.ErrCodeInvalid, f.Code)
+	assert.Equal(t, "Invalid metadata", f.Data)
+}
+
+func TestInstanceCompositeMetadata(t *testing.T) {
+	g := newFakeGuest(t, greeterOps)
+	g.i.SetMimeTypes(metadata.MimeTypes{Metadata: metadata.MimeTypeComposite})
+	greet(g.i)
+
+	g.send(request(1, compositeMetadata(t, metadata.NewBuilder().Route("greeter/hello")), "Ann"))
+	assert.Equal(t, response(1, "hello Ann"), g.next())
+
+	g.send(request(3, compositeMetadata(t, metadata.NewBuilder().OperationIndex(1)), "Bob"))
+	assert.Equal(t, response(3, "bye Bob"), g.next())
+
+	g.send(request(5, compositeMetadata(t, metadata.NewBuilder().Route("greeter/unknown")), "Cy"))
+	assert.Equal(t, &frames.Error{StreamID: 5, Code: frames.ErrCodeInvalid, Data: "not_found"}, g.next())
+
+	g.send(request(7, compositeMetadata(t, metadata.NewBuilder().BearerToken("token")), "Cy"))
+	assert.Equal(t, &frames.Error{StreamID: 7, Code: frames.ErrCodeInvalid, Data: "Invalid metadata"}, g.next())
+}
+
+func TestInstanceCompositeMimeTypesInContext(t *testing.T) {
+	g := newFakeGuest(t, greeterOps)
+	mimeTypes := metadata.MimeTypes{Metadata: metadata.MimeTypeComposite}
+	g.i.SetMimeTypes(mimeTypes)
+	got := make(chan metadata.MimeTypes, 1)
+	g.i.SetRequestResponseHandler(0, func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+		m, _ := metadata.MimeTypesFromContext(ctx)
+		got <- m
+		return mono.Just[payload.Payload](payload.New(nil, nil))
+	})
+
+	g.send(request(1, compositeMetadata(t, metadata.NewBuilder().Route("greeter/hello")), ""))
+	g.next()
+	assert.Equal(t, mimeTypes, <-got)
+}
+
+func TestInstanceFrameLargerThanGuestBuffer(t *testing.T) {
+	g := newFakeGuest(t, greeterOps)
+	large := make([]byte, 2*g.i.sendSize)
+	g.i.SetRequestResponseHandler(0, func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+		return mono.Just[payload.Payload](payload.New(large, nil))
+	})
+	sentinel := []byte("sentinel")
+	g.mem.Write(guestBuffer+g.i.sendSize, sentinel)
+
+	// The response to the guest fails its request.
+	g.send(request(1, plainMetadata(0), "Ann"))
+	f := g.next().(*frames.Error)
+	assert.Equal(t, uint32(1), f.StreamID)
+	assert.Equal(t, frames.ErrCodeCanceled, f.Code)
+	assert.Contains(t, f.Data, "exceeds the guest buffer")
+
+	// A request to the guest fails on the host.
+	_, err := g.i.RequestResponse(context.Background(), payload.New(large, plainMetadata(0))).Block()
+	assert.ErrorContains(t, err, "exceeds the guest buffer")
+	g.none()
+
+	b, _ := g.mem.Read(guestBuffer+g.i.sendSize, uint32(len(sentinel)))
+	assert.Equal(t, sentinel, b)
+}
+
+func TestInstanceGuestDoesNotWaitForHost(t *testing.T) {
+	g := newFakeGuest(t, greeterOps)
+	const n = 2 * 100 // twice the capacity of the send queue
+	var unsupported []frames.Frame
+	for len(unsupported) < n {
+		unsupported = append(unsupported, &frames.Ext{ExtendedType: 1000})
+	}
+	// The guest answers the first frame it receives with extensions
+	// that are all rejected while the host is still sending to it.
+	first := true
+	g.onReceive = func(f frames.Frame) {
+		if first {
+			first = false
+			g.send(unsupported...)
+		}
+	}
+
+	g.i.SendExtension(0, 1000, payload.New(nil, nil), true)
+	g.next()
+	for range unsupported {
+		assert.IsType(t, &frames.Error{}, g.next())
+	}
+}
+
+// lazyGreeter handles import 0 with a greeting that is sent once
+// greet is closed.
+func lazyGreeter(i *Instance, greet chan struct{}) {
+	i.SetRequestResponseHandler(0, func(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
+		return mono.Create(func(sink mono.Sink[payload.Payload]) {
+			go func() {
+				<-greet
+				sink.Success(payload.New([]byte("hello "+string(p.Data())), nil))
+			}()
+		})
+	})
+}
+
+func TestInstanceRequestsOutliveTheirBatch(t *testing.T) {
+	for _, borrow := range []bool{false, true} {
+		g := newFakeGuest(t, greeterOps)
+		g.i.SetBorrowPayloads(borrow)
+		greet := make(chan struct{})
+		lazyGreeter(g.i, greet)
+
+		g.send(request(1, plainMetadata(0), "Ann"))
+		// Later batches reuse the memory of the first one unless the
+		// request holds on to it.
+		for streamID := uint32(3); streamID < 13; streamID += 2 {
+			g.send(request(streamID, plainMetadata(0), "Bob"))
+		}
+		close(greet)
+
+		responses := map[uint32]string{}
+		for len(responses) < 6 {
+			f := g.next().(*frames.Payload)
+			responses[f.StreamID] = string(f.Data)
+		}
+		assert.Equal(t, "hello Ann", responses[1], "borrow=%v", borrow)
+		assert.Equal(t, "hello Bob", responses[11], "borrow=%v", borrow)
+	}
+}
+
+// respond makes the guest answer request-response requests with the
+// data transformed by fn.
+func (g *fakeGuest) respond(fn func([]byte) []byte) {
+	g.onReceive = func(f frames.Frame) {
+		if r, ok := f.(*frames.RequestPayload); ok && r.FrameType == frames.FrameTypeRequestResponse {
+			g.send(&frames.Payload{StreamID: r.StreamID, Data: fn(r.Data), Next: true, Complete: true})
+		}
+	}
+}
+
+func TestInstanceReplayIntoGuest(t *testing.T) {
+	ctx := context.Background()
+
+	// Capture the requests of the host to a guest.
+	var out bytes.Buffer
+	recorder := tap.NewRecorder(&out)
+	g := newFakeGuest(t, nil)
+	g.respond(bytes.ToUpper)
+	g.i.SetFrameObserver(recorder)
+	for _, name := range []string{"ann", "bob"} {
+		p, err := g.i.RequestResponse(ctx, payload.New([]byte(name), plainMetadata(0))).Block()
+		require.NoError(t, err)
+		assert.Equal(t, strings.ToUpper(name), string(p.Data()))
+	}
+	require.NoError(t, recorder.Close())
+	capture, err := tap.ReadAll(&out)
+	require.NoError(t, err)
+
+	// The guest responds as captured.
+	g = newFakeGuest(t, nil)
+	g.respond(bytes.ToUpper)
+	result, err := replay.Run(ctx, g.i.Guest(), capture, replay.WithReverse(), replay.WithTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+	assert.Len(t, result.Expected, 2)
+	assert.Len(t, result.Actual, 2)
+	assert.Empty(t, result.Differences)
+
+	// The guest responds differently to bob.
+	g = newFakeGuest(t, nil)
+	g.respond(func(b []byte) []byte {
+		if string(b) == "bob" {
+			return []byte("Bob")
+		}
+		return bytes.ToUpper(b)
+	})
+	result, err = replay.Run(ctx, g.i.Guest(), capture, replay.WithReverse(), replay.WithTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+	require.Len(t, result.Differences, 1)
+	assert.Equal(t, uint32(4), result.Differences[0].StreamID)
+}
+
+// counter handles import 0 with a stream of count payloads that are
+// all emitted at once.
+func counter(i *Instance, count int) {
+	i.SetRequestStreamHandler(0, func(ctx context.Context, p payload.Payload) flux.Flux[payload.Payload] {
+		return flux.Create(func(sink flux.Sink[payload.Payload]) {
+			for n := 0; n < count; n++ {
+				sink.Next(payload.New([]byte{byte(n)}, nil))
+			}
+			sink.Complete()
+		})
+	})
+}
+
+func TestInstanceRequestStreamRequestN(t *testing.T) {
+	g := newFakeGuest(t, operations.Table{
+		{Index: 0, Type: operations.RequestStream, Direction: operations.Import, Namespace: "counter", Operation: "count"},
+	})
+	counter(g.i, 5)
+
+	// The REQUEST_N frame in the same batch as the request adds to
+	// its initial request.
+	g.send(&frames.RequestPayload{
+		FrameType: frames.FrameTypeRequestStream,
+		StreamID:  1,
+		Metadata:  plainMetadata(0),
+		InitialN:  2,
+	}, &frames.RequestN{StreamID: 1, N: 1})
+	for n := 0; n < 3; n++ {
+		f := g.next().(*frames.Payload)
+		assert.Equal(t, []byte{byte(n)}, f.Data)
+		assert.False(t, f.Complete)
+	}
+	g.none()
+
+	g.send(&frames.RequestN{StreamID: 1, N: 2})
+	for n := 3; n < 5; n++ {
+		assert.Equal(t, []byte{byte(n)}, g.next().(*frames.Payload).Data)
+	}
+	assert.True(t, g.next().(*frames.Payload).Complete)
+}
+
+func TestInstanceRequestStreamCancel(t *testing.T) {
+	g := newFakeGuest(t, operations.Table{
+		{Index: 0, Type: operations.RequestStream, Direction: operations.Import, Namespace: "counter", Operation: "count"},
+	})
+	counter(g.i, 5)
+
+	g.send(&frames.RequestPayload{
+		FrameType: frames.FrameTypeRequestStream,
+		StreamID:  1,
+		Metadata:  plainMetadata(0),
+		InitialN:  1,
+	})
+	assert.Equal(t, []byte{0}, g.next().(*frames.Payload).Data)
+	g.send(&frames.Cancel{StreamID: 1})
+	g.none()
+
+	// The cancelled stream no longer holds up Close.
+	closed := make(chan struct{})
+	go func() {
+		g.i.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close waits for the cancelled stream")
+	}
+}
+
+func TestInstanceRequestStreamToGuest(t *testing.T) {
+	g := newFakeGuest(t, nil)
+	streamID := uint32(0)
+
+	rxtest.Flux(g.i.RequestStream(context.Background(), payload.New([]byte("Ann"), plainMetadata(0)))).
+		ThenRequest(2).
+		Then(func() {
+			f := g.next().(*frames.RequestPayload)
+			assert.Equal(t, frames.FrameTypeRequestStream, f.FrameType)
+			assert.Equal(t, uint32(2), f.InitialN)
+			streamID = f.StreamID
+			g.send(
+				&frames.Payload{StreamID: streamID, Data: []byte("a"), Next: true},
+				&frames.Payload{StreamID: streamID, Data: []byte("b"), Next: true},
+			)
+		}).
+		ExpectNextMatches(func(p payload.Payload) bool { return string(p.Data()) == "a" }).
+		ExpectNextMatches(func(p payload.Payload) bool { return string(p.Data()) == "b" }).
+		ThenCancel().
+		Verify(t)
+
+	assert.Equal(t, &frames.Cancel{StreamID: streamID}, g.next())
+}
+
+func TestInstanceApplicationErrorDecoded(t *testing.T) {
+	g := newFakeGuest(t, nil)
+	// The data of an apperror, encoded by hand so that the test does
+	// not import apperror and its decoder.
+	data := []byte{0x82}
+	for _, s := range []string{"code", "invalid_argument", "message", "name is required"} {
+		data = append(data, 0xa0|byte(len(s)))
+		data = append(data, s...)
+	}
+	g.onReceive = func(f frames.Frame) {
+		if r, ok := f.(*frames.RequestPayload); ok {
+			g.send(&frames.Error{StreamID: r.StreamID, Code: frames.ErrCodeApplicationError, Data: string(data)})
+		}
+	}
+
+	_, err := g.i.RequestResponse(context.Background(), payload.New(nil, plainMetadata(0))).Block()
+	assert.Equal(t, "*apperror.Error", fmt.Sprintf("%T", err))
+	assert.EqualError(t, err, "name is required")
+}
@@ -0,0 +1,85 @@
+package host
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nanobus/iota/go/payload"
+	"github.com/nanobus/iota/go/transport/wasmrs/timer"
+)
+
+// timers are the timers that the guest scheduler armed.
+type timers struct {
+	mu     sync.Mutex
+	active map[uint32]*time.Timer
+	closed bool
+	// firing counts the timers that are telling the guest they fired.
+	firing sync.WaitGroup
+}
+
+// scheduleTimer arms a timer that tells the guest when it fires.
+func (i *Instance) scheduleTimer(_ context.Context, _ uint32, p payload.Payload) {
+	id, delay, err := timer.DecodeSchedule(p.Data())
+	if err != nil {
+		return
+	}
+	t := &i.timers
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	if t.active == nil {
+		t.active = make(map[uint32]*time.Timer)
+	}
+	if prev, ok := t.active[id]; ok {
+		prev.Stop()
+	}
+	t.active[id] = time.AfterFunc(delay, func() {
+		i.fireTimer(id)
+	})
+}
+
+func (i *Instance) cancelTimer(_ context.Context, _ uint32, p payload.Payload) {
+	id, err := timer.DecodeID(p.Data())
+	if err != nil {
+		return
+	}
+	t := &i.timers
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if active, ok := t.active[id]; ok {
+		active.Stop()
+		delete(t.active, id)
+	}
+}
+
+// fireTimer tells the guest that a timer fired.
+func (i *Instance) fireTimer(id uint32) {
+	t := &i.timers
+	t.mu.Lock()
+	if _, ok := t.active[id]; !ok || t.closed {
+		t.mu.Unlock()
+		return
+	}
+	delete(t.active, id)
+	t.firing.Add(1)
+	t.mu.Unlock()
+	defer t.firing.Done()
+	i.SendExtension(0, timer.ExtFired, payload.New(timer.EncodeID(id)), false)
+}
+
+// stopTimers stops the timers once the instance closes and waits for
+// the timers that already fired to be sent.
+func (i *Instance) stopTimers() {
+	t := &i.timers
+	t.mu.Lock()
+	t.closed = true
+	for id, active := range t.active {
+		active.Stop()
+		delete(t.active, id)
+	}
+	t.mu.Unlock()
+	t.firing.Wait()
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/rodaine/table"
 
+	"github.com/nanobus/iota/go/metadata"
 	"github.com/nanobus/iota/go/operations"
 	"github.com/nanobus/iota/go/payload"
 	"github.com/nanobus/iota/go/rx/flux"
@@ -21,6 +22,7 @@ import (
 type (
 	Mesh struct {
 		verbose     bool
+		mimeTypes   metadata.MimeTypes
 		instances   map[string]*host.Instance
 		exports     map[string]map[string]*atomic.Pointer[destination]
 		unsatisfied []*pending
@@ -45,6 +47,14 @@ func WithVerbose() Option {
 	}
 }
 
+// WithMimeTypes sets the MIME types of request metadata and data of the
+// loaded modules. See host.Instance.SetMimeTypes.
+func WithMimeTypes(mimeTypes metadata.MimeTypes) Option {
+	return func(m *Mesh) {
+		m.mimeTypes = mimeTypes
+	}
+}
+
 func New(opts ...Option) *Mesh {
 	m := Mesh{
 		instances:   make(map[string]*host.Instance),
@@ -153,6 +163,7 @@ func (m *Mesh) LoadModule(ctx context.Context, filename string) (*host.Instance,
 	if err != nil {
 		return nil, err
 	}
+	inst.SetMimeTypes(m.mimeTypes)
 
 	// Close previously loaded instance.
 	existing, ok := m.instances[filename]
@@ -261,33 +272,59 @@ func (m *Mesh) linkOperation(inst *host.Instance, op operations.Operation) bool
 }
 
 func (d *destination) RequestResponse(ctx context.Context, p payload.Payload) mono.Mono[payload.Payload] {
-	md := p.Metadata()
-	if md != nil {
-		binary.BigEndian.PutUint32(md, d.index)
-	}
+	p = payload.New(p.Data(), d.metadata(ctx, p.Metadata()))
 	return d.instance.RequestResponse(ctx, p)
 }
 
 func (d *destination) FireAndForget(ctx context.Context, p payload.Payload) {
-	md := p.Metadata()
-	if md != nil {
-		binary.BigEndian.PutUint32(md, d.index)
-	}
+	p = payload.New(p.Data(), d.metadata(ctx, p.Metadata()))
 	d.instance.FireAndForget(ctx, p)
 }
 
 func (d *destination) RequestStream(ctx context.Context, p payload.Payload) flux.Flux[payload.Payload] {
-	md := p.Metadata()
-	if md != nil {
-		binary.BigEndian.PutUint32(md, d.index)
-	}
+	p = payload.New(p.Data(), d.metadata(ctx, p.Metadata()))
 	return d.instance.RequestStream(ctx, p)
 }
 
 func (d *destination) RequestChannel(ctx context.Context, p payload.Payload, in flux.Flux[payload.Payload]) flux.Flux[payload.Payload] {
-	md := p.Metadata()
-	if md != nil {
-		binary.BigEndian.PutUint32(md, d.index)
-	}
+	p = payload.New(p.Data(), d.metadata(ctx, p.Metadata()))
 	return d.instance.RequestChannel(ctx, p, in)
 }
+
+// metadata returns the request metadata that invokes the export of d.
+// The caller's metadata is converted to the format of the instance, so
+// instances with plain and composite metadata can be linked together.
+// Composite metadata keeps its other entries.
+func (d *destination) metadata(ctx context.Context, md []byte) []byte {
+	caller, _ := metadata.MimeTypesFromContext(ctx)
+	var entries metadata.Composite
+	if caller.Metadata == metadata.MimeTypeComposite {
+		c, err := metadata.ParseComposite(md)
+		if err != nil {
+			return md
+		}
+		entries = c
+	}
+
+	if d.instance.MimeTypes().Metadata != metadata.MimeTypeComposite {
+		if caller.Metadata == metadata.MimeTypeComposite {
+			md = make([]byte, 8)
+		}
+		if md != nil {
+			binary.BigEndian.PutUint32(md, d.index)
+		}
+		return md
+	}
+
+	b := metadata.NewBuilder().OperationIndex(d.index)
+	for _, e := range entries {
+		if e.MimeType != metadata.MimeTypeOperationIndex {
+			b.Add(e.MimeType, e.Content)
+		}
+	}
+	composite, err := b.Build()
+	if err != nil {
+		return md
+	}
+	return composite
+}
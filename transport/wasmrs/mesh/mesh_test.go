@@ -0,0 +1,62 @@
+package mesh
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/metadata"
+	"github.com/nanobus/iota/go/transport/wasmrs/host"
+)
+
+func TestDestinationMetadata(t *testing.T) {
+	plain := metadata.MimeTypes{}
+	composite := metadata.MimeTypes{Metadata: metadata.MimeTypeComposite}
+	destination := func(mimeTypes metadata.MimeTypes) *destination {
+		var inst host.Instance
+		inst.SetMimeTypes(mimeTypes)
+		return &destination{instance: &inst, index: 7}
+	}
+	caller := func(mimeTypes metadata.MimeTypes) context.Context {
+		return metadata.WithMimeTypes(context.Background(), mimeTypes)
+	}
+	token := metadata.BearerAuthentication("token")
+	callerMetadata, err := metadata.NewBuilder().
+		OperationIndex(2).
+		Route("greeter/hello").
+		Authentication(token).
+		Build()
+	require.NoError(t, err)
+
+	// Plain to plain keeps the index rewrite of the baseline.
+	md := destination(plain).metadata(caller(plain), []byte{0, 0, 0, 2, 0, 0, 0, 3})
+	assert.Equal(t, []byte{0, 0, 0, 7, 0, 0, 0, 3}, md)
+
+	// Composite to plain.
+	md = destination(plain).metadata(caller(composite), callerMetadata)
+	assert.Equal(t, []byte{0, 0, 0, 7, 0, 0, 0, 0}, md)
+
+	// Plain to composite.
+	md = destination(composite).metadata(caller(plain), []byte{0, 0, 0, 2, 0, 0, 0, 0})
+	c, err := metadata.ParseComposite(md)
+	require.NoError(t, err)
+	index, ok := c.OperationIndex()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(7), index)
+
+	// Composite to composite keeps the other entries.
+	md = destination(composite).metadata(caller(composite), callerMetadata)
+	c, err = metadata.ParseComposite(md)
+	require.NoError(t, err)
+	index, ok = c.OperationIndex()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(7), index)
+	route, ok := c.Route()
+	assert.True(t, ok)
+	assert.Equal(t, "greeter/hello", route)
+	auth, ok := c.Authentication()
+	assert.True(t, ok)
+	assert.Equal(t, token, auth)
+}
@@ -0,0 +1,64 @@
+// Package timer defines the EXT frames that guests use to run tasks on
+// timers that the host provides. Guests cannot sleep between calls, so
+// the host arms the timers and tells the guest when they fire.
+package timer
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const (
+	// ExtSchedule asks the host to arm a timer. The guest sends it on
+	// stream 0 with the data of EncodeSchedule.
+	ExtSchedule uint32 = 0x0100
+	// ExtCancel asks the host to stop a timer. The guest sends it on
+	// stream 0 with the data of EncodeID.
+	ExtCancel uint32 = 0x0101
+	// ExtFired tells the guest that a timer fired. The host sends it on
+	// stream 0 with the data of EncodeID.
+	ExtFired uint32 = 0x0102
+)
+
+// ErrInvalid is returned for data that is too short.
+var ErrInvalid = errors.New("invalid timer data")
+
+// EncodeSchedule encodes the ID of a timer and its delay.
+func EncodeSchedule(id uint32, delay time.Duration) []byte {
+	if delay < 0 {
+		delay = 0
+	}
+	data := make([]byte, 12)
+	binary.BigEndian.PutUint32(data, id)
+	binary.BigEndian.PutUint64(data[4:], uint64(delay))
+	return data
+}
+
+// DecodeSchedule decodes the data of EncodeSchedule.
+func DecodeSchedule(data []byte) (id uint32, delay time.Duration, err error) {
+	if len(data) < 12 {
+		return 0, 0, ErrInvalid
+	}
+	id = binary.BigEndian.Uint32(data)
+	delay = time.Duration(binary.BigEndian.Uint64(data[4:]))
+	if delay < 0 {
+		return 0, 0, ErrInvalid
+	}
+	return id, delay, nil
+}
+
+// EncodeID encodes the ID of a timer.
+func EncodeID(id uint32) []byte {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, id)
+	return data
+}
+
+// DecodeID decodes the data of EncodeID.
+func DecodeID(data []byte) (uint32, error) {
+	if len(data) < 4 {
+		return 0, ErrInvalid
+	}
+	return binary.BigEndian.Uint32(data), nil
+}
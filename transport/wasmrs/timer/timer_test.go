@@ -0,0 +1,37 @@
+package timer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanobus/iota/go/transport/wasmrs/timer"
+)
+
+func TestSchedule(t *testing.T) {
+	id, delay, err := timer.DecodeSchedule(timer.EncodeSchedule(7, 1500*time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7), id)
+	assert.Equal(t, 1500*time.Millisecond, delay)
+
+	// Negative delays are due right away.
+	_, delay, err = timer.DecodeSchedule(timer.EncodeSchedule(7, -time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, delay)
+
+	_, _, err = timer.DecodeSchedule(make([]byte, 11))
+	assert.ErrorIs(t, err, timer.ErrInvalid)
+	_, _, err = timer.DecodeSchedule([]byte{0, 0, 0, 1, 0xFF, 0, 0, 0, 0, 0, 0, 0})
+	assert.ErrorIs(t, err, timer.ErrInvalid)
+}
+
+func TestID(t *testing.T) {
+	id, err := timer.DecodeID(timer.EncodeID(42))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(42), id)
+
+	_, err = timer.DecodeID([]byte{1, 2, 3})
+	assert.ErrorIs(t, err, timer.ErrInvalid)
+}